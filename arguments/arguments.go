@@ -97,4 +97,8 @@ type Args struct {
 	VolumeDriver        string
 	VolumesFrom         []string
 	Workdir             string
+
+	// EntrypointCleared is set when --entrypoint is passed an empty value to
+	// clear the image entrypoint, as an empty Entrypoint means it is not set
+	EntrypointCleared bool
 }
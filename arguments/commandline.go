@@ -0,0 +1,158 @@
+package arguments
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// shellSafeWord matches words that do not need to be quoted in a POSIX shell
+var shellSafeWord = regexp.MustCompile(`^[a-zA-Z0-9@%+=:,./_-]+$`)
+
+// lineContinuation matches a backslash-newline pair as written in shell
+// scripts and READMEs to split a long command over several lines
+var lineContinuation = regexp.MustCompile(`\\\r?\n`)
+
+// ParseCommandLine tokenizes a literal `docker run ...` command line and
+// returns the arguments that follow the run subcommand, ready to be parsed by
+// the export flag set. Tokenization uses the same shellwords rules the
+// converters apply to --entrypoint and --health-cmd. `$VAR` references are
+// kept verbatim so that the target format can interpolate them.
+func ParseCommandLine(line string) ([]string, error) {
+	line = lineContinuation.ReplaceAllString(line, " ")
+
+	parser := shellwords.NewParser()
+	words, err := parser.Parse(line)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse command line: %w", err)
+	}
+	if parser.Position >= 0 {
+		return nil, fmt.Errorf("unable to parse command line: unsupported shell operator at offset %d", parser.Position)
+	}
+
+	return stripDockerRun(words)
+}
+
+// ParseCommandLines splits text holding one `docker run ...` command per line
+// and parses each command with ParseCommandLine. A command may span several
+// lines with backslash continuations or quoted values holding newlines. Blank
+// lines and lines starting with `#` are skipped.
+func ParseCommandLines(text string) ([][]string, error) {
+	var commands [][]string
+	var pending []string
+	start := 0
+	for i, line := range strings.Split(text, "\n") {
+		if len(pending) == 0 {
+			trimmed := strings.TrimSpace(line)
+			if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			start = i + 1
+		}
+
+		pending = append(pending, line)
+		if strings.HasSuffix(strings.TrimRight(line, "\r"), "\\") || hasOpenQuote(strings.Join(pending, "\n")) {
+			continue
+		}
+
+		words, err := ParseCommandLine(strings.Join(pending, "\n"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+		commands = append(commands, words)
+		pending = nil
+	}
+
+	if len(pending) > 0 {
+		if hasOpenQuote(strings.Join(pending, "\n")) {
+			return nil, fmt.Errorf("line %d: unable to parse command line: unterminated quoted string", start)
+		}
+		return nil, fmt.Errorf("line %d: unable to parse command line: unterminated line continuation", start)
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("unable to parse command line: empty command")
+	}
+
+	return commands, nil
+}
+
+// hasOpenQuote reports whether text ends inside a single or double quoted
+// string, following the shell rules for backslash escapes
+func hasOpenQuote(text string) bool {
+	inSingle, inDouble, escaped := false, false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inSingle:
+			inSingle = r != '\''
+		case r == '\\':
+			escaped = true
+		case inDouble:
+			inDouble = r != '"'
+		case r == '\'':
+			inSingle = true
+		case r == '"':
+			inDouble = true
+		}
+	}
+
+	return inSingle || inDouble
+}
+
+// stripDockerRun removes the leading `docker run` or `docker container run`
+// words (optionally preceded by a `$` prompt or `sudo`) from a tokenized
+// command line
+func stripDockerRun(words []string) ([]string, error) {
+	for len(words) > 0 && (words[0] == "$" || words[0] == "sudo") {
+		words = words[1:]
+	}
+
+	if len(words) == 0 {
+		return nil, fmt.Errorf("unable to parse command line: empty command")
+	}
+
+	if filepath.Base(words[0]) != "docker" {
+		return nil, fmt.Errorf("unable to parse command line: expected a docker command, got %q", words[0])
+	}
+	words = words[1:]
+
+	if len(words) > 0 && words[0] == "container" {
+		words = words[1:]
+	}
+
+	if len(words) == 0 || words[0] != "run" {
+		return nil, fmt.Errorf("unable to parse command line: expected `docker run` or `docker container run`, got %q", strings.Join(words, " "))
+	}
+
+	return words[1:], nil
+}
+
+// IsShellSafeWord reports whether a word can be written to a POSIX shell
+// command line without quoting
+func IsShellSafeWord(value string) bool {
+	return shellSafeWord.MatchString(value)
+}
+
+// ShellQuote quotes a single word for a POSIX shell, using single quotes so
+// that no expansion takes place. ParseCommandLine reads the word back as is.
+func ShellQuote(value string) string {
+	if IsShellSafeWord(value) {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// ShellJoin quotes each word for a POSIX shell and joins them with spaces
+func ShellJoin(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, ShellQuote(word))
+	}
+	return strings.Join(quoted, " ")
+}
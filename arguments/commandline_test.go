@@ -0,0 +1,168 @@
+package arguments
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseCommandLine verifies that literal docker run command lines, as
+// copied out of READMEs and shell history, are tokenized into the arguments
+// that follow the run subcommand.
+func TestParseCommandLine(t *testing.T) {
+	testCases := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "docker_run",
+			line: "docker run -p 8080:80 nginx:latest",
+			want: []string{"-p", "8080:80", "nginx:latest"},
+		},
+		{
+			name: "docker_container_run",
+			line: "docker container run --rm alpine:latest echo hello",
+			want: []string{"--rm", "alpine:latest", "echo", "hello"},
+		},
+		{
+			name: "prompt_and_sudo",
+			line: "$ sudo /usr/bin/docker run alpine:latest",
+			want: []string{"alpine:latest"},
+		},
+		{
+			name: "line_continuations",
+			line: "docker run \\\n  -e FOO=bar \\\r\n  --name web \\\n  nginx:latest",
+			want: []string{"-e", "FOO=bar", "--name", "web", "nginx:latest"},
+		},
+		{
+			name: "quoting",
+			line: `docker run --health-cmd "curl -f http://localhost/" -l 'com.example.desc=a b' alpine:latest sh -c "echo hi"`,
+			want: []string{"--health-cmd", "curl -f http://localhost/", "-l", "com.example.desc=a b", "alpine:latest", "sh", "-c", "echo hi"},
+		},
+		{
+			name: "env_references_kept_verbatim",
+			line: "docker run -e TOKEN=$TOKEN -e HOME=${HOME} alpine:latest",
+			want: []string{"-e", "TOKEN=$TOKEN", "-e", "HOME=${HOME}", "alpine:latest"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseCommandLine(tc.line)
+			if err != nil {
+				t.Fatalf("ParseCommandLine returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseCommandLine(%q) = %q, want %q", tc.line, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestParseCommandLine_Errors verifies that input which is not a single
+// docker run invocation is rejected.
+func TestParseCommandLine_Errors(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"ls -la",
+		"docker ps",
+		"docker container ls",
+		"docker run alpine:latest && echo done",
+		`docker run -e "FOO=bar alpine:latest`,
+	} {
+		t.Run(line, func(t *testing.T) {
+			if got, err := ParseCommandLine(line); err == nil {
+				t.Errorf("ParseCommandLine(%q) = %q, want error", line, got)
+			}
+		})
+	}
+}
+
+// TestParseCommandLines verifies that text holding several docker run
+// commands is split into one argument list per command.
+func TestParseCommandLines(t *testing.T) {
+	text := "# stack\n" +
+		"docker run --name redis redis:7\n" +
+		"\n" +
+		"docker run --name web \\\n" +
+		"  --link redis:cache \\\n" +
+		"  nginx:latest\n"
+
+	got, err := ParseCommandLines(text)
+	if err != nil {
+		t.Fatalf("ParseCommandLines returned error: %v", err)
+	}
+
+	want := [][]string{
+		{"--name", "redis", "redis:7"},
+		{"--name", "web", "--link", "redis:cache", "nginx:latest"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCommandLines() = %q, want %q", got, want)
+	}
+}
+
+// TestParseCommandLines_QuotedNewlines verifies that newlines inside quoted
+// values do not end the command.
+func TestParseCommandLines_QuotedNewlines(t *testing.T) {
+	text := "docker run -e \"A=line1\nline2\" --name web nginx:latest\n" +
+		"docker run -l 'desc=it\nworks' alpine:latest sh -c \"echo \\\"a\n b\\\"\"\n" +
+		"docker run -e B=it\\'s redis:7\n"
+
+	got, err := ParseCommandLines(text)
+	if err != nil {
+		t.Fatalf("ParseCommandLines returned error: %v", err)
+	}
+
+	want := [][]string{
+		{"-e", "A=line1\nline2", "--name", "web", "nginx:latest"},
+		{"-l", "desc=it\nworks", "alpine:latest", "sh", "-c", "echo \"a\n b\""},
+		{"-e", "B=it's", "redis:7"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCommandLines() = %q, want %q", got, want)
+	}
+}
+
+// TestParseCommandLines_Errors verifies that errors report the line of the
+// offending command.
+func TestParseCommandLines_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "empty",
+			text: "\n# nothing here\n",
+			want: "unable to parse command line: empty command",
+		},
+		{
+			name: "invalid_command",
+			text: "docker run redis:7\n\ndocker ps\n",
+			want: "line 3: unable to parse command line: expected `docker run` or `docker container run`, got \"ps\"",
+		},
+		{
+			name: "unterminated_quote",
+			text: "docker run redis:7\ndocker run -e \"A=b\nredis:7\n",
+			want: "line 2: unable to parse command line: unterminated quoted string",
+		},
+		{
+			name: "unterminated_continuation",
+			text: "docker run redis:7\ndocker run \\",
+			want: "line 2: unable to parse command line: unterminated line continuation",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCommandLines(tc.text)
+			if err == nil {
+				t.Fatalf("ParseCommandLines(%q) returned no error", tc.text)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("ParseCommandLines(%q) error = %q, want %q", tc.text, err.Error(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,753 @@
+package arguments
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultCapabilities is the capability set docker grants every container.
+// Adding one of these with --cap-add is a no-op, so they are dropped when
+// they show up in an inspected container's HostConfig.CapAdd.
+var defaultCapabilities = map[string]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"NET_RAW":          true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// defaultShmSize is the /dev/shm size docker uses when --shm-size is unset
+const defaultShmSize = 64 * 1024 * 1024
+
+// inspectObject holds the subset of a `docker inspect` document used to
+// rebuild a docker run command. Container and image documents share the
+// same shape for the fields we read.
+type inspectObject struct {
+	ID              string                 `json:"Id"`
+	Name            string                 `json:"Name"`
+	Image           string                 `json:"Image"`
+	RepoTags        []string               `json:"RepoTags"`
+	Config          *inspectConfig         `json:"Config"`
+	HostConfig      *inspectHostConfig     `json:"HostConfig"`
+	NetworkSettings *inspectNetworkSetting `json:"NetworkSettings"`
+}
+
+type inspectConfig struct {
+	Hostname     string              `json:"Hostname"`
+	Domainname   string              `json:"Domainname"`
+	User         string              `json:"User"`
+	Tty          bool                `json:"Tty"`
+	OpenStdin    bool                `json:"OpenStdin"`
+	Env          []string            `json:"Env"`
+	Cmd          []string            `json:"Cmd"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	Image        string              `json:"Image"`
+	WorkingDir   string              `json:"WorkingDir"`
+	Labels       map[string]string   `json:"Labels"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	Volumes      map[string]struct{} `json:"Volumes"`
+	Healthcheck  *inspectHealthcheck `json:"Healthcheck"`
+	MacAddress   string              `json:"MacAddress"`
+	StopSignal   string              `json:"StopSignal"`
+	StopTimeout  *int                `json:"StopTimeout"`
+}
+
+type inspectHealthcheck struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval"`
+	Timeout     int64    `json:"Timeout"`
+	StartPeriod int64    `json:"StartPeriod"`
+	Retries     int      `json:"Retries"`
+}
+
+type inspectHostConfig struct {
+	Annotations          map[string]string               `json:"Annotations"`
+	AutoRemove           bool                            `json:"AutoRemove"`
+	Binds                []string                        `json:"Binds"`
+	BlkioWeight          int                             `json:"BlkioWeight"`
+	BlkioWeightDevice    []inspectWeightDevice           `json:"BlkioWeightDevice"`
+	BlkioDeviceReadBps   []inspectThrottleDevice         `json:"BlkioDeviceReadBps"`
+	BlkioDeviceReadIOps  []inspectThrottleDevice         `json:"BlkioDeviceReadIOps"`
+	BlkioDeviceWriteBps  []inspectThrottleDevice         `json:"BlkioDeviceWriteBps"`
+	BlkioDeviceWriteIOps []inspectThrottleDevice         `json:"BlkioDeviceWriteIOps"`
+	CapAdd               []string                        `json:"CapAdd"`
+	CapDrop              []string                        `json:"CapDrop"`
+	CgroupnsMode         string                          `json:"CgroupnsMode"`
+	CgroupParent         string                          `json:"CgroupParent"`
+	CpuPeriod            int64                           `json:"CpuPeriod"`
+	CpuQuota             int64                           `json:"CpuQuota"`
+	CpuRealtimePeriod    int64                           `json:"CpuRealtimePeriod"`
+	CpuRealtimeRuntime   int64                           `json:"CpuRealtimeRuntime"`
+	CpuShares            int64                           `json:"CpuShares"`
+	CpusetCpus           string                          `json:"CpusetCpus"`
+	CpusetMems           string                          `json:"CpusetMems"`
+	DeviceCgroupRules    []string                        `json:"DeviceCgroupRules"`
+	DeviceRequests       []inspectDeviceRequest          `json:"DeviceRequests"`
+	Devices              []inspectDevice                 `json:"Devices"`
+	Dns                  []string                        `json:"Dns"`
+	DnsOptions           []string                        `json:"DnsOptions"`
+	DnsSearch            []string                        `json:"DnsSearch"`
+	ExtraHosts           []string                        `json:"ExtraHosts"`
+	GroupAdd             []string                        `json:"GroupAdd"`
+	Init                 *bool                           `json:"Init"`
+	IpcMode              string                          `json:"IpcMode"`
+	Isolation            string                          `json:"Isolation"`
+	KernelMemory         int64                           `json:"KernelMemory"`
+	Links                []string                        `json:"Links"`
+	LogConfig            inspectLogConfig                `json:"LogConfig"`
+	Memory               int64                           `json:"Memory"`
+	MemoryReservation    int64                           `json:"MemoryReservation"`
+	MemorySwap           int64                           `json:"MemorySwap"`
+	MemorySwappiness     *int64                          `json:"MemorySwappiness"`
+	Mounts               []inspectMount                  `json:"Mounts"`
+	NanoCpus             int64                           `json:"NanoCpus"`
+	NetworkMode          string                          `json:"NetworkMode"`
+	OomKillDisable       *bool                           `json:"OomKillDisable"`
+	OomScoreAdj          int                             `json:"OomScoreAdj"`
+	PidMode              string                          `json:"PidMode"`
+	PidsLimit            *int64                          `json:"PidsLimit"`
+	PortBindings         map[string][]inspectPortBinding `json:"PortBindings"`
+	Privileged           bool                            `json:"Privileged"`
+	PublishAllPorts      bool                            `json:"PublishAllPorts"`
+	ReadonlyRootfs       bool                            `json:"ReadonlyRootfs"`
+	RestartPolicy        inspectRestartPolicy            `json:"RestartPolicy"`
+	Runtime              string                          `json:"Runtime"`
+	SecurityOpt          []string                        `json:"SecurityOpt"`
+	ShmSize              int64                           `json:"ShmSize"`
+	StorageOpt           map[string]string               `json:"StorageOpt"`
+	Sysctls              map[string]string               `json:"Sysctls"`
+	Tmpfs                map[string]string               `json:"Tmpfs"`
+	Ulimits              []inspectUlimit                 `json:"Ulimits"`
+	UsernsMode           string                          `json:"UsernsMode"`
+	UTSMode              string                          `json:"UTSMode"`
+	VolumeDriver         string                          `json:"VolumeDriver"`
+	VolumesFrom          []string                        `json:"VolumesFrom"`
+}
+
+type inspectWeightDevice struct {
+	Path   string `json:"Path"`
+	Weight int    `json:"Weight"`
+}
+
+type inspectThrottleDevice struct {
+	Path string `json:"Path"`
+	Rate int64  `json:"Rate"`
+}
+
+type inspectDeviceRequest struct {
+	Driver       string     `json:"Driver"`
+	Count        int        `json:"Count"`
+	DeviceIDs    []string   `json:"DeviceIDs"`
+	Capabilities [][]string `json:"Capabilities"`
+}
+
+type inspectDevice struct {
+	PathOnHost        string `json:"PathOnHost"`
+	PathInContainer   string `json:"PathInContainer"`
+	CgroupPermissions string `json:"CgroupPermissions"`
+}
+
+type inspectLogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config"`
+}
+
+type inspectMount struct {
+	Type          string                `json:"Type"`
+	Source        string                `json:"Source"`
+	Target        string                `json:"Target"`
+	ReadOnly      bool                  `json:"ReadOnly"`
+	BindOptions   *inspectBindOptions   `json:"BindOptions"`
+	VolumeOptions *inspectVolumeOptions `json:"VolumeOptions"`
+	TmpfsOptions  *inspectTmpfsOptions  `json:"TmpfsOptions"`
+}
+
+type inspectBindOptions struct {
+	Propagation string `json:"Propagation"`
+}
+
+type inspectVolumeOptions struct {
+	NoCopy       bool              `json:"NoCopy"`
+	Labels       map[string]string `json:"Labels"`
+	DriverConfig *inspectLogConfig `json:"DriverConfig"`
+}
+
+type inspectTmpfsOptions struct {
+	SizeBytes int64 `json:"SizeBytes"`
+	Mode      int64 `json:"Mode"`
+}
+
+type inspectPortBinding struct {
+	HostIp   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type inspectRestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount"`
+}
+
+type inspectUlimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+type inspectNetworkSetting struct {
+	Networks map[string]inspectEndpoint `json:"Networks"`
+}
+
+type inspectEndpoint struct {
+	Aliases    []string             `json:"Aliases"`
+	IPAMConfig *inspectEndpointIPAM `json:"IPAMConfig"`
+}
+
+type inspectEndpointIPAM struct {
+	IPv4Address  string   `json:"IPv4Address"`
+	IPv6Address  string   `json:"IPv6Address"`
+	LinkLocalIPs []string `json:"LinkLocalIPs"`
+}
+
+// ParseDockerInspect rebuilds the docker run arguments (flags, image and
+// command) for the container described by a `docker inspect` JSON document.
+// Engine defaults are dropped so that only operator-set options remain. If
+// the document also contains the inspected image (for example from
+// `docker inspect web $(docker inspect -f '{{.Image}}' web)`), env, labels,
+// command and other settings inherited from the image are dropped as well.
+func ParseDockerInspect(data []byte) ([]string, error) {
+	var objects []inspectObject
+	if err := json.Unmarshal(data, &objects); err != nil {
+		var object inspectObject
+		if objErr := json.Unmarshal(data, &object); objErr != nil {
+			return nil, fmt.Errorf("unable to parse docker inspect output: %w", err)
+		}
+		objects = []inspectObject{object}
+	}
+
+	var containers []inspectObject
+	images := map[string]inspectObject{}
+	for _, object := range objects {
+		if object.HostConfig != nil {
+			containers = append(containers, object)
+		} else if object.Config != nil {
+			images[object.ID] = object
+		}
+	}
+
+	if len(containers) != 1 {
+		return nil, fmt.Errorf("unable to parse docker inspect output: expected exactly one container, found %d", len(containers))
+	}
+
+	container := containers[0]
+	if container.Config == nil {
+		return nil, fmt.Errorf("unable to parse docker inspect output: container %s has no Config", container.Name)
+	}
+
+	image := &inspectConfig{}
+	if object, ok := images[container.Image]; ok {
+		image = object.Config
+	}
+
+	return inspectToArgs(container, image), nil
+}
+
+// inspectToArgs converts an inspected container into docker run arguments,
+// omitting any values that match the engine defaults or the image config
+func inspectToArgs(container inspectObject, image *inspectConfig) []string {
+	cfg := container.Config
+	hc := container.HostConfig
+	args := []string{}
+
+	flag := func(name string, value string) {
+		args = append(args, fmt.Sprintf("--%s=%s", name, value))
+	}
+	boolFlag := func(name string, value bool) {
+		if value {
+			args = append(args, fmt.Sprintf("--%s", name))
+		}
+	}
+	stringFlag := func(name string, value string) {
+		if len(value) > 0 {
+			flag(name, value)
+		}
+	}
+	intFlag := func(name string, value int64) {
+		if value != 0 {
+			flag(name, strconv.FormatInt(value, 10))
+		}
+	}
+	listFlag := func(name string, values []string) {
+		for _, value := range values {
+			flag(name, value)
+		}
+	}
+	mapFlag := func(name string, values map[string]string) {
+		for _, key := range sortedKeys(values) {
+			flag(name, fmt.Sprintf("%s=%s", key, values[key]))
+		}
+	}
+
+	name := strings.TrimPrefix(container.Name, "/")
+	stringFlag("name", name)
+
+	// config
+	if len(container.ID) < 12 || cfg.Hostname != container.ID[:12] {
+		stringFlag("hostname", cfg.Hostname)
+	}
+	stringFlag("domainname", cfg.Domainname)
+	if cfg.User != image.User {
+		stringFlag("user", cfg.User)
+	}
+	boolFlag("tty", cfg.Tty)
+	boolFlag("interactive", cfg.OpenStdin)
+	listFlag("env", subtractList(cfg.Env, image.Env, func(env string) bool {
+		return strings.HasPrefix(env, "PATH=")
+	}))
+	if cfg.WorkingDir != image.WorkingDir {
+		stringFlag("workdir", cfg.WorkingDir)
+	}
+	mapFlag("label", subtractMap(cfg.Labels, image.Labels))
+	stringFlag("mac-address", cfg.MacAddress)
+	if cfg.StopSignal != image.StopSignal && cfg.StopSignal != "SIGTERM" {
+		stringFlag("stop-signal", cfg.StopSignal)
+	}
+	if cfg.StopTimeout != nil {
+		intFlag("stop-timeout", int64(*cfg.StopTimeout))
+	}
+
+	if cfg.Healthcheck != nil && !healthcheckEqual(cfg.Healthcheck, image.Healthcheck) {
+		hc := cfg.Healthcheck
+		if len(hc.Test) > 0 {
+			switch hc.Test[0] {
+			case "NONE":
+				boolFlag("no-healthcheck", true)
+			case "CMD-SHELL":
+				stringFlag("health-cmd", strings.Join(hc.Test[1:], " "))
+			case "CMD":
+				stringFlag("health-cmd", ShellJoin(hc.Test[1:]))
+			}
+		}
+		if hc.Interval > 0 {
+			flag("health-interval", time.Duration(hc.Interval).String())
+		}
+		if hc.Timeout > 0 {
+			flag("health-timeout", time.Duration(hc.Timeout).String())
+		}
+		if hc.StartPeriod > 0 {
+			flag("health-start-period", time.Duration(hc.StartPeriod).String())
+		}
+		intFlag("health-retries", int64(hc.Retries))
+	}
+
+	publishedPorts := map[string]bool{}
+	for port := range hc.PortBindings {
+		publishedPorts[port] = true
+	}
+	var exposed []string
+	for port := range cfg.ExposedPorts {
+		if _, ok := image.ExposedPorts[port]; ok || publishedPorts[port] {
+			continue
+		}
+		exposed = append(exposed, strings.TrimSuffix(port, "/tcp"))
+	}
+	sort.Strings(exposed)
+	listFlag("expose", exposed)
+
+	var anonymousVolumes []string
+	for volume := range cfg.Volumes {
+		if _, ok := image.Volumes[volume]; !ok {
+			anonymousVolumes = append(anonymousVolumes, volume)
+		}
+	}
+	sort.Strings(anonymousVolumes)
+
+	// host config
+	mapFlag("annotation", hc.Annotations)
+	boolFlag("rm", hc.AutoRemove)
+	listFlag("volume", append(append([]string{}, hc.Binds...), anonymousVolumes...))
+	intFlag("blkio-weight", int64(hc.BlkioWeight))
+	for _, device := range hc.BlkioWeightDevice {
+		flag("blkio-weight-device", fmt.Sprintf("%s:%d", device.Path, device.Weight))
+	}
+	for _, device := range hc.BlkioDeviceReadBps {
+		flag("device-read-bps", fmt.Sprintf("%s:%d", device.Path, device.Rate))
+	}
+	for _, device := range hc.BlkioDeviceReadIOps {
+		flag("device-read-iops", fmt.Sprintf("%s:%d", device.Path, device.Rate))
+	}
+	for _, device := range hc.BlkioDeviceWriteBps {
+		flag("device-write-bps", fmt.Sprintf("%s:%d", device.Path, device.Rate))
+	}
+	for _, device := range hc.BlkioDeviceWriteIOps {
+		flag("device-write-iops", fmt.Sprintf("%s:%d", device.Path, device.Rate))
+	}
+	for _, capability := range hc.CapAdd {
+		if !defaultCapabilities[strings.TrimPrefix(strings.ToUpper(capability), "CAP_")] {
+			flag("cap-add", capability)
+		}
+	}
+	listFlag("cap-drop", hc.CapDrop)
+	if hc.CgroupnsMode != "private" {
+		stringFlag("cgroupns", hc.CgroupnsMode)
+	}
+	stringFlag("cgroup-parent", hc.CgroupParent)
+	intFlag("cpu-period", hc.CpuPeriod)
+	intFlag("cpu-quota", hc.CpuQuota)
+	intFlag("cpu-rt-period", hc.CpuRealtimePeriod)
+	intFlag("cpu-rt-runtime", hc.CpuRealtimeRuntime)
+	intFlag("cpu-shares", hc.CpuShares)
+	if hc.NanoCpus > 0 {
+		flag("cpus", strconv.FormatFloat(float64(hc.NanoCpus)/1e9, 'f', -1, 64))
+	}
+	stringFlag("cpuset-cpus", hc.CpusetCpus)
+	stringFlag("cpuset-mems", hc.CpusetMems)
+	listFlag("device-cgroup-rule", hc.DeviceCgroupRules)
+	for _, request := range hc.DeviceRequests {
+		if gpus := deviceRequestToGpus(request); len(gpus) > 0 {
+			flag("gpus", gpus)
+		}
+	}
+	for _, device := range hc.Devices {
+		value := device.PathOnHost
+		if device.PathInContainer != device.PathOnHost || (device.CgroupPermissions != "" && device.CgroupPermissions != "rwm") {
+			value = fmt.Sprintf("%s:%s", value, device.PathInContainer)
+		}
+		if device.CgroupPermissions != "" && device.CgroupPermissions != "rwm" {
+			value = fmt.Sprintf("%s:%s", value, device.CgroupPermissions)
+		}
+		flag("device", value)
+	}
+	listFlag("dns", hc.Dns)
+	listFlag("dns-option", hc.DnsOptions)
+	listFlag("dns-search", hc.DnsSearch)
+	listFlag("add-host", hc.ExtraHosts)
+	listFlag("group-add", hc.GroupAdd)
+	if hc.Init != nil {
+		boolFlag("init", *hc.Init)
+	}
+	if hc.IpcMode != "private" && hc.IpcMode != "shareable" {
+		stringFlag("ipc", hc.IpcMode)
+	}
+	if hc.Isolation != "default" {
+		stringFlag("isolation", hc.Isolation)
+	}
+	intFlag("kernel-memory", hc.KernelMemory)
+	for _, link := range hc.Links {
+		flag("link", inspectLinkToFlag(link))
+	}
+	if hc.LogConfig.Type != "json-file" || len(hc.LogConfig.Config) > 0 {
+		stringFlag("log-driver", hc.LogConfig.Type)
+	}
+	mapFlag("log-opt", hc.LogConfig.Config)
+	intFlag("memory", hc.Memory)
+	intFlag("memory-reservation", hc.MemoryReservation)
+	if hc.MemorySwap != hc.Memory*2 {
+		intFlag("memory-swap", hc.MemorySwap)
+	}
+	if hc.MemorySwappiness != nil && *hc.MemorySwappiness >= 0 {
+		intFlag("memory-swappiness", *hc.MemorySwappiness)
+	}
+	for _, mount := range hc.Mounts {
+		flag("mount", inspectMountToFlag(mount))
+	}
+	if hc.OomKillDisable != nil {
+		boolFlag("oom-kill-disable", *hc.OomKillDisable)
+	}
+	intFlag("oom-score-adj", int64(hc.OomScoreAdj))
+	stringFlag("pid", hc.PidMode)
+	if hc.PidsLimit != nil && *hc.PidsLimit > 0 {
+		intFlag("pids-limit", *hc.PidsLimit)
+	}
+	for _, port := range sortedPortKeys(hc.PortBindings) {
+		for _, binding := range hc.PortBindings[port] {
+			flag("publish", inspectPortToFlag(port, binding))
+		}
+	}
+	boolFlag("privileged", hc.Privileged)
+	boolFlag("publish-all", hc.PublishAllPorts)
+	boolFlag("read-only", hc.ReadonlyRootfs)
+	switch hc.RestartPolicy.Name {
+	case "", "no":
+	case "on-failure":
+		if hc.RestartPolicy.MaximumRetryCount > 0 {
+			flag("restart", fmt.Sprintf("on-failure:%d", hc.RestartPolicy.MaximumRetryCount))
+		} else {
+			flag("restart", "on-failure")
+		}
+	default:
+		flag("restart", hc.RestartPolicy.Name)
+	}
+	if hc.Runtime != "runc" {
+		stringFlag("runtime", hc.Runtime)
+	}
+	listFlag("security-opt", hc.SecurityOpt)
+	if hc.ShmSize != defaultShmSize {
+		intFlag("shm-size", hc.ShmSize)
+	}
+	mapFlag("storage-opt", hc.StorageOpt)
+	mapFlag("sysctl", hc.Sysctls)
+	for _, target := range sortedKeys(hc.Tmpfs) {
+		if len(hc.Tmpfs[target]) > 0 {
+			flag("tmpfs", fmt.Sprintf("%s:%s", target, hc.Tmpfs[target]))
+		} else {
+			flag("tmpfs", target)
+		}
+	}
+	for _, ulimit := range hc.Ulimits {
+		flag("ulimit", fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+	stringFlag("userns", hc.UsernsMode)
+	stringFlag("uts", hc.UTSMode)
+	stringFlag("volume-driver", hc.VolumeDriver)
+	listFlag("volumes-from", hc.VolumesFrom)
+
+	// network settings
+	switch hc.NetworkMode {
+	case "", "default", "bridge":
+	default:
+		flag("network", hc.NetworkMode)
+	}
+	if container.NetworkSettings != nil {
+		if endpoint, ok := container.NetworkSettings.Networks[hc.NetworkMode]; ok {
+			for _, alias := range endpoint.Aliases {
+				if alias == name || (len(container.ID) >= 12 && alias == container.ID[:12]) {
+					continue
+				}
+				flag("network-alias", alias)
+			}
+			if endpoint.IPAMConfig != nil {
+				stringFlag("ip", endpoint.IPAMConfig.IPv4Address)
+				stringFlag("ip6", endpoint.IPAMConfig.IPv6Address)
+				listFlag("link-local-ip", endpoint.IPAMConfig.LinkLocalIPs)
+			}
+		}
+	}
+
+	// entrypoint, image and command
+	// docker stores --entrypoint "" as [""] to clear the image entrypoint
+	entrypoint := cfg.Entrypoint
+	entrypointCleared := len(entrypoint) == 1 && entrypoint[0] == ""
+	if entrypointCleared {
+		entrypoint = nil
+	}
+	entrypointChanged := entrypointCleared || !stringsEqual(entrypoint, image.Entrypoint)
+	if entrypointChanged {
+		if len(entrypoint) == 0 {
+			flag("entrypoint", "")
+		} else {
+			flag("entrypoint", ShellJoin(entrypoint))
+		}
+	}
+
+	imageName := cfg.Image
+	if len(imageName) == 0 {
+		imageName = container.Image
+	}
+	args = append(args, imageName)
+
+	// docker resets the image CMD whenever the entrypoint is overridden
+	if entrypointChanged || !stringsEqual(cfg.Cmd, image.Cmd) {
+		args = append(args, cfg.Cmd...)
+	}
+
+	return args
+}
+
+// deviceRequestToGpus converts a GPU device request back into a --gpus value
+func deviceRequestToGpus(request inspectDeviceRequest) string {
+	isGpu := false
+	for _, capabilities := range request.Capabilities {
+		for _, capability := range capabilities {
+			if capability == "gpu" {
+				isGpu = true
+			}
+		}
+	}
+	if !isGpu {
+		return ""
+	}
+
+	if len(request.DeviceIDs) > 0 {
+		return fmt.Sprintf("device=%s", strings.Join(request.DeviceIDs, ","))
+	}
+	if request.Count < 0 {
+		return "all"
+	}
+	return strconv.Itoa(request.Count)
+}
+
+// inspectLinkToFlag converts a HostConfig link (e.g. "/redis:/web/db") into
+// a --link value (e.g. "redis:db")
+func inspectLinkToFlag(link string) string {
+	source, alias := extractLinkParts(link)
+	source = strings.TrimPrefix(source, "/")
+	if idx := strings.LastIndex(alias, "/"); idx >= 0 {
+		alias = alias[idx+1:]
+	}
+	if len(alias) == 0 || alias == source {
+		return source
+	}
+	return fmt.Sprintf("%s:%s", source, alias)
+}
+
+func extractLinkParts(link string) (string, string) {
+	parts := strings.SplitN(link, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// inspectMountToFlag converts a HostConfig mount into a --mount value
+func inspectMountToFlag(mount inspectMount) string {
+	parts := []string{}
+	if len(mount.Type) > 0 {
+		parts = append(parts, fmt.Sprintf("type=%s", mount.Type))
+	}
+	if len(mount.Source) > 0 {
+		parts = append(parts, fmt.Sprintf("source=%s", mount.Source))
+	}
+	parts = append(parts, fmt.Sprintf("target=%s", mount.Target))
+	if mount.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	if mount.BindOptions != nil && len(mount.BindOptions.Propagation) > 0 {
+		parts = append(parts, fmt.Sprintf("bind-propagation=%s", mount.BindOptions.Propagation))
+	}
+	if mount.VolumeOptions != nil {
+		if mount.VolumeOptions.NoCopy {
+			parts = append(parts, "volume-nocopy")
+		}
+		for _, key := range sortedKeys(mount.VolumeOptions.Labels) {
+			parts = append(parts, fmt.Sprintf("volume-label=%s=%s", key, mount.VolumeOptions.Labels[key]))
+		}
+		if mount.VolumeOptions.DriverConfig != nil {
+			if len(mount.VolumeOptions.DriverConfig.Type) > 0 {
+				parts = append(parts, fmt.Sprintf("volume-driver=%s", mount.VolumeOptions.DriverConfig.Type))
+			}
+			for _, key := range sortedKeys(mount.VolumeOptions.DriverConfig.Config) {
+				parts = append(parts, fmt.Sprintf("volume-opt=%s=%s", key, mount.VolumeOptions.DriverConfig.Config[key]))
+			}
+		}
+	}
+	if mount.TmpfsOptions != nil {
+		if mount.TmpfsOptions.SizeBytes > 0 {
+			parts = append(parts, fmt.Sprintf("tmpfs-size=%d", mount.TmpfsOptions.SizeBytes))
+		}
+		if mount.TmpfsOptions.Mode > 0 {
+			parts = append(parts, fmt.Sprintf("tmpfs-mode=%o", mount.TmpfsOptions.Mode))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// inspectPortToFlag converts a HostConfig port binding into a --publish value
+func inspectPortToFlag(port string, binding inspectPortBinding) string {
+	containerPort, protocol := extractLinkParts(strings.Replace(port, "/", ":", 1))
+	value := containerPort
+	if len(binding.HostPort) > 0 {
+		value = fmt.Sprintf("%s:%s", binding.HostPort, value)
+	}
+	if len(binding.HostIp) > 0 {
+		hostIp := binding.HostIp
+		if strings.Contains(hostIp, ":") {
+			hostIp = fmt.Sprintf("[%s]", hostIp)
+		}
+		if len(binding.HostPort) > 0 {
+			value = fmt.Sprintf("%s:%s", hostIp, value)
+		} else {
+			value = fmt.Sprintf("%s::%s", hostIp, value)
+		}
+	}
+	if len(protocol) > 0 && protocol != "tcp" {
+		value = fmt.Sprintf("%s/%s", value, protocol)
+	}
+	return value
+}
+
+// healthcheckEqual reports whether two healthchecks are identical
+func healthcheckEqual(a *inspectHealthcheck, b *inspectHealthcheck) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return stringsEqual(a.Test, b.Test) &&
+		a.Interval == b.Interval &&
+		a.Timeout == b.Timeout &&
+		a.StartPeriod == b.StartPeriod &&
+		a.Retries == b.Retries
+}
+
+// subtractList returns the values not present in base and not matched by skip
+func subtractList(values []string, base []string, skip func(string) bool) []string {
+	exclude := map[string]bool{}
+	for _, value := range base {
+		exclude[value] = true
+	}
+
+	out := []string{}
+	for _, value := range values {
+		if exclude[value] || skip(value) {
+			continue
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// subtractMap returns the entries whose key and value are not present in base
+func subtractMap(values map[string]string, base map[string]string) map[string]string {
+	out := map[string]string{}
+	for key, value := range values {
+		if baseValue, ok := base[key]; ok && baseValue == value {
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// stringsEqual reports whether two string slices hold the same values
+func stringsEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a map[string]string sorted alphabetically
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedPortKeys returns the keys of a port binding map sorted alphabetically
+func sortedPortKeys(m map[string][]inspectPortBinding) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package arguments
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestParseDockerInspect verifies that a `docker inspect` document for a
+// container (plus its image) is rebuilt into the docker run arguments an
+// operator would have typed, without engine or image defaults.
+func TestParseDockerInspect(t *testing.T) {
+	data, err := os.ReadFile("testdata/inspect-web.json")
+	if err != nil {
+		t.Fatalf("unable to read fixture: %v", err)
+	}
+
+	got, err := ParseDockerInspect(data)
+	if err != nil {
+		t.Fatalf("ParseDockerInspect returned error: %v", err)
+	}
+
+	want := []string{
+		"--name=web",
+		"--env=APP_ENV=production",
+		"--label=com.example.team=web",
+		"--health-cmd=curl -f http://localhost/",
+		"--health-interval=30s",
+		"--health-timeout=10s",
+		"--health-retries=3",
+		"--expose=9000",
+		"--volume=/srv/html:/usr/share/nginx/html:ro",
+		"--cap-add=NET_ADMIN",
+		"--cpus=1.5",
+		"--add-host=db:10.0.0.5",
+		"--init",
+		"--link=redis:cache",
+		"--memory=536870912",
+		"--mount=type=volume,source=cache,target=/var/cache/nginx",
+		"--oom-score-adj=-500",
+		"--publish=127.0.0.1:8443:443",
+		"--publish=8080:80",
+		"--read-only",
+		"--restart=on-failure:3",
+		"--tmpfs=/run:size=64m",
+		"--ulimit=nofile=1024:2048",
+		"--network=frontend",
+		"--network-alias=www",
+		"--ip=172.20.0.10",
+		"nginx:1.27",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDockerInspect() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestParseDockerInspect_WithoutImage verifies that, without the image
+// document, the image command is kept and only engine defaults are dropped.
+func TestParseDockerInspect_WithoutImage(t *testing.T) {
+	data := []byte(`{
+		"Id": "0123456789abcdef",
+		"Name": "/worker",
+		"Image": "sha256:ffff",
+		"Config": {
+			"Hostname": "0123456789ab",
+			"Env": ["PATH=/usr/bin:/bin", "QUEUE=default"],
+			"Entrypoint": ["/bin/sh", "-c"],
+			"Cmd": ["echo 'it works'"],
+			"Image": "alpine:latest",
+			"StopSignal": "SIGTERM"
+		},
+		"HostConfig": {
+			"NetworkMode": "bridge",
+			"LogConfig": {"Type": "json-file"},
+			"RestartPolicy": {"Name": "no"},
+			"AutoRemove": true,
+			"CapAdd": ["CAP_CHOWN"],
+			"ShmSize": 67108864,
+			"DeviceRequests": [{"Count": -1, "Capabilities": [["gpu"]]}]
+		}
+	}`)
+
+	got, err := ParseDockerInspect(data)
+	if err != nil {
+		t.Fatalf("ParseDockerInspect returned error: %v", err)
+	}
+
+	want := []string{
+		"--name=worker",
+		"--env=QUEUE=default",
+		"--rm",
+		"--gpus=all",
+		"--entrypoint=/bin/sh -c",
+		"alpine:latest",
+		"echo 'it works'",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDockerInspect() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestParseDockerInspect_ClearedEntrypoint verifies that an entrypoint
+// cleared with --entrypoint "" is kept, so the image entrypoint is not run.
+func TestParseDockerInspect_ClearedEntrypoint(t *testing.T) {
+	for name, entrypoint := range map[string]string{
+		"cleared": `[""]`,
+		"null":    `null`,
+		"empty":   `[]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := []byte(`[
+				{
+					"Id": "0123456789abcdef",
+					"Name": "/debug",
+					"Image": "sha256:eeee",
+					"Config": {
+						"Hostname": "0123456789ab",
+						"Entrypoint": ` + entrypoint + `,
+						"Cmd": ["sh"],
+						"Image": "acme/app:1.0"
+					},
+					"HostConfig": {"NetworkMode": "bridge"}
+				},
+				{
+					"Id": "sha256:eeee",
+					"Config": {
+						"Entrypoint": ["/docker-entrypoint.sh"],
+						"Cmd": ["serve"]
+					}
+				}
+			]`)
+
+			got, err := ParseDockerInspect(data)
+			if err != nil {
+				t.Fatalf("ParseDockerInspect returned error: %v", err)
+			}
+
+			want := []string{
+				"--name=debug",
+				"--entrypoint=",
+				"acme/app:1.0",
+				"sh",
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseDockerInspect() =\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+// TestParseDockerInspect_Errors verifies that documents which do not
+// describe exactly one container are rejected.
+func TestParseDockerInspect_Errors(t *testing.T) {
+	for name, data := range map[string]string{
+		"invalid_json":  `not json`,
+		"empty":         `[]`,
+		"image_only":    `[{"Id": "sha256:ffff", "Config": {"Cmd": ["sh"]}}]`,
+		"two_container": `[{"Name": "/a", "Config": {}, "HostConfig": {}}, {"Name": "/b", "Config": {}, "HostConfig": {}}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got, err := ParseDockerInspect([]byte(data)); err == nil {
+				t.Errorf("ParseDockerInspect(%q) = %q, want error", data, got)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"docker-run-export/arguments"
 	"docker-run-export/convert"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/hashicorp/go-multierror"
@@ -13,6 +16,29 @@ import (
 	flag "github.com/spf13/pflag"
 )
 
+// singleContainerFormats holds the formats that cannot export several
+// containers at once
+var singleContainerFormats = map[string]bool{
+	"apprunner":        true,
+	"apprunner-cfn":    true,
+	"cloudrun":         true,
+	"devcontainer":     true,
+	"helm":             true,
+	"aws-batch":        true,
+	"dokku":            true,
+	"engine-api":       true,
+	"fly":              true,
+	"kamal":            true,
+	"kubernetes":       true,
+	"kubernetes-job":   true,
+	"kustomize":        true,
+	"oci-runtime":      true,
+	"quadlet":          true,
+	"swarm-service":    true,
+	"systemd":          true,
+	"terraform-docker": true,
+}
+
 type ExportCommand struct {
 	command.Meta
 	GlobalFlagCommand
@@ -34,7 +60,8 @@ func (c *ExportCommand) Help() string {
 func (c *ExportCommand) Examples() map[string]string {
 	appName := os.Getenv("CLI_APP_NAME")
 	return map[string]string{
-		"Exports to docker-compose": fmt.Sprintf("%s %s --format compose alpine:latest", appName, c.Name()),
+		"Exports to docker-compose":               fmt.Sprintf("%s %s --format compose alpine:latest", appName, c.Name()),
+		"Exports a docker run command from stdin": fmt.Sprintf("echo 'docker run -p 80:80 nginx' | %s %s --dre-from-stdin", appName, c.Name()),
 	}
 }
 
@@ -281,10 +308,52 @@ func (c *ExportCommand) Run(args []string) int {
 		return 1
 	}
 
-	arguments, err := c.ParsedArguments(flags.Args())
-	if err != nil {
-		c.Ui.Error(err.Error())
-		c.Ui.Error(command.CommandErrorText(c))
+	var groups [][]string
+	var err error
+	if c.fromStdin || len(c.fromString) > 0 || len(c.fromFile) > 0 || len(c.fromInspect) > 0 {
+		groups, err = c.inputGroups(flags.Args())
+		if err != nil {
+			c.Ui.Error(err.Error())
+			c.Ui.Error(command.CommandErrorText(c))
+			return 1
+		}
+	} else if flags.ArgsLenAtDash() == 0 {
+		groups = splitGroups(flags.Args())
+	}
+
+	var containers []convert.Container
+	if len(groups) > 1 {
+		containers, err = c.parseContainers(groups)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			c.Ui.Error(command.CommandErrorText(c))
+			return 1
+		}
+	} else {
+		if len(groups) == 1 {
+			// docker run stops parsing flags at the image name, so everything
+			// after it belongs to the container command
+			flags.SetInterspersed(false)
+			if err := flags.Parse(groups[0]); err != nil {
+				c.Ui.Error(err.Error())
+				c.Ui.Error(command.CommandErrorText(c))
+				return 1
+			}
+		}
+
+		arguments, err := c.ParsedArguments(flags.Args())
+		if err != nil {
+			c.Ui.Error(err.Error())
+			c.Ui.Error(command.CommandErrorText(c))
+			return 1
+		}
+
+		c.EntrypointCleared = flags.Changed("entrypoint") && len(c.Entrypoint) == 0
+		containers = []convert.Container{{Args: &c.Args, Arguments: arguments}}
+	}
+
+	if len(containers) > 1 && singleContainerFormats[c.format] {
+		c.Ui.Error(fmt.Sprintf("%s format does not support exporting several containers", c.format))
 		return 1
 	}
 
@@ -293,14 +362,37 @@ func (c *ExportCommand) Run(args []string) int {
 	var errs *multierror.Error
 
 	if c.format == "compose" {
-		output, warnings, errs = convert.ToCompose(c.project, &c.Args, arguments)
-	} else if c.format == "ecs" || c.format == "ecs-cfn" {
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToComposeServices(c.project, containers)
+		} else {
+			output, warnings, errs = convert.ToCompose(c.project, containers[0].Args, containers[0].Arguments)
+		}
+	} else if c.format == "ecs" || c.format == "ecs-cfn" || c.format == "ecs-terraform" {
+		ecsOpts := convert.ECSOptions{
+			TaskRoleArn:             c.ecsTaskRoleArn,
+			ExecutionRoleArn:        c.ecsExecutionRoleArn,
+			RequiresCompatibilities: c.ecsRequiresCompatibilities,
+		}
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToECSContainers(c.project, containers, ecsOpts)
+		} else {
+			output, warnings, errs = convert.ToECS(c.project, containers[0].Args, containers[0].Arguments, ecsOpts)
+		}
+	} else if c.format == "aws-batch" {
 		ecsOpts := convert.ECSOptions{
 			TaskRoleArn:             c.ecsTaskRoleArn,
 			ExecutionRoleArn:        c.ecsExecutionRoleArn,
 			RequiresCompatibilities: c.ecsRequiresCompatibilities,
 		}
-		output, warnings, errs = convert.ToECS(c.project, &c.Args, arguments, ecsOpts)
+		output, warnings, errs = convert.ToAWSBatch(c.project, containers[0].Args, containers[0].Arguments, ecsOpts)
+	} else if c.format == "apprunner" || c.format == "apprunner-cfn" {
+		output, warnings, errs = convert.ToAppRunner(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "aci" {
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToACIContainers(c.project, containers)
+		} else {
+			output, warnings, errs = convert.ToACI(c.project, containers[0].Args, containers[0].Arguments)
+		}
 	} else if c.format == "nomad" || c.format == "nomad-json" {
 		nomadOpts := convert.NomadOptions{
 			Datacenters: c.nomadDatacenters,
@@ -308,8 +400,91 @@ func (c *ExportCommand) Run(args []string) int {
 			Namespace:   c.nomadNamespace,
 			Type:        c.nomadType,
 			Count:       c.nomadCount,
+			Schedule:    c.schedule,
+		}
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToNomadTasks(c.project, containers, nomadOpts)
+		} else {
+			output, warnings, errs = convert.ToNomad(c.project, containers[0].Args, containers[0].Arguments, nomadOpts)
+		}
+	} else if c.format == "terraform-docker" {
+		output, warnings, errs = convert.ToTerraformDocker(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "docker-run" {
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToDockerRunContainers(c.project, containers)
+		} else {
+			output, warnings, errs = convert.ToDockerRun(c.project, containers[0].Args, containers[0].Arguments)
+		}
+	} else if c.format == "swarm-stack" {
+		swarmOpts := convert.SwarmOptions{
+			Replicas: c.swarmReplicas,
+		}
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToSwarmStackServices(c.project, containers, swarmOpts)
+		} else {
+			output, warnings, errs = convert.ToSwarmStack(c.project, containers[0].Args, containers[0].Arguments, swarmOpts)
+		}
+	} else if c.format == "swarm-service" {
+		swarmOpts := convert.SwarmOptions{
+			Replicas: c.swarmReplicas,
+		}
+		output, warnings, errs = convert.ToSwarmService(c.project, containers[0].Args, containers[0].Arguments, swarmOpts)
+	} else if c.format == "ansible" {
+		ansibleOpts := convert.AnsibleOptions{
+			PullImage: c.ansiblePullImage,
+		}
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToAnsibleContainers(c.project, containers, ansibleOpts)
+		} else {
+			output, warnings, errs = convert.ToAnsible(c.project, containers[0].Args, containers[0].Arguments, ansibleOpts)
 		}
-		output, warnings, errs = convert.ToNomad(c.project, &c.Args, arguments, nomadOpts)
+	} else if c.format == "github-actions" {
+		gitHubOpts := convert.GitHubActionsOptions{
+			JobContainer: c.gitHubJobContainer,
+		}
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToGitHubActionsContainers(c.project, containers, gitHubOpts)
+		} else {
+			output, warnings, errs = convert.ToGitHubActions(c.project, containers[0].Args, containers[0].Arguments, gitHubOpts)
+		}
+	} else if c.format == "gitlab-ci" {
+		if len(containers) > 1 {
+			output, warnings, errs = convert.ToGitLabCIContainers(c.project, containers)
+		} else {
+			output, warnings, errs = convert.ToGitLabCI(c.project, containers[0].Args, containers[0].Arguments)
+		}
+	} else if c.format == "devcontainer" {
+		output, warnings, errs = convert.ToDevContainer(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "engine-api" {
+		output, warnings, errs = convert.ToEngineAPI(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "oci-runtime" {
+		output, warnings, errs = convert.ToOCIRuntime(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "kamal" {
+		output, warnings, errs = convert.ToKamal(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "cloudrun" {
+		output, warnings, errs = convert.ToCloudRun(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "fly" {
+		output, warnings, errs = convert.ToFly(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "helm" {
+		output, warnings, errs = convert.ToHelm(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "kubernetes" {
+		output, warnings, errs = convert.ToKubernetes(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "kubernetes-job" {
+		jobOpts := convert.KubernetesJobOptions{
+			Schedule: c.schedule,
+		}
+		output, warnings, errs = convert.ToKubernetesJob(c.project, containers[0].Args, containers[0].Arguments, jobOpts)
+	} else if c.format == "kustomize" {
+		kustomizeOpts := convert.KustomizeOptions{
+			Overlay: c.kustomizeOverlay,
+		}
+		output, warnings, errs = convert.ToKustomize(c.project, containers[0].Args, containers[0].Arguments, kustomizeOpts)
+	} else if c.format == "quadlet" {
+		output, warnings, errs = convert.ToQuadlet(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "systemd" {
+		output, warnings, errs = convert.ToSystemd(c.project, containers[0].Args, containers[0].Arguments)
+	} else if c.format == "dokku" {
+		output, warnings, errs = convert.ToDokku(c.project, containers[0].Args, containers[0].Arguments)
 	} else {
 		c.Ui.Error("Invalid dre-format specified")
 		return 1
@@ -350,6 +525,72 @@ func (c *ExportCommand) Run(args []string) int {
 		}
 		fmt.Println("---")
 		fmt.Println(string(out))
+	} else if c.format == "ecs-terraform" {
+		out, err := convert.MarshalECSTerraform(output.(*convert.ECSTaskDefinition))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "aws-batch" {
+		out, err := convert.MarshalAWSBatch(output.(*convert.AWSBatchJobDefinition))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println(string(out))
+	} else if c.format == "apprunner" {
+		out, err := convert.MarshalAppRunner(output.(*convert.AppRunnerService))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println(string(out))
+	} else if c.format == "apprunner-cfn" {
+		out, err := convert.MarshalAppRunnerCloudFormation(output.(*convert.AppRunnerService))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Println(string(out))
+	} else if c.format == "github-actions" {
+		out, err := convert.MarshalGitHubActions(output.(*convert.GitHubActionsJob))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "gitlab-ci" {
+		out, err := convert.MarshalGitLabCI(output.(*convert.GitLabCIServices))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "devcontainer" {
+		out, err := convert.MarshalDevContainer(output.(*convert.DevContainer))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println(string(out))
+	} else if c.format == "engine-api" {
+		out, err := convert.MarshalEngineAPI(output.(*convert.EngineAPIContainerCreate))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println(string(out))
+	} else if c.format == "oci-runtime" {
+		out, err := convert.MarshalOCIRuntime(output.(*convert.OCIRuntimeSpec))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println(string(out))
 	} else if c.format == "nomad" {
 		out, err := convert.MarshalNomadHCL(output.(*convert.NomadJob))
 		if err != nil {
@@ -364,6 +605,136 @@ func (c *ExportCommand) Run(args []string) int {
 			return 1
 		}
 		fmt.Println(string(out))
+	} else if c.format == "terraform-docker" {
+		out, err := convert.MarshalTerraform(output.(*convert.TerraformConfig))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "docker-run" {
+		out, err := convert.MarshalDockerRun(output.(*convert.DockerRunCommands))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "swarm-stack" {
+		out, err := convert.MarshalSwarmStack(output.(*types.Project))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Println(string(out))
+	} else if c.format == "swarm-service" {
+		out, err := convert.MarshalSwarmService(output.(*convert.SwarmService))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "ansible" {
+		out, err := convert.MarshalAnsible(output.(*convert.AnsibleTasks))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "kamal" {
+		out, err := convert.MarshalKamal(output.(*convert.KamalConfig))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "cloudrun" {
+		out, err := convert.MarshalCloudRun(output.(*convert.CloudRunService))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "aci" {
+		out, err := convert.MarshalACI(output.(*convert.ACIContainerGroup))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Print(string(out))
+	} else if c.format == "fly" {
+		out, err := convert.MarshalFly(output.(*convert.FlyConfig))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "helm" {
+		chart := output.(*convert.HelmChart)
+		if len(c.outputDir) > 0 {
+			if err := writeOutputFiles(c.outputDir, chart.Files); err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+			return 0
+		}
+
+		out, err := convert.MarshalHelm(chart)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "kubernetes" {
+		out, err := convert.MarshalKubernetes(output.(*convert.KubernetesManifests))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Println(string(out))
+	} else if c.format == "kubernetes-job" {
+		out, err := convert.MarshalKubernetesJob(output.(*convert.KubernetesJobManifests))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Println("---")
+		fmt.Println(string(out))
+	} else if c.format == "kustomize" {
+		layout := output.(*convert.KustomizeLayout)
+		if len(c.outputDir) > 0 {
+			if err := writeOutputFiles(c.outputDir, layout.Files); err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+			return 0
+		}
+
+		out, err := convert.MarshalKustomize(layout)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "quadlet" || c.format == "systemd" {
+		out, err := convert.MarshalSystemdUnit(output.(*convert.SystemdUnit))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
+	} else if c.format == "dokku" {
+		out, err := convert.MarshalDokku(output.(*convert.DokkuApp))
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		fmt.Print(string(out))
 	} else {
 		c.Ui.Error("Invalid dre-format specified")
 		return 1
@@ -371,3 +742,125 @@ func (c *ExportCommand) Run(args []string) int {
 
 	return 0
 }
+
+// inputGroups reads the docker run command lines specified via
+// --dre-from-stdin, --dre-from-string or --dre-from-file, or the container
+// specified via --dre-from-inspect, and returns the equivalent arguments that
+// follow the run subcommand, one group per container
+func (c *ExportCommand) inputGroups(positional []string) ([][]string, error) {
+	sources := 0
+	for _, set := range []bool{c.fromStdin, len(c.fromString) > 0, len(c.fromFile) > 0, len(c.fromInspect) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("only one of --dre-from-stdin, --dre-from-string, --dre-from-file and --dre-from-inspect can be used")
+	}
+
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("image and command arguments cannot be used with --dre-from-stdin, --dre-from-string, --dre-from-file or --dre-from-inspect")
+	}
+
+	if len(c.fromInspect) > 0 {
+		var b []byte
+		var err error
+		if c.fromInspect == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(c.fromInspect)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read docker inspect output: %w", err)
+		}
+
+		runArgs, err := arguments.ParseDockerInspect(b)
+		if err != nil {
+			return nil, err
+		}
+
+		return [][]string{runArgs}, nil
+	}
+
+	text := c.fromString
+	if c.fromStdin {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read command line from stdin: %w", err)
+		}
+		text = string(b)
+	} else if len(c.fromFile) > 0 {
+		b, err := os.ReadFile(c.fromFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read command lines from file: %w", err)
+		}
+		text = string(b)
+	}
+
+	return arguments.ParseCommandLines(text)
+}
+
+// parseContainers parses each group of docker run arguments into the flags
+// and positional arguments of a separate container
+func (c *ExportCommand) parseContainers(groups [][]string) ([]convert.Container, error) {
+	containers := []convert.Container{}
+	for i, group := range groups {
+		container := &ExportCommand{Meta: c.Meta}
+		flags := container.FlagSet()
+		flags.SetInterspersed(false)
+		if err := flags.Parse(group); err != nil {
+			return nil, fmt.Errorf("container %d: %w", i+1, err)
+		}
+
+		var dreFlags []string
+		flags.Visit(func(f *flag.Flag) {
+			if strings.HasPrefix(f.Name, "dre-") {
+				dreFlags = append(dreFlags, "--"+f.Name)
+			}
+		})
+		if len(dreFlags) > 0 {
+			return nil, fmt.Errorf("container %d: %s cannot be set per container when exporting several containers", i+1, strings.Join(dreFlags, ", "))
+		}
+
+		arguments, err := container.ParsedArguments(flags.Args())
+		if err != nil {
+			return nil, fmt.Errorf("container %d: %w", i+1, err)
+		}
+
+		container.EntrypointCleared = flags.Changed("entrypoint") && len(container.Entrypoint) == 0
+		containers = append(containers, convert.Container{Args: &container.Args, Arguments: arguments})
+	}
+
+	return containers, nil
+}
+
+// splitGroups splits arguments into the groups separated by `--`, one
+// group per container
+func splitGroups(args []string) [][]string {
+	groups := [][]string{{}}
+	for _, arg := range args {
+		if arg == "--" {
+			groups = append(groups, []string{})
+			continue
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], arg)
+	}
+
+	return groups
+}
+
+// writeOutputFiles writes the files of a multi-file format to the output
+// directory, creating the directory and its subdirectories when needed
+func writeOutputFiles(dir string, files []convert.OutputFile) error {
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("unable to create output directory: %w", err)
+		}
+		if err := os.WriteFile(path, file.Content, 0644); err != nil {
+			return fmt.Errorf("unable to write output file: %w", err)
+		}
+	}
+
+	return nil
+}
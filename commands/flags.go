@@ -8,6 +8,10 @@ import (
 type GlobalFlagCommand struct {
 	format                     string
 	project                    string
+	fromStdin                  bool
+	fromString                 string
+	fromFile                   string
+	fromInspect                string
 	ecsTaskRoleArn             string
 	ecsExecutionRoleArn        string
 	ecsRequiresCompatibilities []string
@@ -16,11 +20,21 @@ type GlobalFlagCommand struct {
 	nomadNamespace             string
 	nomadType                  string
 	nomadCount                 int
+	swarmReplicas              int
+	ansiblePullImage           bool
+	gitHubJobContainer         string
+	outputDir                  string
+	kustomizeOverlay           string
+	schedule                   string
 }
 
 func (c *GlobalFlagCommand) GlobalFlags(f *flag.FlagSet) {
 	f.StringVar(&c.format, "dre-format", "compose", "format to export to")
 	f.StringVar(&c.project, "dre-project", "", "project name to use")
+	f.BoolVar(&c.fromStdin, "dre-from-stdin", false, "read a full docker run command line from stdin")
+	f.StringVar(&c.fromString, "dre-from-string", "", "full docker run command line to export")
+	f.StringVar(&c.fromFile, "dre-from-file", "", "path to a file with one docker run command line per container")
+	f.StringVar(&c.fromInspect, "dre-from-inspect", "", "path to docker inspect JSON output to export ('-' for stdin)")
 	f.StringVar(&c.ecsTaskRoleArn, "dre-ecs-task-role-arn", "", "ECS task role ARN")
 	f.StringVar(&c.ecsExecutionRoleArn, "dre-ecs-execution-role-arn", "", "ECS execution role ARN")
 	f.StringArrayVar(&c.ecsRequiresCompatibilities, "dre-ecs-launch-type", []string{}, "ECS launch type compatibility (FARGATE, EC2)")
@@ -29,12 +43,22 @@ func (c *GlobalFlagCommand) GlobalFlags(f *flag.FlagSet) {
 	f.StringVar(&c.nomadNamespace, "dre-nomad-namespace", "", "Nomad namespace")
 	f.StringVar(&c.nomadType, "dre-nomad-type", "service", "Nomad job type (service, batch, system)")
 	f.IntVar(&c.nomadCount, "dre-nomad-count", 1, "Number of task group instances")
+	f.IntVar(&c.swarmReplicas, "dre-swarm-replicas", 1, "Number of swarm service replicas")
+	f.BoolVar(&c.ansiblePullImage, "dre-ansible-pull-image", false, "Add an Ansible task that pulls the image")
+	f.StringVar(&c.gitHubJobContainer, "dre-github-job-container", "", "name of the container that runs the steps of a GitHub Actions job")
+	f.StringVar(&c.outputDir, "dre-output-dir", "", "directory to write multi-file formats (helm, kustomize) to, instead of printing them")
+	f.StringVar(&c.kustomizeOverlay, "dre-kustomize-overlay", "production", "name of the kustomize overlay holding the environment-specific settings")
+	f.StringVar(&c.schedule, "dre-schedule", "", "cron schedule to run a job on (kubernetes CronJob, nomad periodic batch job)")
 }
 
 func (c *GlobalFlagCommand) AutocompleteGlobalFlags() complete.Flags {
 	return complete.Flags{
 		"--dre-format":                 complete.PredictAnything,
 		"--dre-project":                complete.PredictAnything,
+		"--dre-from-stdin":             complete.PredictNothing,
+		"--dre-from-string":            complete.PredictAnything,
+		"--dre-from-file":              complete.PredictFiles("*"),
+		"--dre-from-inspect":           complete.PredictFiles("*.json"),
 		"--dre-ecs-task-role-arn":      complete.PredictAnything,
 		"--dre-ecs-execution-role-arn": complete.PredictAnything,
 		"--dre-ecs-launch-type":        complete.PredictAnything,
@@ -43,5 +67,11 @@ func (c *GlobalFlagCommand) AutocompleteGlobalFlags() complete.Flags {
 		"--dre-nomad-namespace":        complete.PredictAnything,
 		"--dre-nomad-type":             complete.PredictAnything,
 		"--dre-nomad-count":            complete.PredictAnything,
+		"--dre-swarm-replicas":         complete.PredictAnything,
+		"--dre-ansible-pull-image":     complete.PredictNothing,
+		"--dre-github-job-container":   complete.PredictAnything,
+		"--dre-output-dir":             complete.PredictDirs("*"),
+		"--dre-kustomize-overlay":      complete.PredictAnything,
+		"--dre-schedule":               complete.PredictAnything,
 	}
 }
@@ -0,0 +1,650 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mattn/go-shellwords"
+	"gopkg.in/yaml.v2"
+)
+
+// aciAPIVersion holds the Microsoft.ContainerInstance API version of the
+// container group
+const aciAPIVersion = "2023-05-01"
+
+// aciDefaultCPU and aciDefaultMemoryInGB hold the resources requested when
+// --cpus and --memory are not set, matching `az container create`
+const (
+	aciDefaultCPU        = 1.0
+	aciDefaultMemoryInGB = 1.5
+)
+
+// aciFlags holds the docker run flags that are mapped to the container group
+var aciFlags = map[string]bool{
+	"cpus":                true,
+	"detach":              true,
+	"dns":                 true,
+	"dns-option":          true,
+	"dns-search":          true,
+	"entrypoint":          true,
+	"env":                 true,
+	"expose":              true,
+	"health-cmd":          true,
+	"health-interval":     true,
+	"health-retries":      true,
+	"health-start-period": true,
+	"health-timeout":      true,
+	"label":               true,
+	"link":                true,
+	"memory":              true,
+	"name":                true,
+	"no-healthcheck":      true,
+	"platform":            true,
+	"publish":             true,
+	"restart":             true,
+	"tmpfs":               true,
+	"volume":              true,
+	"volumes-from":        true,
+}
+
+// ACIContainerGroup represents an Azure Container Instances container group,
+// as deployed by `az container create --file`
+type ACIContainerGroup struct {
+	APIVersion string                      `yaml:"apiVersion"`
+	Name       string                      `yaml:"name"`
+	Properties ACIContainerGroupProperties `yaml:"properties"`
+	Tags       map[string]string           `yaml:"tags,omitempty"`
+	Type       string                      `yaml:"type"`
+}
+
+// ACIContainerGroupProperties represents the properties of a container group
+type ACIContainerGroupProperties struct {
+	Containers    []ACIContainer `yaml:"containers"`
+	RestartPolicy string         `yaml:"restartPolicy,omitempty"`
+	IPAddress     *ACIIPAddress  `yaml:"ipAddress,omitempty"`
+	DNSConfig     *ACIDNSConfig  `yaml:"dnsConfig,omitempty"`
+	OSType        string         `yaml:"osType"`
+	Volumes       []ACIVolume    `yaml:"volumes,omitempty"`
+}
+
+// ACIContainer represents a container of a container group
+type ACIContainer struct {
+	Name       string                 `yaml:"name"`
+	Properties ACIContainerProperties `yaml:"properties"`
+}
+
+// ACIContainerProperties represents the properties of a container
+type ACIContainerProperties struct {
+	Image                string                   `yaml:"image"`
+	Command              []string                 `yaml:"command,omitempty"`
+	Ports                []ACIPort                `yaml:"ports,omitempty"`
+	EnvironmentVariables []ACIEnvironmentVariable `yaml:"environmentVariables,omitempty"`
+	Resources            ACIResourceRequirements  `yaml:"resources"`
+	VolumeMounts         []ACIVolumeMount         `yaml:"volumeMounts,omitempty"`
+	LivenessProbe        *ACIProbe                `yaml:"livenessProbe,omitempty"`
+}
+
+// ACIPort represents a port of a container or of the group IP address
+type ACIPort struct {
+	Port     int    `yaml:"port"`
+	Protocol string `yaml:"protocol,omitempty"`
+}
+
+// ACIEnvironmentVariable represents an environment variable of a container
+type ACIEnvironmentVariable struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// ACIResourceRequirements represents the resources of a container
+type ACIResourceRequirements struct {
+	Requests ACIResourceRequests `yaml:"requests"`
+}
+
+// ACIResourceRequests represents the resources requested by a container
+type ACIResourceRequests struct {
+	CPU        float64 `yaml:"cpu"`
+	MemoryInGB float64 `yaml:"memoryInGB"`
+}
+
+// ACIVolumeMount represents a volume mounted into a container
+type ACIVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// ACIProbe represents a liveness probe of a container
+type ACIProbe struct {
+	Exec                ACIExec `yaml:"exec"`
+	InitialDelaySeconds int     `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int     `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int     `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int     `yaml:"failureThreshold,omitempty"`
+}
+
+// ACIExec represents the command run by a probe
+type ACIExec struct {
+	Command []string `yaml:"command"`
+}
+
+// ACIIPAddress represents the public IP address of a container group
+type ACIIPAddress struct {
+	Type  string    `yaml:"type"`
+	Ports []ACIPort `yaml:"ports"`
+}
+
+// ACIDNSConfig represents the DNS configuration of a container group
+type ACIDNSConfig struct {
+	NameServers   []string `yaml:"nameServers"`
+	SearchDomains string   `yaml:"searchDomains,omitempty"`
+	Options       string   `yaml:"options,omitempty"`
+}
+
+// ACIVolume represents a volume of a container group
+type ACIVolume struct {
+	Name      string              `yaml:"name"`
+	EmptyDir  *ACIEmptyDirVolume  `yaml:"emptyDir,omitempty"`
+	AzureFile *ACIAzureFileVolume `yaml:"azureFile,omitempty"`
+}
+
+// ACIEmptyDirVolume represents an emptyDir volume, which is written as {}
+type ACIEmptyDirVolume struct{}
+
+// ACIAzureFileVolume represents an Azure Files share mounted as a volume
+type ACIAzureFileVolume struct {
+	ShareName          string `yaml:"shareName"`
+	StorageAccountName string `yaml:"storageAccountName"`
+	StorageAccountKey  string `yaml:"storageAccountKey"`
+	ReadOnly           bool   `yaml:"readOnly,omitempty"`
+}
+
+// ToACI converts docker run arguments to an Azure Container Instances
+// container group with a single container
+func ToACI(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	containerName := "app"
+	if len(c.ContainerName) > 0 {
+		containerName = kubernetesName(c.ContainerName)
+	}
+
+	groupName := projectName
+	if len(groupName) == 0 {
+		groupName = containerName
+	}
+
+	group := &ACIContainerGroup{
+		APIVersion: aciAPIVersion,
+		Name:       kubernetesName(groupName),
+		Properties: ACIContainerGroupProperties{
+			OSType: "Linux",
+		},
+		Type: "Microsoft.ContainerInstance/containerGroups",
+	}
+
+	container := &ACIContainerProperties{
+		Image: arguments["image"].StringValue(),
+		Resources: ACIResourceRequirements{
+			Requests: ACIResourceRequests{
+				CPU:        aciDefaultCPU,
+				MemoryInGB: aciDefaultMemoryInGB,
+			},
+		},
+	}
+
+	// cpus -> resources.requests.cpu, in steps of 0.1 core
+	if c.Cpus > 0 {
+		cpu := aciRoundUp(float64(c.Cpus))
+		if strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32) != strconv.FormatFloat(cpu, 'f', -1, 64) {
+			warnings = multierror.Append(warnings, fmt.Errorf("rounding --cpus %s up to %s in aci container group as cpu is requested in steps of 0.1", strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32), strconv.FormatFloat(cpu, 'f', -1, 64)))
+		}
+		container.Resources.Requests.CPU = cpu
+	}
+
+	// dns / dns-search / dns-option -> dnsConfig
+	if len(c.Dns) > 0 {
+		group.Properties.DNSConfig = &ACIDNSConfig{
+			NameServers:   c.Dns,
+			SearchDomains: strings.Join(c.DnsSearch, " "),
+			Options:       strings.Join(c.DnsOption, " "),
+		}
+	} else if len(c.DnsSearch) > 0 || len(c.DnsOption) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --dns-search or --dns-option property in aci container group without --dns as dnsConfig requires name servers"))
+	}
+
+	// entrypoint / command -> command
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			container.Command = append(args, arguments["command"].ListValue()...)
+		}
+	} else if c.EntrypointCleared {
+		// command replaces the image entrypoint, so the command runs on its own
+		if len(arguments["command"].ListValue()) == 0 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in aci container group without a command to run"))
+		}
+		container.Command = arguments["command"].ListValue()
+	} else if len(arguments["command"].ListValue()) > 0 {
+		container.Command = arguments["command"].ListValue()
+		warnings = multierror.Append(warnings, fmt.Errorf("setting command in aci container group replaces the entrypoint of the image, set --entrypoint to keep it"))
+	}
+
+	// env -> environmentVariables
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in aci container group as passing through host environment variables is not supported", value))
+			continue
+		}
+		key, val := extractParts(value, "=")
+		container.EnvironmentVariables = append(container.EnvironmentVariables, ACIEnvironmentVariable{
+			Name:  key,
+			Value: val,
+		})
+	}
+
+	// health-cmd / health-* -> livenessProbe
+	if len(c.HealthCmd) > 0 {
+		if c.NoHealthcheck {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+		} else {
+			container.LivenessProbe = &ACIProbe{
+				Exec: ACIExec{
+					Command: []string{"/bin/sh", "-c", c.HealthCmd},
+				},
+			}
+		}
+	}
+
+	if container.LivenessProbe != nil {
+		if c.HealthInterval != "0s" {
+			seconds, err := durationToSeconds(c.HealthInterval)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-interval flag to duration: %w", err))
+			} else {
+				container.LivenessProbe.PeriodSeconds = seconds
+			}
+		}
+
+		if c.HealthRetries != 0 {
+			container.LivenessProbe.FailureThreshold = int(c.HealthRetries)
+		}
+
+		if c.HealthStartPeriod != "0s" {
+			seconds, err := durationToSeconds(c.HealthStartPeriod)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-start-period flag to duration: %w", err))
+			} else {
+				container.LivenessProbe.InitialDelaySeconds = seconds
+			}
+		}
+
+		if c.HealthTimeout != "0s" {
+			seconds, err := durationToSeconds(c.HealthTimeout)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-timeout flag to duration: %w", err))
+			} else {
+				container.LivenessProbe.TimeoutSeconds = seconds
+			}
+		}
+	} else if !c.NoHealthcheck && (c.HealthInterval != "0s" || c.HealthRetries != 0 || c.HealthStartPeriod != "0s" || c.HealthTimeout != "0s") {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --health-* properties in aci container group without --health-cmd as the probe requires a command"))
+	}
+
+	// label -> tags
+	if len(c.Label) > 0 {
+		group.Tags = map[string]string{}
+		for _, value := range c.Label {
+			key, val := extractParts(value, "=")
+			group.Tags[key] = val
+		}
+	}
+
+	// memory -> resources.requests.memoryInGB, in steps of 0.1 GB
+	if c.Memory > 0 {
+		memory := aciRoundUp(float64(c.Memory) / (1024 * 1024 * 1024))
+		if float64(c.Memory) != memory*1024*1024*1024 {
+			warnings = multierror.Append(warnings, fmt.Errorf("rounding --memory %d up to %s GB in aci container group as memory is requested in steps of 0.1 GB", c.Memory, strconv.FormatFloat(memory, 'f', -1, 64)))
+		}
+		container.Resources.Requests.MemoryInGB = memory
+	}
+
+	// platform -> osType
+	if len(c.Platform) > 0 {
+		platformOS, _ := extractParts(c.Platform, "/")
+		switch platformOS {
+		case "linux":
+			group.Properties.OSType = "Linux"
+		case "windows":
+			group.Properties.OSType = "Windows"
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --platform %s in aci container group as only linux and windows are supported", c.Platform))
+		}
+	}
+
+	// publish -> ports and ipAddress.ports
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			port := ACIPort{
+				Port:     int(p.Target),
+				Protocol: strings.ToUpper(p.Protocol),
+			}
+			if len(p.Published) > 0 && p.Published != strconv.Itoa(int(p.Target)) {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to publish port %d/%s on host port %s in aci container group as ports cannot be remapped, publishing it on port %d", p.Target, p.Protocol, p.Published, p.Target))
+			}
+			container.Ports = appendACIPort(container.Ports, port)
+			if group.Properties.IPAddress == nil {
+				group.Properties.IPAddress = &ACIIPAddress{Type: "Public"}
+			}
+			group.Properties.IPAddress.Ports = appendACIPort(group.Properties.IPAddress.Ports, port)
+		}
+	}
+
+	// expose -> ports
+	for _, value := range c.Expose {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --expose flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			container.Ports = appendACIPort(container.Ports, ACIPort{
+				Port:     int(p.Target),
+				Protocol: strings.ToUpper(p.Protocol),
+			})
+		}
+	}
+
+	// restart -> restartPolicy
+	if len(c.Restart) > 0 {
+		mode, retries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			switch mode {
+			case "no":
+				group.Properties.RestartPolicy = "Never"
+			case "always", "unless-stopped":
+				group.Properties.RestartPolicy = "Always"
+			case "on-failure":
+				group.Properties.RestartPolicy = "OnFailure"
+				if retries > 0 {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s retry count in aci container group as the property is not supported", c.Restart))
+				}
+			}
+		}
+	}
+
+	// tmpfs -> emptyDir
+	for i, value := range c.Tmpfs {
+		target, _ := extractParts(value, ":")
+		volumeName := fmt.Sprintf("tmpfs-%d", i)
+		group.Properties.Volumes = append(group.Properties.Volumes, ACIVolume{
+			Name:     volumeName,
+			EmptyDir: &ACIEmptyDirVolume{},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, ACIVolumeMount{
+			Name:      volumeName,
+			MountPath: target,
+		})
+	}
+
+	// volume -> emptyDir for anonymous volumes and bind mounts, azureFile for
+	// named volumes
+	for i, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		readOnly := false
+		if len(parts) == 3 {
+			if parts[2] == "ro" {
+				readOnly = true
+			} else if parts[2] != "rw" {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --volume flag as volume: invalid read mode %s", parts[2]))
+				continue
+			}
+		}
+
+		if len(parts) == 1 {
+			volumeName := fmt.Sprintf("volume-%d", i)
+			group.Properties.Volumes = append(group.Properties.Volumes, ACIVolume{
+				Name:     volumeName,
+				EmptyDir: &ACIEmptyDirVolume{},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, ACIVolumeMount{
+				Name:      volumeName,
+				MountPath: parts[0],
+			})
+			continue
+		}
+
+		if !isNamedVolume(parts[0]) {
+			// host paths are not available, so the path is mounted from an
+			// empty placeholder volume
+			volumeName := fmt.Sprintf("bind-%d", i)
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to bind mount %s for --volume %s in aci container group as host paths are not available, mounting the emptyDir volume %s instead", parts[0], value, volumeName))
+			group.Properties.Volumes = append(group.Properties.Volumes, ACIVolume{
+				Name:     volumeName,
+				EmptyDir: &ACIEmptyDirVolume{},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, ACIVolumeMount{
+				Name:      volumeName,
+				MountPath: parts[1],
+				ReadOnly:  readOnly,
+			})
+			continue
+		}
+
+		volumeName := kubernetesName(parts[0])
+		group.Properties.Volumes = appendACIVolume(group.Properties.Volumes, ACIVolume{
+			Name: volumeName,
+			AzureFile: &ACIAzureFileVolume{
+				ShareName:          volumeName,
+				StorageAccountName: "STORAGE_ACCOUNT_NAME",
+				StorageAccountKey:  "STORAGE_ACCOUNT_KEY",
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, ACIVolumeMount{
+			Name:      volumeName,
+			MountPath: parts[1],
+			ReadOnly:  readOnly,
+		})
+	}
+
+	// link / volumes-from: only meaningful between containers of a group
+	for _, value := range c.Link {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --link %s in aci container group as the container is not part of the export", value))
+	}
+	for _, value := range c.VolumesFrom {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volumes-from %s in aci container group as the container is not part of the export", value))
+	}
+
+	// every other flag has no container group equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if aciFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in aci container group as the property is not supported", flag.Name))
+	}
+
+	// assemble
+	group.Properties.Containers = []ACIContainer{{
+		Name:       containerName,
+		Properties: *container,
+	}}
+
+	return group, warnings, errs
+}
+
+// ToACIContainers converts several docker run invocations to a single
+// container group with one container per docker run invocation
+func ToACIContainers(projectName string, containers []Container) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, resolved, references, err := resolveContainers(containers)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+		return &ACIContainerGroup{Name: projectName}, warnings, errs
+	}
+
+	groupName := projectName
+	if len(groupName) == 0 {
+		groupName = names[0]
+	}
+
+	group := &ACIContainerGroup{
+		APIVersion: aciAPIVersion,
+		Name:       kubernetesName(groupName),
+		Type:       "Microsoft.ContainerInstance/containerGroups",
+	}
+
+	for i, container := range resolved {
+		container.Args.ContainerName = names[i]
+		output, w, e := ToACI(groupName, container.Args, container.Arguments)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+
+		single := output.(*ACIContainerGroup)
+		definition := single.Properties.Containers[0]
+
+		// emptyDir volumes belong to a single container, azureFile volumes
+		// are shared by name
+		for _, volume := range single.Properties.Volumes {
+			if volume.AzureFile != nil {
+				group.Properties.Volumes = appendACIVolume(group.Properties.Volumes, volume)
+				continue
+			}
+
+			volumeName := fmt.Sprintf("%s-%s", definition.Name, volume.Name)
+			for j := range definition.Properties.VolumeMounts {
+				if definition.Properties.VolumeMounts[j].Name == volume.Name {
+					definition.Properties.VolumeMounts[j].Name = volumeName
+				}
+			}
+			volume.Name = volumeName
+			group.Properties.Volumes = appendACIVolume(group.Properties.Volumes, volume)
+		}
+
+		if single.Properties.IPAddress != nil {
+			if group.Properties.IPAddress == nil {
+				group.Properties.IPAddress = &ACIIPAddress{Type: single.Properties.IPAddress.Type}
+			}
+			for _, port := range single.Properties.IPAddress.Ports {
+				for _, existing := range group.Properties.IPAddress.Ports {
+					if existing == port {
+						warnings = multierror.Append(warnings, fmt.Errorf("%s: port %d/%s is already published by another container of the aci container group", names[i], port.Port, strings.ToLower(port.Protocol)))
+					}
+				}
+				group.Properties.IPAddress.Ports = appendACIPort(group.Properties.IPAddress.Ports, port)
+			}
+		}
+
+		for _, field := range []struct {
+			flag  string
+			name  string
+			value string
+			group *string
+		}{
+			{"--restart", "restartPolicy", single.Properties.RestartPolicy, &group.Properties.RestartPolicy},
+			{"--platform", "osType", single.Properties.OSType, &group.Properties.OSType},
+		} {
+			if len(field.value) == 0 {
+				continue
+			}
+			if len(*field.group) > 0 && *field.group != field.value {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring %s as %s is already set to %q in aci container group", names[i], field.flag, field.name, *field.group))
+				continue
+			}
+			*field.group = field.value
+		}
+
+		if single.Properties.DNSConfig != nil {
+			if group.Properties.DNSConfig != nil {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring --dns as dnsConfig is already set in aci container group", names[i]))
+			} else {
+				group.Properties.DNSConfig = single.Properties.DNSConfig
+			}
+		}
+
+		for key, value := range single.Tags {
+			if group.Tags == nil {
+				group.Tags = map[string]string{}
+			}
+			group.Tags[key] = value
+		}
+
+		group.Properties.Containers = append(group.Properties.Containers, definition)
+	}
+
+	// containers of a group share localhost, and volumes-from mounts the
+	// volumes of the referenced container
+	for i := range resolved {
+		if len(references[i].Links) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("%s: containers of an aci container group reach each other on localhost rather than through --link", names[i]))
+		}
+
+		for _, value := range references[i].VolumesFrom {
+			name, mode := extractParts(value, ":")
+			for _, source := range group.Properties.Containers {
+				if source.Name != kubernetesName(name) {
+					continue
+				}
+				for _, mount := range source.Properties.VolumeMounts {
+					mount.ReadOnly = mount.ReadOnly || mode == "ro"
+					group.Properties.Containers[i].Properties.VolumeMounts = append(group.Properties.Containers[i].Properties.VolumeMounts, mount)
+				}
+			}
+		}
+	}
+
+	return group, warnings, errs
+}
+
+// MarshalACI marshals an Azure Container Instances container group to YAML
+func MarshalACI(group *ACIContainerGroup) ([]byte, error) {
+	return yaml.Marshal(group)
+}
+
+// aciRoundUp rounds a cpu or memory request up to the 0.1 granularity
+// accepted by Azure Container Instances
+func aciRoundUp(value float64) float64 {
+	return math.Ceil(math.Round(value*1000)/100) / 10
+}
+
+// appendACIPort appends a port unless the same port is already defined
+func appendACIPort(ports []ACIPort, port ACIPort) []ACIPort {
+	for _, existing := range ports {
+		if existing == port {
+			return ports
+		}
+	}
+
+	return append(ports, port)
+}
+
+// appendACIVolume appends a group-level volume unless a volume with the same
+// name is already defined
+func appendACIVolume(volumes []ACIVolume, volume ACIVolume) []ACIVolume {
+	for _, existing := range volumes {
+		if existing.Name == volume.Name {
+			return volumes
+		}
+	}
+
+	return append(volumes, volume)
+}
@@ -0,0 +1,706 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mattn/go-shellwords"
+	"gopkg.in/yaml.v2"
+)
+
+// ansibleDockerContainerFlags holds the docker run flags that are mapped to
+// the options of the community.docker.docker_container module
+var ansibleDockerContainerFlags = map[string]bool{
+	"add-host":            true,
+	"blkio-weight":        true,
+	"cap-add":             true,
+	"cap-drop":            true,
+	"cgroup-parent":       true,
+	"cgroupns":            true,
+	"cpu-period":          true,
+	"cpu-quota":           true,
+	"cpu-shares":          true,
+	"cpus":                true,
+	"cpuset-cpus":         true,
+	"cpuset-mems":         true,
+	"detach":              true,
+	"device":              true,
+	"device-cgroup-rule":  true,
+	"device-read-bps":     true,
+	"device-read-iops":    true,
+	"device-write-bps":    true,
+	"device-write-iops":   true,
+	"dns":                 true,
+	"dns-option":          true,
+	"dns-search":          true,
+	"domainname":          true,
+	"entrypoint":          true,
+	"env":                 true,
+	"env-file":            true,
+	"expose":              true,
+	"gpus":                true,
+	"group-add":           true,
+	"health-cmd":          true,
+	"health-interval":     true,
+	"health-retries":      true,
+	"health-start-period": true,
+	"health-timeout":      true,
+	"hostname":            true,
+	"init":                true,
+	"interactive":         true,
+	"ip":                  true,
+	"ip6":                 true,
+	"ipc":                 true,
+	"kernel-memory":       true,
+	"label":               true,
+	"link":                true,
+	"log-driver":          true,
+	"log-opt":             true,
+	"mac-address":         true,
+	"memory":              true,
+	"memory-reservation":  true,
+	"memory-swap":         true,
+	"memory-swappiness":   true,
+	"mount":               true,
+	"name":                true,
+	"network":             true,
+	"network-alias":       true,
+	"no-healthcheck":      true,
+	"oom-kill-disable":    true,
+	"oom-score-adj":       true,
+	"pid":                 true,
+	"pids-limit":          true,
+	"platform":            true,
+	"privileged":          true,
+	"publish":             true,
+	"publish-all":         true,
+	"pull":                true,
+	"read-only":           true,
+	"restart":             true,
+	"rm":                  true,
+	"runtime":             true,
+	"security-opt":        true,
+	"shm-size":            true,
+	"stop-signal":         true,
+	"stop-timeout":        true,
+	"storage-opt":         true,
+	"sysctl":              true,
+	"tmpfs":               true,
+	"tty":                 true,
+	"ulimit":              true,
+	"user":                true,
+	"userns":              true,
+	"uts":                 true,
+	"volume":              true,
+	"volume-driver":       true,
+	"volumes-from":        true,
+	"workdir":             true,
+}
+
+// AnsibleOptions holds the DRE flags that apply to the ansible format
+type AnsibleOptions struct {
+	// PullImage adds a community.docker.docker_image task that pulls the
+	// image ahead of the container task
+	PullImage bool
+}
+
+// AnsibleTasks holds the tasks of an Ansible task list
+type AnsibleTasks struct {
+	Tasks []AnsibleTask
+}
+
+// AnsibleTask represents a single Ansible task
+type AnsibleTask struct {
+	Name            string                  `yaml:"name"`
+	DockerImage     *AnsibleDockerImage     `yaml:"community.docker.docker_image,omitempty"`
+	DockerContainer *AnsibleDockerContainer `yaml:"community.docker.docker_container,omitempty"`
+}
+
+// AnsibleDockerImage represents the options of the
+// community.docker.docker_image module
+type AnsibleDockerImage struct {
+	Name   string `yaml:"name"`
+	Source string `yaml:"source"`
+}
+
+// AnsibleDockerContainer represents the options of the
+// community.docker.docker_container module
+type AnsibleDockerContainer struct {
+	Name              string                 `yaml:"name"`
+	Image             string                 `yaml:"image"`
+	State             string                 `yaml:"state"`
+	Pull              bool                   `yaml:"pull,omitempty"`
+	Platform          string                 `yaml:"platform,omitempty"`
+	Entrypoint        []string               `yaml:"entrypoint,omitempty"`
+	Command           []string               `yaml:"command,omitempty"`
+	Env               map[string]string      `yaml:"env,omitempty"`
+	EnvFile           string                 `yaml:"env_file,omitempty"`
+	Labels            map[string]string      `yaml:"labels,omitempty"`
+	PublishedPorts    []string               `yaml:"published_ports,omitempty"`
+	ExposedPorts      []string               `yaml:"exposed_ports,omitempty"`
+	PublishAllPorts   bool                   `yaml:"publish_all_ports,omitempty"`
+	Volumes           []string               `yaml:"volumes,omitempty"`
+	VolumeDriver      string                 `yaml:"volume_driver,omitempty"`
+	VolumesFrom       []string               `yaml:"volumes_from,omitempty"`
+	Mounts            []AnsibleMount         `yaml:"mounts,omitempty"`
+	Tmpfs             []string               `yaml:"tmpfs,omitempty"`
+	NetworkMode       string                 `yaml:"network_mode,omitempty"`
+	Networks          []AnsibleNetwork       `yaml:"networks,omitempty"`
+	Links             []string               `yaml:"links,omitempty"`
+	Hostname          string                 `yaml:"hostname,omitempty"`
+	Domainname        string                 `yaml:"domainname,omitempty"`
+	MacAddress        string                 `yaml:"mac_address,omitempty"`
+	EtcHosts          map[string]string      `yaml:"etc_hosts,omitempty"`
+	DNSServers        []string               `yaml:"dns_servers,omitempty"`
+	DNSOpts           []string               `yaml:"dns_opts,omitempty"`
+	DNSSearchDomains  []string               `yaml:"dns_search_domains,omitempty"`
+	RestartPolicy     string                 `yaml:"restart_policy,omitempty"`
+	RestartRetries    int                    `yaml:"restart_retries,omitempty"`
+	AutoRemove        bool                   `yaml:"auto_remove,omitempty"`
+	Healthcheck       *AnsibleHealthcheck    `yaml:"healthcheck,omitempty"`
+	User              string                 `yaml:"user,omitempty"`
+	Groups            []string               `yaml:"groups,omitempty"`
+	WorkingDir        string                 `yaml:"working_dir,omitempty"`
+	Init              bool                   `yaml:"init,omitempty"`
+	Interactive       bool                   `yaml:"interactive,omitempty"`
+	Tty               bool                   `yaml:"tty,omitempty"`
+	Privileged        bool                   `yaml:"privileged,omitempty"`
+	ReadOnly          bool                   `yaml:"read_only,omitempty"`
+	Capabilities      []string               `yaml:"capabilities,omitempty"`
+	CapDrop           []string               `yaml:"cap_drop,omitempty"`
+	SecurityOpts      []string               `yaml:"security_opts,omitempty"`
+	Devices           []string               `yaml:"devices,omitempty"`
+	DeviceCgroupRules []string               `yaml:"device_cgroup_rules,omitempty"`
+	DeviceReadBps     []AnsibleDeviceRate    `yaml:"device_read_bps,omitempty"`
+	DeviceReadIops    []AnsibleDeviceRate    `yaml:"device_read_iops,omitempty"`
+	DeviceWriteBps    []AnsibleDeviceRate    `yaml:"device_write_bps,omitempty"`
+	DeviceWriteIops   []AnsibleDeviceRate    `yaml:"device_write_iops,omitempty"`
+	DeviceRequests    []AnsibleDeviceRequest `yaml:"device_requests,omitempty"`
+	Cpus              float32                `yaml:"cpus,omitempty"`
+	CpuShares         int                    `yaml:"cpu_shares,omitempty"`
+	CpuPeriod         int                    `yaml:"cpu_period,omitempty"`
+	CpuQuota          int                    `yaml:"cpu_quota,omitempty"`
+	CpusetCpus        string                 `yaml:"cpuset_cpus,omitempty"`
+	CpusetMems        string                 `yaml:"cpuset_mems,omitempty"`
+	BlkioWeight       int                    `yaml:"blkio_weight,omitempty"`
+	Memory            string                 `yaml:"memory,omitempty"`
+	MemoryReservation string                 `yaml:"memory_reservation,omitempty"`
+	MemorySwap        string                 `yaml:"memory_swap,omitempty"`
+	MemorySwappiness  int64                  `yaml:"memory_swappiness,omitempty"`
+	KernelMemory      string                 `yaml:"kernel_memory,omitempty"`
+	ShmSize           string                 `yaml:"shm_size,omitempty"`
+	OomKiller         bool                   `yaml:"oom_killer,omitempty"`
+	OomScoreAdj       int                    `yaml:"oom_score_adj,omitempty"`
+	PidsLimit         int                    `yaml:"pids_limit,omitempty"`
+	Ulimits           []string               `yaml:"ulimits,omitempty"`
+	Sysctls           map[string]string      `yaml:"sysctls,omitempty"`
+	StorageOpts       map[string]string      `yaml:"storage_opts,omitempty"`
+	CgroupParent      string                 `yaml:"cgroup_parent,omitempty"`
+	CgroupnsMode      string                 `yaml:"cgroupns_mode,omitempty"`
+	IpcMode           string                 `yaml:"ipc_mode,omitempty"`
+	PidMode           string                 `yaml:"pid_mode,omitempty"`
+	Uts               string                 `yaml:"uts,omitempty"`
+	UsernsMode        string                 `yaml:"userns_mode,omitempty"`
+	Runtime           string                 `yaml:"runtime,omitempty"`
+	LogDriver         string                 `yaml:"log_driver,omitempty"`
+	LogOptions        map[string]string      `yaml:"log_options,omitempty"`
+	StopSignal        string                 `yaml:"stop_signal,omitempty"`
+	StopTimeout       int                    `yaml:"stop_timeout,omitempty"`
+}
+
+// AnsibleMount represents an entry of the mounts option
+type AnsibleMount struct {
+	Type         string            `yaml:"type"`
+	Source       string            `yaml:"source,omitempty"`
+	Target       string            `yaml:"target"`
+	ReadOnly     bool              `yaml:"read_only,omitempty"`
+	Propagation  string            `yaml:"propagation,omitempty"`
+	NoCopy       bool              `yaml:"no_copy,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	VolumeDriver string            `yaml:"volume_driver,omitempty"`
+	VolumeOpts   map[string]string `yaml:"volume_options,omitempty"`
+	TmpfsSize    string            `yaml:"tmpfs_size,omitempty"`
+	TmpfsMode    string            `yaml:"tmpfs_mode,omitempty"`
+}
+
+// AnsibleNetwork represents an entry of the networks option
+type AnsibleNetwork struct {
+	Name        string   `yaml:"name"`
+	Aliases     []string `yaml:"aliases,omitempty"`
+	IPv4Address string   `yaml:"ipv4_address,omitempty"`
+	IPv6Address string   `yaml:"ipv6_address,omitempty"`
+}
+
+// AnsibleHealthcheck represents the healthcheck option
+type AnsibleHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+}
+
+// AnsibleDeviceRate represents an entry of the device_read_bps,
+// device_write_bps, device_read_iops and device_write_iops options
+type AnsibleDeviceRate struct {
+	Path string `yaml:"path"`
+	Rate string `yaml:"rate"`
+}
+
+// AnsibleDeviceRequest represents an entry of the device_requests option
+type AnsibleDeviceRequest struct {
+	Driver       string     `yaml:"driver,omitempty"`
+	Count        int        `yaml:"count,omitempty"`
+	DeviceIds    []string   `yaml:"device_ids,omitempty"`
+	Capabilities [][]string `yaml:"capabilities"`
+}
+
+// ToAnsible converts docker run arguments to Ansible tasks using the
+// community.docker.docker_container module
+func ToAnsible(projectName string, c *arguments.Args, arguments map[string]command.Argument, ansibleOpts AnsibleOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = projectName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	container, warnings, errs := toAnsibleDockerContainer(name, c, arguments)
+	return ansibleTasks([]*AnsibleDockerContainer{container}, ansibleOpts), warnings, errs
+}
+
+// ToAnsibleContainers converts several docker run invocations to one
+// community.docker.docker_container task per container. References between
+// the containers are kept as is, as the module resolves them by name.
+func ToAnsibleContainers(projectName string, containers []Container, ansibleOpts AnsibleOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, err := containerNames(containers)
+	if err != nil {
+		return nil, nil, multierror.Append(errs, err)
+	}
+
+	dockerContainers := []*AnsibleDockerContainer{}
+	for i, container := range containers {
+		dockerContainer, w, e := toAnsibleDockerContainer(names[i], container.Args, container.Arguments)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+		dockerContainers = append(dockerContainers, dockerContainer)
+	}
+
+	return ansibleTasks(dockerContainers, ansibleOpts), warnings, errs
+}
+
+// ansibleTasks returns the task list for the containers, with an image pull
+// task for each image ahead of the container tasks when requested
+func ansibleTasks(containers []*AnsibleDockerContainer, ansibleOpts AnsibleOptions) *AnsibleTasks {
+	tasks := &AnsibleTasks{}
+	if ansibleOpts.PullImage {
+		seen := map[string]bool{}
+		for _, container := range containers {
+			if seen[container.Image] {
+				continue
+			}
+			seen[container.Image] = true
+			tasks.Tasks = append(tasks.Tasks, AnsibleTask{
+				Name: fmt.Sprintf("Pull %s", container.Image),
+				DockerImage: &AnsibleDockerImage{
+					Name:   container.Image,
+					Source: "pull",
+				},
+			})
+		}
+	}
+
+	for _, container := range containers {
+		tasks.Tasks = append(tasks.Tasks, AnsibleTask{
+			Name:            fmt.Sprintf("Start %s container", container.Name),
+			DockerContainer: container,
+		})
+	}
+
+	return tasks
+}
+
+// toAnsibleDockerContainer converts docker run arguments to the options of
+// the community.docker.docker_container module
+func toAnsibleDockerContainer(name string, c *arguments.Args, arguments map[string]command.Argument) (*AnsibleDockerContainer, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	container := &AnsibleDockerContainer{
+		Name:  name,
+		Image: arguments["image"].StringValue(),
+		State: "started",
+	}
+
+	// pull -> pull
+	switch c.Pull {
+	case "always":
+		container.Pull = true
+	case "never":
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pull never in ansible docker_container as the property is not supported"))
+	}
+
+	container.Platform = c.Platform
+
+	// command / entrypoint
+	container.Command = arguments["command"].ListValue()
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			container.Entrypoint = args
+		}
+	} else if c.EntrypointCleared {
+		container.Entrypoint = []string{""}
+	}
+
+	// env -> env
+	if len(c.Env) > 0 {
+		env := map[string]string{}
+		for _, value := range c.Env {
+			if !strings.Contains(value, "=") {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in ansible docker_container as passing through host environment variables is not supported", value))
+				continue
+			}
+			key, val := extractParts(value, "=")
+			env[key] = val
+		}
+		if len(env) > 0 {
+			container.Env = env
+		}
+	}
+
+	// env-file -> env_file, which only accepts a single file
+	if len(c.EnvFile) > 0 {
+		container.EnvFile = c.EnvFile[0]
+		for _, value := range c.EnvFile[1:] {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env-file %s in ansible docker_container as only a single env file is supported", value))
+		}
+	}
+
+	// label -> labels
+	if len(c.Label) > 0 {
+		container.Labels = map[string]string{}
+		for _, value := range c.Label {
+			key, val := extractParts(value, "=")
+			container.Labels[key] = val
+		}
+	}
+
+	// publish / expose / publish-all
+	container.PublishedPorts = c.Publish
+	container.ExposedPorts = c.Expose
+	container.PublishAllPorts = c.PublishAll
+
+	// volume / volume-driver / volumes-from / mount / tmpfs
+	container.Volumes = c.Volume
+	container.VolumeDriver = c.VolumeDriver
+	container.VolumesFrom = c.VolumesFrom
+	for _, value := range c.Mount {
+		parsed, err := parseDockerMount(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		container.Mounts = append(container.Mounts, ansibleMount(parsed))
+	}
+	container.Tmpfs = c.Tmpfs
+
+	// network / network-alias / ip / ip6 -> network_mode or networks
+	switch {
+	case len(c.Network) == 0:
+		if len(c.NetworkAlias) > 0 || len(c.Ip) > 0 || len(c.Ip6) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias, --ip and --ip6 properties in ansible docker_container without --network"))
+		}
+	case c.Network == "host" || c.Network == "bridge" || c.Network == "none" || strings.HasPrefix(c.Network, "container:"):
+		container.NetworkMode = c.Network
+		if len(c.NetworkAlias) > 0 || len(c.Ip) > 0 || len(c.Ip6) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias, --ip and --ip6 properties in ansible docker_container with --network %s", c.Network))
+		}
+	default:
+		container.NetworkMode = c.Network
+		container.Networks = []AnsibleNetwork{
+			{
+				Name:        c.Network,
+				Aliases:     c.NetworkAlias,
+				IPv4Address: c.Ip,
+				IPv6Address: c.Ip6,
+			},
+		}
+	}
+	container.Links = c.Link
+
+	container.Hostname = c.Hostname
+	container.Domainname = c.Domainname
+	container.MacAddress = c.Mac
+
+	// add-host -> etc_hosts
+	if len(c.AddHost) > 0 {
+		container.EtcHosts = map[string]string{}
+		for _, value := range c.AddHost {
+			host, ip := extractParts(strings.Replace(value, "=", ":", 1), ":")
+			container.EtcHosts[host] = ip
+		}
+	}
+
+	container.DNSServers = c.Dns
+	container.DNSOpts = c.DnsOption
+	container.DNSSearchDomains = c.DnsSearch
+
+	// restart -> restart_policy / restart_retries
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, retries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			container.RestartPolicy = mode
+			container.RestartRetries = retries
+		}
+	}
+	container.AutoRemove = c.Rm
+
+	// health-* -> healthcheck
+	if c.NoHealthcheck {
+		if len(c.HealthCmd) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+		}
+		container.Healthcheck = &AnsibleHealthcheck{
+			Test: []string{"NONE"},
+		}
+	} else if len(c.HealthCmd) > 0 {
+		healthcheck := &AnsibleHealthcheck{
+			Test:    []string{"CMD-SHELL", c.HealthCmd},
+			Retries: int(c.HealthRetries),
+		}
+		if c.HealthInterval != "0s" {
+			healthcheck.Interval = c.HealthInterval
+		}
+		if c.HealthTimeout != "0s" {
+			healthcheck.Timeout = c.HealthTimeout
+		}
+		if c.HealthStartPeriod != "0s" {
+			healthcheck.StartPeriod = c.HealthStartPeriod
+		}
+		container.Healthcheck = healthcheck
+	}
+
+	container.User = c.User
+	container.Groups = c.GroupAdd
+	container.WorkingDir = c.Workdir
+	container.Init = c.Init
+	container.Interactive = c.Interactive
+	container.Tty = c.Tty
+	container.Privileged = c.Privileged
+	container.ReadOnly = c.ReadOnly
+	container.Capabilities = c.CapAdd
+	container.CapDrop = c.CapDrop
+	container.SecurityOpts = c.SecurityOpt
+
+	// device / device-cgroup-rule / device-* rates
+	container.Devices = c.Device
+	container.DeviceCgroupRules = c.DeviceCgroupRule
+	container.DeviceReadBps = ansibleDeviceRates(c.DeviceReadBps)
+	container.DeviceReadIops = ansibleDeviceRates(c.DeviceReadIops)
+	container.DeviceWriteBps = ansibleDeviceRates(c.DeviceWriteBps)
+	container.DeviceWriteIops = ansibleDeviceRates(c.DeviceWriteIops)
+
+	// gpus -> device_requests
+	if len(c.Gpus) > 0 {
+		request, err := engineAPIGpuRequest(c.Gpus)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			container.DeviceRequests = []AnsibleDeviceRequest{{
+				Driver:       request.Driver,
+				Count:        request.Count,
+				DeviceIds:    request.DeviceIDs,
+				Capabilities: request.Capabilities,
+			}}
+		}
+	}
+
+	// cpu limits
+	container.Cpus = c.Cpus
+	container.CpuShares = c.CpuShares
+	container.CpuPeriod = c.CpuPeriod
+	container.CpuQuota = c.CpuQuota
+	container.CpusetCpus = c.CpusetCpus
+	container.CpusetMems = c.CpusetMems
+	container.BlkioWeight = c.BlkioWeight
+
+	// memory limits are set as sizes with a unit
+	if c.Memory > 0 {
+		container.Memory = ansibleSize(c.Memory)
+	}
+	if c.MemoryReservation > 0 {
+		container.MemoryReservation = ansibleSize(c.MemoryReservation)
+	}
+	if c.MemorySwap == -1 {
+		container.MemorySwap = "-1"
+	} else if c.MemorySwap > 0 {
+		container.MemorySwap = ansibleSize(c.MemorySwap)
+	}
+	container.MemorySwappiness = c.MemorySwappiness
+	if c.KernelMemory > 0 {
+		container.KernelMemory = ansibleSize(int64(c.KernelMemory))
+	}
+	if c.ShmSize > 0 {
+		container.ShmSize = ansibleSize(int64(c.ShmSize))
+	}
+	container.OomKiller = c.OomKillDisable
+	container.OomScoreAdj = c.OomScore
+	container.PidsLimit = c.PidsLimit
+
+	// ulimit -> ulimits, as name:soft:hard
+	for _, value := range c.Ulimit {
+		name, limits := extractParts(value, "=")
+		soft, hard := extractParts(limits, ":")
+		if hard == "" {
+			hard = soft
+		}
+		container.Ulimits = append(container.Ulimits, fmt.Sprintf("%s:%s:%s", name, soft, hard))
+	}
+
+	if len(c.Sysctl) > 0 {
+		container.Sysctls = c.Sysctl
+	}
+
+	// storage-opt -> storage_opts
+	if len(c.StorageOpt) > 0 {
+		container.StorageOpts = map[string]string{}
+		for _, value := range c.StorageOpt {
+			key, val := extractParts(value, "=")
+			container.StorageOpts[key] = val
+		}
+	}
+
+	container.CgroupParent = c.CgroupParent
+	container.CgroupnsMode = c.Cgroupns
+	container.IpcMode = c.Ipc
+	container.PidMode = c.Pid
+	container.Uts = c.Uts
+	container.UsernsMode = c.Userns
+	container.Runtime = c.Runtime
+
+	// log-driver / log-opt -> log_driver / log_options
+	container.LogDriver = c.LogDriver
+	if len(c.LogOpt) > 0 {
+		container.LogOptions = map[string]string{}
+		for _, value := range c.LogOpt {
+			key, val := extractParts(value, "=")
+			container.LogOptions[key] = val
+		}
+	}
+
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		container.StopSignal = c.StopSignal
+	}
+	container.StopTimeout = c.StopTimeout
+
+	// every other flag has no docker_container equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if ansibleDockerContainerFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in ansible docker_container as the property is not supported", flag.Name))
+	}
+
+	return container, warnings, errs
+}
+
+// MarshalAnsible marshals Ansible tasks to YAML format
+func MarshalAnsible(tasks *AnsibleTasks) ([]byte, error) {
+	return yaml.Marshal(tasks.Tasks)
+}
+
+// ansibleMount converts a parsed --mount value to an entry of the mounts
+// option
+func ansibleMount(parsed map[string]interface{}) AnsibleMount {
+	mount := AnsibleMount{
+		Type:   parsed["type"].(string),
+		Target: parsed["target"].(string),
+	}
+	if source, ok := parsed["source"].(string); ok {
+		mount.Source = source
+	}
+	if readOnly, ok := parsed["readonly"].(bool); ok {
+		mount.ReadOnly = readOnly
+	}
+	if options, ok := parsed["bind_options"].(map[string]interface{}); ok {
+		if propagation, ok := options["propagation"].(string); ok {
+			mount.Propagation = propagation
+		}
+	}
+	if options, ok := parsed["volume_options"].(map[string]interface{}); ok {
+		if noCopy, ok := options["no_copy"].(bool); ok {
+			mount.NoCopy = noCopy
+		}
+		if labels, ok := options["labels"].(map[string]string); ok {
+			mount.Labels = labels
+		}
+		if driver, ok := options["driver_config"].(map[string]interface{}); ok {
+			if name, ok := driver["name"].(string); ok {
+				mount.VolumeDriver = name
+			}
+			if driverOptions, ok := driver["options"].(map[string]string); ok {
+				mount.VolumeOpts = driverOptions
+			}
+		}
+	}
+	if options, ok := parsed["tmpfs_options"].(map[string]interface{}); ok {
+		if size, ok := options["size"].(int64); ok {
+			mount.TmpfsSize = ansibleSize(size)
+		}
+		if mode, ok := options["mode"].(int); ok {
+			mount.TmpfsMode = strconv.FormatInt(int64(mode), 8)
+		}
+	}
+
+	return mount
+}
+
+// ansibleDeviceRates converts --device-read-bps style values of the form
+// PATH:RATE to device rate entries
+func ansibleDeviceRates(values []string) []AnsibleDeviceRate {
+	rates := []AnsibleDeviceRate{}
+	for _, value := range values {
+		path, rate := extractParts(value, ":")
+		rates = append(rates, AnsibleDeviceRate{Path: path, Rate: rate})
+	}
+
+	if len(rates) == 0 {
+		return nil
+	}
+	return rates
+}
+
+// ansibleSize returns a size in bytes in the <number>[<unit>] form accepted by
+// the docker_container module, using the largest unit that divides it
+func ansibleSize(bytes int64) string {
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"G", 1024 * 1024 * 1024},
+		{"M", 1024 * 1024},
+		{"K", 1024},
+	}
+	for _, unit := range units {
+		if bytes%unit.size == 0 {
+			return fmt.Sprintf("%d%s", bytes/unit.size, unit.suffix)
+		}
+	}
+
+	return strconv.FormatInt(bytes, 10)
+}
@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"docker-run-export/arguments"
+)
+
+// TestAnsibleSize verifies that sizes use the largest unit that divides them.
+func TestAnsibleSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{536870912, "512M"},
+		{1073741824, "1G"},
+		{65536, "64K"},
+		{1000, "1000"},
+	}
+
+	for _, tt := range tests {
+		if got := ansibleSize(tt.bytes); got != tt.want {
+			t.Errorf("ansibleSize(%d) = %s, want %s", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+// TestAnsibleDeviceRates verifies that device limits keep the device path and
+// the rate as docker accepts them.
+func TestAnsibleDeviceRates(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []AnsibleDeviceRate
+	}{
+		{nil, nil},
+		{[]string{"/dev/sda:1mb"}, []AnsibleDeviceRate{{Path: "/dev/sda", Rate: "1mb"}}},
+		{[]string{"/dev/sda:300", "/dev/sdb:100"}, []AnsibleDeviceRate{{Path: "/dev/sda", Rate: "300"}, {Path: "/dev/sdb", Rate: "100"}}},
+	}
+
+	for _, tt := range tests {
+		if got := ansibleDeviceRates(tt.values); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ansibleDeviceRates(%v) = %+v, want %+v", tt.values, got, tt.want)
+		}
+	}
+}
+
+// TestAnsibleDockerContainer verifies that the parsed docker run flags are
+// mapped to the options of the docker_container module.
+func TestAnsibleDockerContainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    arguments.Args
+		field   func(*AnsibleDockerContainer) interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "published ports",
+			args:  arguments.Args{Publish: []string{"127.0.0.1:8080:80", "53:53/udp"}},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.PublishedPorts },
+			want:  []string{"127.0.0.1:8080:80", "53:53/udp"},
+		},
+		{
+			name:  "volume mount",
+			args:  arguments.Args{Mount: []string{"type=volume,source=data,target=/data,readonly,volume-driver=local"}},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.Mounts },
+			want:  []AnsibleMount{{Type: "volume", Source: "data", Target: "/data", ReadOnly: true, VolumeDriver: "local"}},
+		},
+		{
+			name:  "tmpfs mount",
+			args:  arguments.Args{Mount: []string{"type=tmpfs,target=/run,tmpfs-size=67108864,tmpfs-mode=1777"}},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.Mounts },
+			want:  []AnsibleMount{{Type: "tmpfs", Target: "/run", TmpfsSize: "64M", TmpfsMode: "1777"}},
+		},
+		{
+			name:    "invalid mount",
+			args:    arguments.Args{Mount: []string{"type=volume"}},
+			wantErr: true,
+		},
+		{
+			name:  "restart on-failure with retries",
+			args:  arguments.Args{Restart: "on-failure:5"},
+			field: func(c *AnsibleDockerContainer) interface{} { return []interface{}{c.RestartPolicy, c.RestartRetries} },
+			want:  []interface{}{"on-failure", 5},
+		},
+		{
+			name:  "restart no",
+			args:  arguments.Args{},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.RestartPolicy },
+			want:  "",
+		},
+		{
+			name:    "unknown restart policy",
+			args:    arguments.Args{Restart: "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:  "device limits",
+			args:  arguments.Args{DeviceWriteIops: []string{"/dev/sda:300"}},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.DeviceWriteIops },
+			want:  []AnsibleDeviceRate{{Path: "/dev/sda", Rate: "300"}},
+		},
+		{
+			name:  "gpus",
+			args:  arguments.Args{Gpus: "all"},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.DeviceRequests },
+			want:  []AnsibleDeviceRequest{{Count: -1, Capabilities: [][]string{{"gpu"}}}},
+		},
+		{
+			name:    "invalid gpus",
+			args:    arguments.Args{Gpus: "memory=1"},
+			wantErr: true,
+		},
+		{
+			name:  "capabilities",
+			args:  arguments.Args{CapAdd: []string{"NET_ADMIN"}, CapDrop: []string{"ALL"}},
+			field: func(c *AnsibleDockerContainer) interface{} { return [][]string{c.Capabilities, c.CapDrop} },
+			want:  [][]string{{"NET_ADMIN"}, {"ALL"}},
+		},
+		{
+			name:  "entrypoint",
+			args:  arguments.Args{Entrypoint: "/bin/sh -c"},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.Entrypoint },
+			want:  []string{"/bin/sh", "-c"},
+		},
+		{
+			name:  "cleared entrypoint",
+			args:  arguments.Args{EntrypointCleared: true},
+			field: func(c *AnsibleDockerContainer) interface{} { return c.Entrypoint },
+			want:  []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := withDefaults(tt.args)
+			if len(tt.args.Restart) > 0 {
+				args.Restart = tt.args.Restart
+			}
+			container, _, errs := toAnsibleDockerContainer("app", args, makeArgs("alpine:3.20"))
+			if tt.wantErr {
+				if errs.ErrorOrNil() == nil {
+					t.Errorf("toAnsibleDockerContainer() returned no error")
+				}
+				return
+			}
+			if errs.ErrorOrNil() != nil {
+				t.Fatalf("toAnsibleDockerContainer() returned errors: %v", errs)
+			}
+			if got := tt.field(container); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toAnsibleDockerContainer() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
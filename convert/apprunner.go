@@ -0,0 +1,433 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// appRunnerDefaultPort holds the port App Runner routes traffic to when no
+// port is published
+const appRunnerDefaultPort = 8080
+
+// appRunnerMaxNameLength holds the longest service name App Runner accepts
+const appRunnerMaxNameLength = 40
+
+// appRunnerDefaultVCPU and appRunnerDefaultMemoryGB hold the instance size
+// used when --cpus and --memory are not set
+const (
+	appRunnerDefaultVCPU     = 1
+	appRunnerDefaultMemoryGB = 2
+)
+
+// appRunnerHealthCheckMin and appRunnerHealthCheckMax hold the bounds of the
+// health check interval, timeout and thresholds
+const (
+	appRunnerHealthCheckMin = 1
+	appRunnerHealthCheckMax = 20
+)
+
+// appRunnerSizes holds the vCPU values of App Runner instances, mapped to
+// the memory values in GB accepted for each of them
+var appRunnerSizes = []struct {
+	vcpu   float64
+	memory []float64
+}{
+	{0.25, []float64{0.5, 1}},
+	{0.5, []float64{1}},
+	{1, []float64{2, 3, 4}},
+	{2, []float64{4}},
+	{4, []float64{8, 10, 12}},
+}
+
+// appRunnerECRImageRegexp matches images stored in a private ECR repository,
+// capturing the account id
+var appRunnerECRImageRegexp = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr\.[a-z0-9-]+\.amazonaws\.com(\.cn)?/`)
+
+// appRunnerFlags holds the docker run flags that are mapped to the App
+// Runner service
+var appRunnerFlags = map[string]bool{
+	"cpus":            true,
+	"detach":          true,
+	"env":             true,
+	"health-cmd":      true,
+	"health-interval": true,
+	"health-retries":  true,
+	"health-timeout":  true,
+	"label":           true,
+	"memory":          true,
+	"name":            true,
+	"no-healthcheck":  true,
+	"publish":         true,
+	"restart":         true,
+	"rm":              true,
+}
+
+// AppRunnerService represents the input of an App Runner CreateService call
+type AppRunnerService struct {
+	ServiceName              string                             `json:"ServiceName"                        yaml:"ServiceName"`
+	SourceConfiguration      AppRunnerSourceConfiguration       `json:"SourceConfiguration"                yaml:"SourceConfiguration"`
+	InstanceConfiguration    AppRunnerInstanceConfiguration     `json:"InstanceConfiguration"              yaml:"InstanceConfiguration"`
+	HealthCheckConfiguration *AppRunnerHealthCheckConfiguration `json:"HealthCheckConfiguration,omitempty" yaml:"HealthCheckConfiguration,omitempty"`
+	Tags                     []AppRunnerTag                     `json:"Tags,omitempty"                     yaml:"Tags,omitempty"`
+}
+
+// AppRunnerSourceConfiguration represents the image an App Runner service runs
+type AppRunnerSourceConfiguration struct {
+	ImageRepository             AppRunnerImageRepository    `json:"ImageRepository"                       yaml:"ImageRepository"`
+	AutoDeploymentsEnabled      bool                        `json:"AutoDeploymentsEnabled"                yaml:"AutoDeploymentsEnabled"`
+	AuthenticationConfiguration *AppRunnerAuthConfiguration `json:"AuthenticationConfiguration,omitempty" yaml:"AuthenticationConfiguration,omitempty"`
+}
+
+// AppRunnerImageRepository represents an ECR or ECR Public image
+type AppRunnerImageRepository struct {
+	ImageIdentifier     string                      `json:"ImageIdentifier"     yaml:"ImageIdentifier"`
+	ImageRepositoryType string                      `json:"ImageRepositoryType" yaml:"ImageRepositoryType"`
+	ImageConfiguration  AppRunnerImageConfiguration `json:"ImageConfiguration"  yaml:"ImageConfiguration"`
+}
+
+// AppRunnerImageConfiguration represents the runtime settings of an image.
+// The API takes environment variables as a map, while CloudFormation takes
+// them as a list, so RuntimeEnvironmentVariablesList is only set when
+// marshalling to CloudFormation.
+type AppRunnerImageConfiguration struct {
+	Port                            string              `json:"Port,omitempty"                        yaml:"Port,omitempty"`
+	RuntimeEnvironmentVariables     map[string]string   `json:"RuntimeEnvironmentVariables,omitempty" yaml:"-"`
+	RuntimeEnvironmentVariablesList []AppRunnerKeyValue `json:"-"                                     yaml:"RuntimeEnvironmentVariables,omitempty"`
+	StartCommand                    string              `json:"StartCommand,omitempty"                yaml:"StartCommand,omitempty"`
+}
+
+// AppRunnerKeyValue represents a CloudFormation environment variable
+type AppRunnerKeyValue struct {
+	Name  string `yaml:"Name"`
+	Value string `yaml:"Value"`
+}
+
+// AppRunnerAuthConfiguration represents the role App Runner uses to pull
+// private ECR images
+type AppRunnerAuthConfiguration struct {
+	AccessRoleArn string `json:"AccessRoleArn" yaml:"AccessRoleArn"`
+}
+
+// AppRunnerInstanceConfiguration represents the size of App Runner instances
+type AppRunnerInstanceConfiguration struct {
+	Cpu    string `json:"Cpu"    yaml:"Cpu"`
+	Memory string `json:"Memory" yaml:"Memory"`
+}
+
+// AppRunnerHealthCheckConfiguration represents the health check of an App
+// Runner service
+type AppRunnerHealthCheckConfiguration struct {
+	Protocol           string `json:"Protocol"           yaml:"Protocol"`
+	Path               string `json:"Path,omitempty"     yaml:"Path,omitempty"`
+	Interval           int    `json:"Interval"           yaml:"Interval"`
+	Timeout            int    `json:"Timeout"            yaml:"Timeout"`
+	HealthyThreshold   int    `json:"HealthyThreshold"   yaml:"HealthyThreshold"`
+	UnhealthyThreshold int    `json:"UnhealthyThreshold" yaml:"UnhealthyThreshold"`
+}
+
+// AppRunnerTag represents a tag of an App Runner service
+type AppRunnerTag struct {
+	Key   string `json:"Key"   yaml:"Key"`
+	Value string `json:"Value" yaml:"Value"`
+}
+
+// ToAppRunner converts docker run arguments to an App Runner CreateService
+// input. App Runner runs a single ECR or ECR Public image behind a managed
+// HTTPS endpoint, so flags it cannot express are returned as errors.
+func ToAppRunner(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+	name = kubernetesName(name)
+	if len(name) > appRunnerMaxNameLength {
+		name = strings.Trim(name[:appRunnerMaxNameLength], "-")
+	}
+
+	service := &AppRunnerService{
+		ServiceName: name,
+		SourceConfiguration: AppRunnerSourceConfiguration{
+			AutoDeploymentsEnabled: false,
+		},
+	}
+	repository := &service.SourceConfiguration.ImageRepository
+	imageConfig := &repository.ImageConfiguration
+
+	// image -> imageRepository
+	image := arguments["image"].StringValue()
+	if !strings.Contains(image, "@") && !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+		image += ":latest"
+	}
+	repository.ImageIdentifier = image
+	if strings.HasPrefix(image, "public.ecr.aws/") {
+		repository.ImageRepositoryType = "ECR_PUBLIC"
+	} else if match := appRunnerECRImageRegexp.FindStringSubmatch(image); match != nil {
+		repository.ImageRepositoryType = "ECR"
+		partition := "aws"
+		if len(match[2]) > 0 {
+			partition = "aws-cn"
+		}
+		service.SourceConfiguration.AuthenticationConfiguration = &AppRunnerAuthConfiguration{
+			AccessRoleArn: fmt.Sprintf("arn:%s:iam::%s:role/service-role/AppRunnerECRAccessRole", partition, match[1]),
+		}
+		warnings = multierror.Append(warnings, fmt.Errorf("using the AppRunnerECRAccessRole role to pull %s in apprunner service, replace it with a role that can read the repository", image))
+	} else {
+		errs = multierror.Append(errs, fmt.Errorf("unable to use image %s in apprunner service as only amazon ecr and ecr public images are supported", image))
+	}
+
+	// command -> startCommand
+	if len(arguments["command"].ListValue()) > 0 {
+		imageConfig.StartCommand = shellJoin(arguments["command"].ListValue())
+	}
+
+	// env -> runtimeEnvironmentVariables
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --env %s in apprunner service as passing through host environment variables is not supported", value))
+			continue
+		}
+		key, val := extractParts(value, "=")
+		if strings.HasPrefix(key, "AWSAPPRUNNER") {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --env %s in apprunner service as variables prefixed with AWSAPPRUNNER are reserved", key))
+			continue
+		}
+		if imageConfig.RuntimeEnvironmentVariables == nil {
+			imageConfig.RuntimeEnvironmentVariables = map[string]string{}
+		}
+		imageConfig.RuntimeEnvironmentVariables[key] = val
+	}
+
+	// publish -> port
+	port := 0
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			if p.Protocol != "tcp" {
+				errs = multierror.Append(errs, fmt.Errorf("unable to publish port %d/%s in apprunner service as only tcp ports are supported", p.Target, p.Protocol))
+				continue
+			}
+			if port != 0 && port != int(p.Target) {
+				errs = multierror.Append(errs, fmt.Errorf("unable to publish port %d in apprunner service as services serve a single port, and port %d is already published", p.Target, port))
+				continue
+			}
+			port = int(p.Target)
+		}
+	}
+	if port != 0 {
+		imageConfig.Port = strconv.Itoa(port)
+	} else {
+		warnings = multierror.Append(warnings, fmt.Errorf("no port is published, apprunner service will route traffic to port %d", appRunnerDefaultPort))
+	}
+
+	// cpus / memory -> instanceConfiguration
+	instance, instanceWarnings, err := appRunnerInstance(c.Cpus, c.Memory)
+	for _, warning := range instanceWarnings {
+		warnings = multierror.Append(warnings, warning)
+	}
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	service.InstanceConfiguration = instance
+
+	// health-cmd -> healthCheckConfiguration
+	if len(c.HealthCmd) > 0 {
+		if c.NoHealthcheck {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+		} else {
+			healthCheck := &AppRunnerHealthCheckConfiguration{
+				Protocol:           "TCP",
+				Interval:           5,
+				Timeout:            2,
+				HealthyThreshold:   1,
+				UnhealthyThreshold: 5,
+			}
+			if checkPort, path, ok := healthCheckURL(c.HealthCmd); ok {
+				healthCheck.Protocol = "HTTP"
+				healthCheck.Path = path
+				servicePort := port
+				if servicePort == 0 {
+					servicePort = appRunnerDefaultPort
+				}
+				if checkPort != servicePort {
+					warnings = multierror.Append(warnings, fmt.Errorf("checking path %s on port %d instead of port %d in apprunner service as health checks use the service port", path, servicePort, checkPort))
+				}
+			} else {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to run --health-cmd in apprunner service as health checks only support http and tcp, checking the tcp port instead"))
+			}
+
+			for _, field := range []struct {
+				flag  string
+				value string
+				check *int
+			}{
+				{"--health-interval", c.HealthInterval, &healthCheck.Interval},
+				{"--health-timeout", c.HealthTimeout, &healthCheck.Timeout},
+			} {
+				if field.value == "0s" {
+					continue
+				}
+				seconds, err := durationToSeconds(field.value)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("unable to parse %s flag to duration: %w", field.flag, err))
+					continue
+				}
+				*field.check, err = appRunnerHealthCheckValue(field.flag, field.value, seconds)
+				if err != nil {
+					warnings = multierror.Append(warnings, err)
+				}
+			}
+			if c.HealthRetries > 0 {
+				healthCheck.UnhealthyThreshold, err = appRunnerHealthCheckValue("--health-retries", strconv.FormatUint(c.HealthRetries, 10), int(c.HealthRetries))
+				if err != nil {
+					warnings = multierror.Append(warnings, err)
+				}
+			}
+			service.HealthCheckConfiguration = healthCheck
+		}
+	}
+
+	// label -> tags
+	for _, value := range c.Label {
+		key, val := extractParts(value, "=")
+		service.Tags = append(service.Tags, AppRunnerTag{
+			Key:   key,
+			Value: val,
+		})
+	}
+
+	// restart: app runner always replaces stopped instances
+	if len(c.Restart) > 0 && c.Restart != "no" && c.Restart != "always" && c.Restart != "unless-stopped" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in apprunner service as instances are always replaced when they stop", c.Restart))
+	}
+
+	// every other flag has no app runner equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if appRunnerFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		errs = multierror.Append(errs, fmt.Errorf("unable to set --%s property in apprunner service as the property is not supported", flag.Name))
+	}
+
+	return service, warnings, errs
+}
+
+// MarshalAppRunner marshals an App Runner service to CreateService input JSON
+func MarshalAppRunner(service *AppRunnerService) ([]byte, error) {
+	return json.MarshalIndent(service, "", "  ")
+}
+
+// MarshalAppRunnerCloudFormation marshals an App Runner service as a
+// CloudFormation YAML template
+func MarshalAppRunnerCloudFormation(service *AppRunnerService) ([]byte, error) {
+	properties := *service
+	imageConfig := &properties.SourceConfiguration.ImageRepository.ImageConfiguration
+	imageConfig.RuntimeEnvironmentVariablesList = nil
+	for _, key := range sortedKeys(imageConfig.RuntimeEnvironmentVariables) {
+		imageConfig.RuntimeEnvironmentVariablesList = append(imageConfig.RuntimeEnvironmentVariablesList, AppRunnerKeyValue{
+			Name:  key,
+			Value: imageConfig.RuntimeEnvironmentVariables[key],
+		})
+	}
+
+	template := CloudFormationTemplate{
+		AWSTemplateFormatVersion: "2010-09-09",
+		Resources: map[string]CloudFormationResource{
+			"Service": {
+				Type:       "AWS::AppRunner::Service",
+				Properties: &properties,
+			},
+		},
+	}
+	return yaml.Marshal(template)
+}
+
+// appRunnerInstance returns the smallest App Runner instance size that fits
+// --cpus and --memory, raising the vCPU when the memory does not fit
+func appRunnerInstance(cpus float32, memoryBytes int64) (AppRunnerInstanceConfiguration, []error, error) {
+	var warnings []error
+	cpuValue := strconv.FormatFloat(float64(cpus), 'f', -1, 32)
+	largest := appRunnerSizes[len(appRunnerSizes)-1]
+
+	index := 0
+	if cpus > 0 {
+		if float64(cpus) > largest.vcpu {
+			return AppRunnerInstanceConfiguration{}, warnings, fmt.Errorf("unable to set --cpus %s in apprunner service as instances have at most %s vCPU", cpuValue, strconv.FormatFloat(largest.vcpu, 'f', -1, 64))
+		}
+		for i, size := range appRunnerSizes {
+			if float64(cpus) <= size.vcpu {
+				index = i
+				break
+			}
+		}
+		if float64(cpus) != appRunnerSizes[index].vcpu {
+			warnings = append(warnings, fmt.Errorf("rounding --cpus %s up to %s in apprunner service to match an instance size", cpuValue, strconv.FormatFloat(appRunnerSizes[index].vcpu, 'f', -1, 64)))
+		}
+	} else if memoryBytes == 0 {
+		return appRunnerInstanceConfiguration(appRunnerDefaultVCPU, appRunnerDefaultMemoryGB), warnings, nil
+	}
+
+	if memoryBytes == 0 {
+		size := appRunnerSizes[index]
+		return appRunnerInstanceConfiguration(size.vcpu, size.memory[0]), warnings, nil
+	}
+
+	memory := float64(memoryBytes) / (1024 * 1024 * 1024)
+	for i := index; i < len(appRunnerSizes); i++ {
+		for _, value := range appRunnerSizes[i].memory {
+			if value < memory {
+				continue
+			}
+			if i != index {
+				warnings = append(warnings, fmt.Errorf("raising the vCPU to %s in apprunner service to fit --memory %d", strconv.FormatFloat(appRunnerSizes[i].vcpu, 'f', -1, 64), memoryBytes))
+			}
+			if value != memory {
+				warnings = append(warnings, fmt.Errorf("rounding --memory %d up to %s GB in apprunner service to match an instance size", memoryBytes, strconv.FormatFloat(value, 'f', -1, 64)))
+			}
+			return appRunnerInstanceConfiguration(appRunnerSizes[i].vcpu, value), warnings, nil
+		}
+	}
+
+	maxMemory := largest.memory[len(largest.memory)-1]
+	return AppRunnerInstanceConfiguration{}, warnings, fmt.Errorf("unable to set --memory %d in apprunner service as instances have at most %s GB of memory", memoryBytes, strconv.FormatFloat(maxMemory, 'f', -1, 64))
+}
+
+// appRunnerInstanceConfiguration formats an instance size the way App Runner
+// expects it, e.g., "0.25 vCPU" and "0.5 GB"
+func appRunnerInstanceConfiguration(vcpu float64, memory float64) AppRunnerInstanceConfiguration {
+	return AppRunnerInstanceConfiguration{
+		Cpu:    strconv.FormatFloat(vcpu, 'f', -1, 64) + " vCPU",
+		Memory: strconv.FormatFloat(memory, 'f', -1, 64) + " GB",
+	}
+}
+
+// appRunnerHealthCheckValue clamps a health check setting to the range App
+// Runner accepts
+func appRunnerHealthCheckValue(flag string, value string, number int) (int, error) {
+	clamped := int(math.Max(appRunnerHealthCheckMin, math.Min(appRunnerHealthCheckMax, float64(number))))
+	if clamped != number {
+		return clamped, fmt.Errorf("setting %s %s to %d in apprunner service as health checks accept values between %d and %d", flag, value, clamped, appRunnerHealthCheckMin, appRunnerHealthCheckMax)
+	}
+	return clamped, nil
+}
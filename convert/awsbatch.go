@@ -0,0 +1,388 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// awsBatchMaxAttempts holds the most attempts a batch retry strategy allows
+const awsBatchMaxAttempts = 10
+
+// awsBatchMinTimeout holds the shortest attempt duration in seconds that a
+// batch job timeout allows
+const awsBatchMinTimeout = 60
+
+// awsBatchDefaultVCPU and awsBatchDefaultMemoryMiB hold the resources
+// requested when --cpus and --memory are not set
+const (
+	awsBatchDefaultVCPU      = 1
+	awsBatchDefaultMemoryMiB = 2048
+)
+
+// awsBatchFargateSizes holds the vCPU values accepted by fargate batch jobs,
+// mapped to the memory values in MiB accepted for each of them
+var awsBatchFargateSizes = []struct {
+	vcpu   float64
+	memory []int
+}{
+	{0.25, []int{512, 1024, 2048}},
+	{0.5, awsBatchMemoryRange(1024, 4096, 1024)},
+	{1, awsBatchMemoryRange(2048, 8192, 1024)},
+	{2, awsBatchMemoryRange(4096, 16384, 1024)},
+	{4, awsBatchMemoryRange(8192, 30720, 1024)},
+	{8, awsBatchMemoryRange(16384, 61440, 4096)},
+	{16, awsBatchMemoryRange(32768, 122880, 8192)},
+}
+
+// awsBatchFlags holds the docker run flags that are mapped to the batch job
+// definition
+var awsBatchFlags = map[string]bool{
+	"cpus":              true,
+	"detach":            true,
+	"device":            true,
+	"env":               true,
+	"gpus":              true,
+	"init":              true,
+	"label":             true,
+	"log-driver":        true,
+	"log-opt":           true,
+	"memory":            true,
+	"memory-swap":       true,
+	"memory-swappiness": true,
+	"mount":             true,
+	"name":              true,
+	"platform":          true,
+	"privileged":        true,
+	"read-only":         true,
+	"restart":           true,
+	"rm":                true,
+	"shm-size":          true,
+	"stop-timeout":      true,
+	"tmpfs":             true,
+	"ulimit":            true,
+	"user":              true,
+	"volume":            true,
+}
+
+// AWSBatchJobDefinition represents an AWS Batch container job definition
+type AWSBatchJobDefinition struct {
+	JobDefinitionName    string                      `json:"jobDefinitionName"`
+	Type                 string                      `json:"type"`
+	PlatformCapabilities []string                    `json:"platformCapabilities"`
+	ContainerProperties  AWSBatchContainerProperties `json:"containerProperties"`
+	RetryStrategy        *AWSBatchRetryStrategy      `json:"retryStrategy,omitempty"`
+	Timeout              *AWSBatchJobTimeout         `json:"timeout,omitempty"`
+	Tags                 map[string]string           `json:"tags,omitempty"`
+}
+
+// AWSBatchContainerProperties represents the container of a batch job
+// definition. It shares its nested types with ECS container definitions.
+type AWSBatchContainerProperties struct {
+	Image                  string                   `json:"image"`
+	Command                []string                 `json:"command,omitempty"`
+	JobRoleArn             string                   `json:"jobRoleArn,omitempty"`
+	ExecutionRoleArn       string                   `json:"executionRoleArn,omitempty"`
+	ResourceRequirements   []ECSResourceRequirement `json:"resourceRequirements"`
+	Environment            []ECSKeyValuePair        `json:"environment,omitempty"`
+	Volumes                []ECSVolume              `json:"volumes,omitempty"`
+	MountPoints            []ECSMountPoint          `json:"mountPoints,omitempty"`
+	ReadonlyRootFilesystem bool                     `json:"readonlyRootFilesystem,omitempty"`
+	Privileged             bool                     `json:"privileged,omitempty"`
+	Ulimits                []ECSUlimit              `json:"ulimits,omitempty"`
+	User                   string                   `json:"user,omitempty"`
+	LinuxParameters        *ECSLinuxParameters      `json:"linuxParameters,omitempty"`
+	LogConfiguration       *ECSLogConfiguration     `json:"logConfiguration,omitempty"`
+	RuntimePlatform        *ECSRuntimePlatform      `json:"runtimePlatform,omitempty"`
+}
+
+// AWSBatchRetryStrategy represents the retry strategy of a batch job
+type AWSBatchRetryStrategy struct {
+	Attempts int `json:"attempts"`
+}
+
+// AWSBatchJobTimeout represents the timeout of a batch job attempt
+type AWSBatchJobTimeout struct {
+	AttemptDurationSeconds int `json:"attemptDurationSeconds"`
+}
+
+// ToAWSBatch converts docker run arguments to an AWS Batch job definition.
+// The container is built with ToECS, and the fields that batch does not
+// support are removed from it. The platform is FARGATE when
+// --dre-ecs-launch-type FARGATE is set, and EC2 otherwise.
+func ToAWSBatch(projectName string, c *arguments.Args, arguments map[string]command.Argument, ecsOpts ECSOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+
+	output, _, errs := ToECS(projectName, c, arguments, ECSOptions{})
+	taskDef := output.(*ECSTaskDefinition)
+	definition := taskDef.ContainerDefinitions[0]
+
+	// dre-ecs-launch-type -> platformCapabilities
+	platform := ""
+	for _, value := range ecsOpts.RequiresCompatibilities {
+		value = strings.ToUpper(value)
+		if value != "EC2" && value != "FARGATE" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --dre-ecs-launch-type %s in aws batch job definition as only EC2 and FARGATE are supported", value))
+			continue
+		}
+		if len(platform) > 0 && value != platform {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --dre-ecs-launch-type %s as aws batch job definitions support a single platform", value))
+			continue
+		}
+		platform = value
+	}
+	if len(platform) == 0 {
+		platform = "EC2"
+	}
+
+	jobDef := &AWSBatchJobDefinition{
+		JobDefinitionName:    taskDef.Family,
+		Type:                 "container",
+		PlatformCapabilities: []string{platform},
+		ContainerProperties: AWSBatchContainerProperties{
+			Image:                  arguments["image"].StringValue(),
+			Command:                definition.Command,
+			JobRoleArn:             ecsOpts.TaskRoleArn,
+			ExecutionRoleArn:       ecsOpts.ExecutionRoleArn,
+			Volumes:                taskDef.Volumes,
+			MountPoints:            definition.MountPoints,
+			ReadonlyRootFilesystem: definition.ReadonlyRootFilesystem,
+			Privileged:             definition.Privileged,
+			Ulimits:                definition.Ulimits,
+			User:                   definition.User,
+			LinuxParameters:        definition.LinuxParameters,
+			LogConfiguration:       definition.LogConfiguration,
+			RuntimePlatform:        taskDef.RuntimePlatform,
+		},
+		Tags: definition.DockerLabels,
+	}
+	container := &jobDef.ContainerProperties
+	if parameters := container.LinuxParameters; parameters != nil {
+		parameters.Capabilities = nil
+		if len(parameters.Devices) == 0 && !parameters.InitProcessEnabled && parameters.SharedMemorySize == 0 && len(parameters.Tmpfs) == 0 && parameters.MaxSwap == 0 && parameters.Swappiness == 0 {
+			container.LinuxParameters = nil
+		}
+	}
+
+	// env -> environment, without host pass-through variables
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in aws batch job definition as passing through host environment variables is not supported", value))
+			continue
+		}
+		key, val := extractParts(value, "=")
+		container.Environment = append(container.Environment, ECSKeyValuePair{
+			Name:  key,
+			Value: val,
+		})
+	}
+
+	// fargate does not run privileged containers or mount host resources
+	if platform == "FARGATE" {
+		container.Privileged = false
+		if c.Privileged {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --privileged property in aws batch job definition as fargate does not support privileged containers"))
+		}
+		if len(c.Gpus) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --gpus property in aws batch job definition as fargate does not support gpus"))
+		}
+
+		volumes := []ECSVolume{}
+		hostVolumes := map[string]bool{}
+		for _, volume := range container.Volumes {
+			if volume.Host != nil {
+				hostVolumes[volume.Name] = true
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to mount host path %s in aws batch job definition as fargate does not support host volumes", volume.Host.SourcePath))
+				continue
+			}
+			volumes = append(volumes, volume)
+		}
+		mountPoints := []ECSMountPoint{}
+		for _, mountPoint := range container.MountPoints {
+			if !hostVolumes[mountPoint.SourceVolume] {
+				mountPoints = append(mountPoints, mountPoint)
+			}
+		}
+		container.Volumes = volumes
+		container.MountPoints = mountPoints
+
+		if parameters := container.LinuxParameters; parameters != nil {
+			for _, field := range []struct {
+				flag  string
+				isSet bool
+				clear func()
+			}{
+				{"--device", len(parameters.Devices) > 0, func() { parameters.Devices = nil }},
+				{"--memory-swap", parameters.MaxSwap > 0, func() { parameters.MaxSwap = 0 }},
+				{"--memory-swappiness", parameters.Swappiness > 0, func() { parameters.Swappiness = 0 }},
+				{"--shm-size", parameters.SharedMemorySize > 0, func() { parameters.SharedMemorySize = 0 }},
+				{"--tmpfs", len(parameters.Tmpfs) > 0, func() { parameters.Tmpfs = nil }},
+			} {
+				if !field.isSet {
+					continue
+				}
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set %s property in aws batch job definition as fargate does not support it", field.flag))
+				field.clear()
+			}
+			if !parameters.InitProcessEnabled {
+				container.LinuxParameters = nil
+			}
+		}
+	}
+
+	// cpus / memory / gpus -> resourceRequirements
+	vcpu, memory, resourceWarnings := awsBatchResources(platform, c.Cpus, c.Memory)
+	for _, warning := range resourceWarnings {
+		warnings = multierror.Append(warnings, warning)
+	}
+	container.ResourceRequirements = []ECSResourceRequirement{
+		{Value: vcpu, Type: "VCPU"},
+		{Value: strconv.Itoa(memory), Type: "MEMORY"},
+	}
+	if platform == "EC2" {
+		for _, requirement := range definition.ResourceRequirements {
+			if requirement.Type == "GPU" {
+				container.ResourceRequirements = append(container.ResourceRequirements, requirement)
+			}
+		}
+	}
+
+	// restart -> retryStrategy
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, retries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else if mode == "on-failure" {
+			attempts := retries + 1
+			if retries == 0 || attempts > awsBatchMaxAttempts {
+				warnings = multierror.Append(warnings, fmt.Errorf("limiting --restart %s to %d attempts in aws batch job definition", c.Restart, awsBatchMaxAttempts))
+				attempts = awsBatchMaxAttempts
+			}
+			jobDef.RetryStrategy = &AWSBatchRetryStrategy{Attempts: attempts}
+		} else {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in aws batch job definition as batch jobs run to completion", c.Restart))
+		}
+	}
+
+	// stop-timeout -> timeout
+	if c.StopTimeout > 0 {
+		seconds := c.StopTimeout
+		if seconds < awsBatchMinTimeout {
+			warnings = multierror.Append(warnings, fmt.Errorf("raising --stop-timeout %d to %d seconds in aws batch job definition as it is the shortest attempt duration", c.StopTimeout, awsBatchMinTimeout))
+			seconds = awsBatchMinTimeout
+		}
+		jobDef.Timeout = &AWSBatchJobTimeout{AttemptDurationSeconds: seconds}
+	}
+
+	// entrypoint: job definitions only override the command
+	seen := map[string]bool{}
+	if c.EntrypointCleared {
+		errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in aws batch job definition as the property is not supported, build an image without the entrypoint instead"))
+		seen["entrypoint"] = true
+	}
+
+	// every other flag has no batch equivalent
+	for _, flag := range dockerRunFlags(c) {
+		if awsBatchFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in aws batch job definition as the property is not supported", flag.Name))
+	}
+
+	return jobDef, warnings, errs
+}
+
+// MarshalAWSBatch marshals an AWS Batch job definition to JSON
+func MarshalAWSBatch(jobDef *AWSBatchJobDefinition) ([]byte, error) {
+	return json.MarshalIndent(jobDef, "", "  ")
+}
+
+// awsBatchResources returns the vCPU and memory in MiB requested by a batch
+// job. EC2 jobs request whole vCPUs, and fargate jobs request one of the
+// fargate vCPU and memory combinations, rounded up to fit --cpus and --memory.
+func awsBatchResources(platform string, cpus float32, memoryBytes int64) (string, int, []error) {
+	var warnings []error
+	cpuValue := strconv.FormatFloat(float64(cpus), 'f', -1, 32)
+
+	memory := 0
+	if memoryBytes > 0 {
+		memory = int(math.Ceil(float64(memoryBytes) / (1024 * 1024)))
+	}
+
+	if platform == "EC2" {
+		vcpu := awsBatchDefaultVCPU
+		if cpus > 0 {
+			vcpu = int(math.Ceil(float64(cpus)))
+			if float64(vcpu) != float64(cpus) {
+				warnings = append(warnings, fmt.Errorf("rounding --cpus %s up to %d in aws batch job definition as ec2 jobs request whole vCPUs", cpuValue, vcpu))
+			}
+		}
+		if memory == 0 {
+			memory = awsBatchDefaultMemoryMiB
+		}
+		return strconv.Itoa(vcpu), memory, warnings
+	}
+
+	// without --cpus, pick the smallest size that fits the memory
+	index := 0
+	if cpus > 0 {
+		index = len(awsBatchFargateSizes) - 1
+		for i, size := range awsBatchFargateSizes {
+			if float64(cpus) <= size.vcpu {
+				index = i
+				break
+			}
+		}
+	} else if memory == 0 {
+		for i, size := range awsBatchFargateSizes {
+			if size.vcpu == awsBatchDefaultVCPU {
+				index = i
+			}
+		}
+	}
+
+	size := awsBatchFargateSizes[index]
+	if cpus > 0 && float64(cpus) != size.vcpu {
+		warnings = append(warnings, fmt.Errorf("rounding --cpus %s up to %s in aws batch job definition to match a fargate size", cpuValue, strconv.FormatFloat(size.vcpu, 'f', -1, 64)))
+	}
+
+	if memory == 0 {
+		return strconv.FormatFloat(size.vcpu, 'f', -1, 64), size.memory[0], warnings
+	}
+
+	for i := index; i < len(awsBatchFargateSizes); i++ {
+		for _, value := range awsBatchFargateSizes[i].memory {
+			if value < memory {
+				continue
+			}
+			if i != index {
+				warnings = append(warnings, fmt.Errorf("raising the vCPU to %s in aws batch job definition to fit --memory %d", strconv.FormatFloat(awsBatchFargateSizes[i].vcpu, 'f', -1, 64), memoryBytes))
+			}
+			if value != memory {
+				warnings = append(warnings, fmt.Errorf("rounding --memory %d up to %d MiB in aws batch job definition to match a fargate size", memoryBytes, value))
+			}
+			return strconv.FormatFloat(awsBatchFargateSizes[i].vcpu, 'f', -1, 64), value, warnings
+		}
+	}
+
+	largest := awsBatchFargateSizes[len(awsBatchFargateSizes)-1]
+	maxMemory := largest.memory[len(largest.memory)-1]
+	warnings = append(warnings, fmt.Errorf("lowering --memory %d to %d MiB in aws batch job definition as it is the largest fargate size", memoryBytes, maxMemory))
+	return strconv.FormatFloat(largest.vcpu, 'f', -1, 64), maxMemory, warnings
+}
+
+// awsBatchMemoryRange returns the memory values from min to max in steps
+func awsBatchMemoryRange(min int, max int, step int) []int {
+	values := []int{}
+	for value := min; value <= max; value += step {
+		values = append(values, value)
+	}
+	return values
+}
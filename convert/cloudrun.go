@@ -0,0 +1,478 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mattn/go-shellwords"
+	"gopkg.in/yaml.v2"
+)
+
+// cloudRunLabelKey and cloudRunLabelValue match the keys and values allowed
+// in Google Cloud labels. Other docker labels are set as annotations.
+var (
+	cloudRunLabelKey   = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+	cloudRunLabelValue = regexp.MustCompile(`^[a-z0-9_-]{0,63}$`)
+)
+
+// cloudRunDefaultPort is the port Cloud Run sends requests to when the
+// service does not set a container port
+const cloudRunDefaultPort = 8080
+
+// cloudRunCPUs holds the whole numbers of cpus Cloud Run allows. Fractions
+// are only allowed below one cpu.
+var cloudRunCPUs = []float32{1, 2, 4, 6, 8}
+
+// cloudRunReservedEnv holds the environment variables that Cloud Run sets
+// itself and rejects in a service
+var cloudRunReservedEnv = map[string]bool{
+	"PORT":            true,
+	"K_CONFIGURATION": true,
+	"K_REVISION":      true,
+	"K_SERVICE":       true,
+}
+
+// cloudRunFlags holds the docker run flags that are mapped to the Knative
+// Service, or rejected with an error
+var cloudRunFlags = map[string]bool{
+	"annotation":          true,
+	"cpus":                true,
+	"detach":              true,
+	"device":              true,
+	"entrypoint":          true,
+	"env":                 true,
+	"expose":              true,
+	"health-cmd":          true,
+	"health-interval":     true,
+	"health-retries":      true,
+	"health-start-period": true,
+	"health-timeout":      true,
+	"ipc":                 true,
+	"label":               true,
+	"memory":              true,
+	"mount":               true,
+	"name":                true,
+	"network":             true,
+	"no-healthcheck":      true,
+	"pid":                 true,
+	"platform":            true,
+	"privileged":          true,
+	"publish":             true,
+	"restart":             true,
+	"tmpfs":               true,
+	"userns":              true,
+	"uts":                 true,
+	"volume":              true,
+	"workdir":             true,
+}
+
+// CloudRunService represents a Knative serving.knative.dev/v1 Service, as
+// deployed by `gcloud run services replace`
+type CloudRunService struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   KubernetesObjectMeta `yaml:"metadata"`
+	Spec       CloudRunServiceSpec  `yaml:"spec"`
+}
+
+// CloudRunServiceSpec represents the spec of a Knative Service
+type CloudRunServiceSpec struct {
+	Template CloudRunRevisionTemplate `yaml:"template"`
+}
+
+// CloudRunRevisionTemplate represents the template of the revisions of a
+// Knative Service
+type CloudRunRevisionTemplate struct {
+	Metadata *KubernetesObjectMeta `yaml:"metadata,omitempty"`
+	Spec     CloudRunRevisionSpec  `yaml:"spec"`
+}
+
+// CloudRunRevisionSpec represents the spec of a revision
+type CloudRunRevisionSpec struct {
+	Containers []KubernetesContainer `yaml:"containers"`
+	Volumes    []KubernetesVolume    `yaml:"volumes,omitempty"`
+}
+
+// ToCloudRun converts docker run arguments to a Knative Service for Cloud
+// Run. Flags that Cloud Run forbids, such as --privileged, host namespaces,
+// devices and bind mounts, are returned as errors.
+func ToCloudRun(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	containerName := "app"
+	if len(c.ContainerName) > 0 {
+		containerName = kubernetesName(c.ContainerName)
+	}
+
+	service := &CloudRunService{
+		APIVersion: "serving.knative.dev/v1",
+		Kind:       "Service",
+		Metadata: KubernetesObjectMeta{
+			Name: kubernetesName(name),
+		},
+	}
+	container := KubernetesContainer{
+		Name:       containerName,
+		Image:      arguments["image"].StringValue(),
+		Args:       arguments["command"].ListValue(),
+		WorkingDir: c.Workdir,
+	}
+	revisionSpec := CloudRunRevisionSpec{}
+
+	// forbidden: privileged, host namespaces and devices
+	if c.Privileged {
+		errs = multierror.Append(errs, fmt.Errorf("unable to set --privileged property in cloudrun service as Cloud Run does not allow privileged containers"))
+	}
+	namespaces := []struct {
+		flag  string
+		value string
+	}{
+		{"ipc", c.Ipc},
+		{"network", c.Network},
+		{"pid", c.Pid},
+		{"userns", c.Userns},
+		{"uts", c.Uts},
+	}
+	for _, namespace := range namespaces {
+		if namespace.value == "host" {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --%s host in cloudrun service as Cloud Run does not allow host namespaces", namespace.flag))
+		}
+	}
+	if len(c.Network) > 0 && c.Network != "host" && c.Network != "bridge" && c.Network != "default" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network %s in cloudrun service as the property is not supported", c.Network))
+	}
+	if len(c.Pid) > 0 && c.Pid != "host" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pid %s in cloudrun service as the property is not supported", c.Pid))
+	}
+	if len(c.Ipc) > 0 && c.Ipc != "host" && c.Ipc != "private" && c.Ipc != "shareable" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --ipc %s in cloudrun service as the property is not supported", c.Ipc))
+	}
+	if len(c.Device) > 0 {
+		errs = multierror.Append(errs, fmt.Errorf("unable to set --device property in cloudrun service as Cloud Run does not allow host devices"))
+	}
+
+	// platform: Cloud Run only runs linux/amd64 images
+	if len(c.Platform) > 0 && c.Platform != "linux/amd64" && c.Platform != "linux" {
+		errs = multierror.Append(errs, fmt.Errorf("unable to set --platform %s in cloudrun service as Cloud Run only runs linux/amd64 images", c.Platform))
+	}
+
+	// entrypoint -> command
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			container.Command = args
+		}
+	} else if c.EntrypointCleared {
+		// command replaces the image entrypoint, so the command runs on its own
+		if len(container.Args) == 0 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in cloudrun service without a command to run"))
+		}
+		container.Command = container.Args
+		container.Args = nil
+	}
+
+	// env -> env
+	for _, value := range c.Env {
+		key, val := extractParts(value, "=")
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in cloudrun service as passing through host environment variables is not supported", value))
+			continue
+		}
+		if cloudRunReservedEnv[key] {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --env %s in cloudrun service as Cloud Run reserves the variable", key))
+			continue
+		}
+		container.Env = append(container.Env, KubernetesEnvVar{
+			Name:  key,
+			Value: val,
+		})
+	}
+
+	// publish / expose -> the single container port
+	ports := []string{}
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Target, p.Protocol))
+		}
+	}
+	if len(ports) == 0 {
+		for _, value := range c.Expose {
+			parsed, err := types.ParsePortConfig(value)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --expose flag: %w", err))
+				continue
+			}
+			for _, p := range parsed {
+				ports = append(ports, fmt.Sprintf("%d/%s", p.Target, p.Protocol))
+			}
+		}
+	}
+	for i, value := range ports {
+		port, protocol := extractParts(value, "/")
+		if protocol != "tcp" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set port %s in cloudrun service as only tcp ports are supported", value))
+			continue
+		}
+		if len(container.Ports) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set port %s in cloudrun service as Cloud Run sends requests to a single port", strings.Join(ports[i:], ", ")))
+			break
+		}
+		containerPort, _ := strconv.Atoi(port)
+		container.Ports = []KubernetesContainerPort{{ContainerPort: containerPort}}
+	}
+
+	// cpus / memory -> resources.limits
+	if c.Cpus > 0 {
+		cpu, rounded, err := cloudRunCPU(c.Cpus)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			if rounded {
+				warnings = multierror.Append(warnings, fmt.Errorf("rounding --cpus %v up to %s in cloudrun service as Cloud Run only allows 1, 2, 4, 6 or 8 cpus above one cpu", c.Cpus, cpu))
+			}
+			container.Resources = kubernetesResources(container.Resources)
+			container.Resources.Limits["cpu"] = cpu
+		}
+	}
+	if c.Memory > 0 {
+		container.Resources = kubernetesResources(container.Resources)
+		container.Resources.Limits["memory"] = cloudRunQuantity(c.Memory)
+	}
+
+	// health-cmd / health-* -> startupProbe and livenessProbe, as an http
+	// check since Cloud Run does not run commands in probes
+	checkPort, checkPath, isHTTPCheck := healthCheckURL(c.HealthCmd)
+	if len(c.HealthCmd) > 0 && !c.NoHealthcheck && !isHTTPCheck {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --health-cmd in cloudrun service as probes only support http, tcp and grpc checks"))
+	} else if len(c.HealthCmd) > 0 && !c.NoHealthcheck {
+		servicePort := cloudRunDefaultPort
+		if len(container.Ports) > 0 {
+			servicePort = container.Ports[0].ContainerPort
+		}
+		if checkPort != servicePort {
+			warnings = multierror.Append(warnings, fmt.Errorf("checking path %s on port %d instead of port %d in cloudrun service as probes use the container port", checkPath, servicePort, checkPort))
+		}
+		probe := &KubernetesProbe{
+			HTTPGet: &KubernetesHTTPGetAction{
+				Path: checkPath,
+				Port: servicePort,
+			},
+		}
+		if c.HealthInterval != "0s" {
+			seconds, err := durationToSeconds(c.HealthInterval)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-interval flag to duration: %w", err))
+			} else {
+				probe.PeriodSeconds = seconds
+			}
+		}
+		if c.HealthTimeout != "0s" {
+			seconds, err := durationToSeconds(c.HealthTimeout)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-timeout flag to duration: %w", err))
+			} else {
+				probe.TimeoutSeconds = seconds
+			}
+		}
+		if c.HealthStartPeriod != "0s" {
+			seconds, err := durationToSeconds(c.HealthStartPeriod)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-start-period flag to duration: %w", err))
+			} else {
+				probe.InitialDelaySeconds = seconds
+			}
+		}
+		if c.HealthRetries > 0 {
+			probe.FailureThreshold = int(c.HealthRetries)
+		}
+
+		livenessProbe := *probe
+		livenessProbe.InitialDelaySeconds = 0
+		container.StartupProbe = probe
+		container.LivenessProbe = &livenessProbe
+	} else if len(c.HealthCmd) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+	}
+
+	// label -> metadata.labels when valid as a Google Cloud label, otherwise
+	// metadata.annotations
+	for _, value := range c.Label {
+		key, val := extractParts(value, "=")
+		if cloudRunLabelKey.MatchString(key) && cloudRunLabelValue.MatchString(val) {
+			if service.Metadata.Labels == nil {
+				service.Metadata.Labels = map[string]string{}
+			}
+			service.Metadata.Labels[key] = val
+			continue
+		}
+		if service.Metadata.Annotations == nil {
+			service.Metadata.Annotations = map[string]string{}
+		}
+		service.Metadata.Annotations[key] = val
+	}
+
+	// annotation -> revision annotations
+	if len(c.Annotation) > 0 {
+		annotations := map[string]string{}
+		for _, value := range c.Annotation {
+			key, val := extractParts(value, "=")
+			annotations[key] = val
+		}
+		service.Spec.Template.Metadata = &KubernetesObjectMeta{
+			Annotations: annotations,
+		}
+	}
+
+	// volume: bind mounts are forbidden and named volumes are not supported
+	for _, value := range c.Volume {
+		source, _ := extractParts(value, ":")
+		if !strings.Contains(value, ":") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in cloudrun service as the property is not supported", value))
+		} else if isNamedVolume(source) {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in cloudrun service as named volumes are not supported", value))
+		} else {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --volume %s in cloudrun service as Cloud Run does not allow bind mounts", value))
+		}
+	}
+
+	// mount: tmpfs -> in-memory emptyDir, bind mounts are forbidden
+	for _, value := range c.Mount {
+		parsed, err := parseDockerMount(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		switch parsed["type"] {
+		case "tmpfs":
+			size := int64(0)
+			if options, ok := parsed["tmpfs_options"].(map[string]interface{}); ok {
+				size, _ = options["size"].(int64)
+			}
+			cloudRunTmpfs(&container, &revisionSpec, parsed["target"].(string), size)
+		case "bind":
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --mount %s in cloudrun service as Cloud Run does not allow bind mounts", value))
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --mount type=%s in cloudrun service as the mount type is not supported", parsed["type"]))
+		}
+	}
+
+	// tmpfs -> in-memory emptyDir
+	for _, value := range c.Tmpfs {
+		target, options := extractParts(value, ":")
+		size := int64(0)
+		for _, option := range strings.Split(options, ",") {
+			key, val := extractParts(option, "=")
+			if key != "size" {
+				continue
+			}
+			bytes, err := toSize(val)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --tmpfs flag due to invalid size value: %w", err))
+				continue
+			}
+			size = bytes
+		}
+		cloudRunTmpfs(&container, &revisionSpec, target, size)
+	}
+
+	// restart: Cloud Run always restarts containers
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, _, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else if mode != "always" && mode != "unless-stopped" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in cloudrun service as Cloud Run always restarts containers", c.Restart))
+		}
+	}
+
+	// every other flag has no Cloud Run equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if cloudRunFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in cloudrun service as the property is not supported", flag.Name))
+	}
+
+	revisionSpec.Containers = []KubernetesContainer{container}
+	service.Spec.Template.Spec = revisionSpec
+
+	return service, warnings, errs
+}
+
+// MarshalCloudRun marshals a Knative Service to YAML format
+func MarshalCloudRun(service *CloudRunService) ([]byte, error) {
+	return yaml.Marshal(service)
+}
+
+// cloudRunTmpfs mounts an in-memory emptyDir volume into the container
+func cloudRunTmpfs(container *KubernetesContainer, spec *CloudRunRevisionSpec, target string, size int64) {
+	volume := KubernetesVolume{
+		Name:     fmt.Sprintf("tmpfs-%d", len(spec.Volumes)),
+		EmptyDir: &KubernetesEmptyDirVolumeSource{Medium: "Memory"},
+	}
+	if size > 0 {
+		volume.EmptyDir.SizeLimit = cloudRunQuantity(size)
+	}
+
+	spec.Volumes = append(spec.Volumes, volume)
+	container.VolumeMounts = append(container.VolumeMounts, KubernetesVolumeMount{
+		Name:      volume.Name,
+		MountPath: target,
+	})
+}
+
+// cloudRunQuantity returns a size in bytes as a Kubernetes quantity, using
+// the largest binary unit that divides it
+func cloudRunQuantity(bytes int64) string {
+	switch {
+	case bytes%(1024*1024*1024) == 0:
+		return fmt.Sprintf("%dGi", bytes/(1024*1024*1024))
+	case bytes%(1024*1024) == 0:
+		return fmt.Sprintf("%dMi", bytes/(1024*1024))
+	case bytes%1024 == 0:
+		return fmt.Sprintf("%dKi", bytes/1024)
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// cloudRunCPU returns the cpu limit for --cpus. Fractions below one cpu are
+// kept in millicpus, larger values are rounded up to the next number of cpus
+// Cloud Run allows, returning true when the value was rounded.
+func cloudRunCPU(cpus float32) (string, bool, error) {
+	if cpus < 1 {
+		return fmt.Sprintf("%dm", int(math.Round(float64(cpus)*1000))), false, nil
+	}
+
+	for _, allowed := range cloudRunCPUs {
+		if cpus <= allowed {
+			return strconv.Itoa(int(allowed)), cpus != allowed, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("unable to set --cpus %v in cloudrun service as Cloud Run allows at most %v cpus", cpus, cloudRunCPUs[len(cloudRunCPUs)-1])
+}
@@ -17,6 +17,12 @@ import (
 )
 
 func ToCompose(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	return toComposeService(projectName, "app", c, arguments)
+}
+
+// toComposeService converts docker run arguments to a compose project holding
+// a single service with the given name
+func toComposeService(projectName string, serviceName string, c *arguments.Args, arguments map[string]command.Argument) (*types.Project, *multierror.Error, *multierror.Error) {
 	var warnings *multierror.Error
 	var errs *multierror.Error
 	project := &types.Project{
@@ -24,7 +30,7 @@ func ToCompose(projectName string, c *arguments.Args, arguments map[string]comma
 	}
 
 	service := &types.ServiceConfig{
-		Name: "app",
+		Name: serviceName,
 	}
 
 	service.ExtraHosts = types.HostsList{}
@@ -259,6 +265,8 @@ func ToCompose(projectName string, c *arguments.Args, arguments map[string]comma
 		} else {
 			service.Entrypoint = args
 		}
+	} else if c.EntrypointCleared {
+		service.Entrypoint = types.ShellCommand{""}
 	}
 
 	service.Environment = types.NewMappingWithEquals(c.Env)
@@ -547,7 +555,9 @@ func ToCompose(projectName string, c *arguments.Args, arguments map[string]comma
 
 	service.ContainerName = c.ContainerName
 
-	if len(c.Network) > 0 {
+	if strings.HasPrefix(c.Network, "container:") {
+		service.NetworkMode = c.Network
+	} else if len(c.Network) > 0 {
 		project.Networks = map[string]types.NetworkConfig{
 			"default": {
 				Name:     c.Network,
@@ -761,21 +771,34 @@ func ToCompose(projectName string, c *arguments.Args, arguments map[string]comma
 			parts := strings.SplitN(value, ":", 3)
 			volume := types.ServiceVolumeConfig{}
 			if len(parts) == 1 {
-				volumeName := fmt.Sprintf("app-%d", i)
+				volumeName := fmt.Sprintf("%s-%d", serviceName, i)
 				volume.Source = volumeName
 				volume.Target = parts[0]
 				volume.Type = "volume"
 
-				project.Volumes = types.Volumes{}
+				if project.Volumes == nil {
+					project.Volumes = types.Volumes{}
+				}
 				project.Volumes[volumeName] = types.VolumeConfig{}
-			} else if len(parts) == 2 {
-				volume.Source = parts[0]
-				volume.Target = parts[1]
-				volume.Type = "bind"
-			} else if len(parts) == 3 {
+			} else {
 				volume.Source = parts[0]
 				volume.Target = parts[1]
 				volume.Type = "bind"
+				if isNamedVolume(parts[0]) {
+					// named volumes keep their docker name so that they can be
+					// shared with other containers
+					volume.Type = "volume"
+
+					if project.Volumes == nil {
+						project.Volumes = types.Volumes{}
+					}
+					project.Volumes[parts[0]] = types.VolumeConfig{
+						Name: parts[0],
+					}
+				}
+			}
+
+			if len(parts) == 3 {
 				if parts[2] == "ro" {
 					volume.ReadOnly = true
 				} else if parts[2] == "rw" {
@@ -807,7 +830,70 @@ func ToCompose(projectName string, c *arguments.Args, arguments map[string]comma
 	service.Image = arguments["image"].StringValue()
 
 	project.Services = types.Services{
-		"app": *service,
+		serviceName: *service,
+	}
+
+	return project, warnings, errs
+}
+
+// ToComposeServices converts several docker run invocations to a single
+// compose project with one service per container. Networks and named volumes
+// used by several containers are shared at the project level.
+func ToComposeServices(projectName string, containers []Container) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+	project := &types.Project{
+		Name:     projectName,
+		Services: types.Services{},
+	}
+
+	names, resolved, references, err := resolveContainers(containers)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+		return project, warnings, errs
+	}
+
+	for i, container := range resolved {
+		single, w, e := toComposeService(projectName, names[i], container.Args, container.Arguments)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+
+		service := single.Services[names[i]]
+
+		// --network is exported as the project default network for a single
+		// container; key it by name instead so that containers on different
+		// networks can be combined
+		if network, ok := single.Networks["default"]; ok {
+			if project.Networks == nil {
+				project.Networks = types.Networks{}
+			}
+			project.Networks[network.Name] = network
+
+			serviceNetwork := service.Networks["default"]
+			if serviceNetwork == nil {
+				serviceNetwork = &types.ServiceNetworkConfig{}
+			}
+			delete(service.Networks, "default")
+			if service.Networks == nil {
+				service.Networks = map[string]*types.ServiceNetworkConfig{}
+			}
+			service.Networks[network.Name] = serviceNetwork
+		}
+
+		for name, volume := range single.Volumes {
+			if project.Volumes == nil {
+				project.Volumes = types.Volumes{}
+			}
+			project.Volumes[name] = volume
+		}
+
+		service.Links = append(service.Links, references[i].Links...)
+		service.VolumesFrom = append(service.VolumesFrom, references[i].VolumesFrom...)
+		if len(references[i].Network) > 0 {
+			service.NetworkMode = fmt.Sprintf("service:%s", references[i].Network)
+		}
+
+		project.Services[names[i]] = service
 	}
 
 	return project, warnings, errs
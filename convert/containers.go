@@ -0,0 +1,169 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// Container holds the parsed flags and positional arguments of a single
+// docker run invocation within a multi-container export
+type Container struct {
+	Args      *arguments.Args
+	Arguments map[string]command.Argument
+}
+
+// containerReferences holds the --link, --volumes-from and
+// --network container:NAME references from one container of a
+// multi-container export to the others, keyed by the target's name
+type containerReferences struct {
+	// Links holds `name` or `name:alias` entries
+	Links []string
+	// VolumesFrom holds `name` or `name:mode` entries
+	VolumesFrom []string
+	// Network holds the name of the container whose network namespace is joined
+	Network string
+}
+
+// resolveContainers assigns each container a unique name and splits the
+// references between containers of the export from their arguments. The
+// returned containers hold copies of the arguments with those references
+// removed so that the single-container converters only see references to
+// containers outside of the export.
+func resolveContainers(containers []Container) ([]string, []Container, []containerReferences, error) {
+	names, err := containerNames(containers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+
+	resolved := make([]Container, len(containers))
+	references := make([]containerReferences, len(containers))
+	for i, container := range containers {
+		args := *container.Args
+
+		args.Link = []string{}
+		for _, value := range container.Args.Link {
+			name, alias := extractParts(strings.TrimPrefix(value, "/"), ":")
+			if !known[name] {
+				args.Link = append(args.Link, value)
+				continue
+			}
+			if name == names[i] {
+				return nil, nil, nil, fmt.Errorf("container %s cannot link to itself", name)
+			}
+
+			if len(alias) > 0 {
+				references[i].Links = append(references[i].Links, fmt.Sprintf("%s:%s", name, alias))
+			} else {
+				references[i].Links = append(references[i].Links, name)
+			}
+		}
+
+		args.VolumesFrom = []string{}
+		for _, value := range container.Args.VolumesFrom {
+			name, _ := extractParts(strings.TrimPrefix(value, "/"), ":")
+			if !known[name] {
+				args.VolumesFrom = append(args.VolumesFrom, value)
+				continue
+			}
+			if name == names[i] {
+				return nil, nil, nil, fmt.Errorf("container %s cannot mount volumes from itself", name)
+			}
+
+			references[i].VolumesFrom = append(references[i].VolumesFrom, strings.TrimPrefix(value, "/"))
+		}
+
+		if strings.HasPrefix(args.Network, "container:") {
+			name := strings.TrimPrefix(strings.TrimPrefix(args.Network, "container:"), "/")
+			if known[name] {
+				if name == names[i] {
+					return nil, nil, nil, fmt.Errorf("container %s cannot join its own network namespace", name)
+				}
+
+				references[i].Network = name
+				args.Network = ""
+			}
+		}
+
+		resolved[i] = Container{
+			Args:      &args,
+			Arguments: container.Arguments,
+		}
+	}
+
+	return names, resolved, references, nil
+}
+
+// containerNames returns a unique name for each container. The --name flag
+// is used when set, otherwise the name is derived from the image.
+func containerNames(containers []Container) ([]string, error) {
+	names := make([]string, len(containers))
+	seen := map[string]bool{}
+	for i, container := range containers {
+		name := strings.TrimPrefix(container.Args.ContainerName, "/")
+		if len(name) == 0 {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("container name %q is used by more than one container", name)
+		}
+
+		seen[name] = true
+		names[i] = name
+	}
+
+	for i, container := range containers {
+		if len(names[i]) > 0 {
+			continue
+		}
+
+		base := imageName(container.Arguments["image"].StringValue())
+		name := base
+		for n := 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		seen[name] = true
+		names[i] = name
+	}
+
+	return names, nil
+}
+
+// imageName returns the repository name of an image reference without its
+// registry, namespace, tag or digest, e.g. `redis` for `docker.io/library/redis:7`
+func imageName(image string) string {
+	name, _ := extractParts(image, "@")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name, _ = extractParts(name, ":")
+
+	if len(name) == 0 {
+		return "app"
+	}
+
+	return name
+}
+
+// appendContainerErrors appends the errors of a single container to the
+// errors of a multi-container export, prefixed with the container name
+func appendContainerErrors(result *multierror.Error, name string, errs *multierror.Error) *multierror.Error {
+	if errs == nil {
+		return result
+	}
+
+	for _, err := range errs.Errors {
+		result = multierror.Append(result, fmt.Errorf("%s: %w", name, err))
+	}
+
+	return result
+}
@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"docker-run-export/arguments"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// withDefaults returns a copy of args with the flag defaults of the run
+// command filled in
+func withDefaults(args arguments.Args) *arguments.Args {
+	args.HealthInterval = "0s"
+	args.HealthStartPeriod = "0s"
+	args.HealthTimeout = "0s"
+	args.Pull = "missing"
+	args.Restart = "no"
+	args.StopSignal = "SIGTERM"
+	args.DisableContentTrust = true
+	args.SigProxy = true
+	return &args
+}
+
+// TestContainerNames verifies that containers without --name are named after
+// their image and that duplicate names are made unique.
+func TestContainerNames(t *testing.T) {
+	containers := []Container{
+		{Args: &arguments.Args{}, Arguments: makeArgs("ghcr.io/acme/worker:1.2")},
+		{Args: &arguments.Args{ContainerName: "worker"}, Arguments: makeArgs("alpine:latest")},
+		{Args: &arguments.Args{}, Arguments: makeArgs("redis@sha256:abcdef")},
+		{Args: &arguments.Args{}, Arguments: makeArgs("redis:7")},
+	}
+
+	got, err := containerNames(containers)
+	if err != nil {
+		t.Fatalf("containerNames returned error: %v", err)
+	}
+
+	want := []string{"worker-2", "worker", "redis", "redis-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("containerNames() = %q, want %q", got, want)
+	}
+
+	containers[0].Args.ContainerName = "worker"
+	if _, err := containerNames(containers); err == nil {
+		t.Errorf("containerNames() with duplicate --name returned no error")
+	}
+}
+
+// TestToComposeServices verifies that references between containers are
+// resolved to services and that shared networks and volumes are declared
+// once at the project level.
+func TestToComposeServices(t *testing.T) {
+	containers := []Container{
+		{
+			Args: withDefaults(arguments.Args{
+				ContainerName: "redis",
+				Network:       "backend",
+				Volume:        []string{"redis-data:/data"},
+			}),
+			Arguments: makeArgs("redis:7"),
+		},
+		{
+			Args: withDefaults(arguments.Args{
+				ContainerName: "web",
+				Network:       "backend",
+				Link:          []string{"redis:cache", "legacy:db"},
+				Volume:        []string{"redis-data:/backup:ro"},
+			}),
+			Arguments: makeArgs("nginx:latest"),
+		},
+		{
+			Args: withDefaults(arguments.Args{
+				Network:     "container:web",
+				VolumesFrom: []string{"web:ro"},
+			}),
+			Arguments: makeArgs("alpine:latest", "sleep", "infinity"),
+		},
+	}
+
+	output, _, errs := ToComposeServices("stack", containers)
+	if errs != nil {
+		t.Fatalf("ToComposeServices returned errors: %v", errs)
+	}
+
+	project := output.(*types.Project)
+	if len(project.Services) != 3 {
+		t.Fatalf("expected 3 services, got %d", len(project.Services))
+	}
+
+	web := project.Services["web"]
+	if !reflect.DeepEqual(web.Links, []string{"legacy:db", "redis:cache"}) {
+		t.Errorf("web links = %q", web.Links)
+	}
+	if _, ok := web.Networks["backend"]; !ok {
+		t.Errorf("web is not attached to the backend network: %v", web.Networks)
+	}
+
+	alpine := project.Services["alpine"]
+	if alpine.NetworkMode != "service:web" {
+		t.Errorf("alpine network_mode = %q, want %q", alpine.NetworkMode, "service:web")
+	}
+	if !reflect.DeepEqual(alpine.VolumesFrom, []string{"web:ro"}) {
+		t.Errorf("alpine volumes_from = %q", alpine.VolumesFrom)
+	}
+
+	if len(project.Networks) != 1 || project.Networks["backend"].Name != "backend" {
+		t.Errorf("unexpected project networks: %v", project.Networks)
+	}
+	if _, ok := project.Volumes["redis-data"]; !ok || len(project.Volumes) != 1 {
+		t.Errorf("unexpected project volumes: %v", project.Volumes)
+	}
+
+	// the original arguments are left untouched
+	if !reflect.DeepEqual(containers[1].Args.Link, []string{"redis:cache", "legacy:db"}) {
+		t.Errorf("input links were modified: %q", containers[1].Args.Link)
+	}
+}
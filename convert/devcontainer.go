@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// devContainerIgnoredFlags holds the docker run flags that are accepted
+// without a warning, as the dev container tooling sets them itself
+var devContainerIgnoredFlags = map[string]bool{
+	"detach": true,
+	"name":   true,
+	"rm":     true,
+}
+
+// devContainerBindPropagations holds the --volume options that set the
+// propagation of a bind mount
+var devContainerBindPropagations = map[string]bool{
+	"private":  true,
+	"rprivate": true,
+	"shared":   true,
+	"rshared":  true,
+	"slave":    true,
+	"rslave":   true,
+}
+
+// DevContainer represents a devcontainer.json file
+type DevContainer struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	ForwardPorts  []int             `json:"forwardPorts,omitempty"`
+	AppPort       []string          `json:"appPort,omitempty"`
+	ContainerEnv  map[string]string `json:"containerEnv,omitempty"`
+	Mounts        []string          `json:"mounts,omitempty"`
+	CapAdd        []string          `json:"capAdd,omitempty"`
+	Privileged    bool              `json:"privileged,omitempty"`
+	Init          bool              `json:"init,omitempty"`
+	SecurityOpt   []string          `json:"securityOpt,omitempty"`
+	ContainerUser string            `json:"containerUser,omitempty"`
+	RunArgs       []string          `json:"runArgs,omitempty"`
+}
+
+// ToDevContainer converts docker run arguments to a devcontainer.json file.
+// Flags without a devcontainer.json property are passed to docker run in
+// runArgs, so that the container runs with the same settings.
+func ToDevContainer(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	devContainer := &DevContainer{
+		Name:          name,
+		Image:         arguments["image"].StringValue(),
+		CapAdd:        c.CapAdd,
+		Privileged:    c.Privileged,
+		Init:          c.Init,
+		SecurityOpt:   c.SecurityOpt,
+		ContainerUser: c.User,
+	}
+
+	// command: dev containers run a command that keeps the container alive
+	if len(arguments["command"].ListValue()) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set command in devcontainer.json as the property is not supported, use postStartCommand or build an image with the command instead"))
+	}
+
+	// entrypoint: dev containers replace it with a command that keeps the
+	// container alive, unless overrideCommand is false
+	if len(c.Entrypoint) > 0 || c.EntrypointCleared {
+		warnings = multierror.Append(warnings, fmt.Errorf("passing --entrypoint to runArgs in devcontainer.json, set overrideCommand to false for it to take effect"))
+	}
+
+	// env -> containerEnv, reading pass-through variables from the host
+	for _, value := range c.Env {
+		if devContainer.ContainerEnv == nil {
+			devContainer.ContainerEnv = map[string]string{}
+		}
+		if !strings.Contains(value, "=") {
+			devContainer.ContainerEnv[value] = fmt.Sprintf("${localEnv:%s}", value)
+			continue
+		}
+		key, val := extractParts(value, "=")
+		devContainer.ContainerEnv[key] = val
+	}
+
+	// publish -> forwardPorts for ports published on the same port, appPort
+	// for the others
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+
+		if len(parsed) == 1 {
+			p := parsed[0]
+			if len(p.HostIP) == 0 && p.Protocol == "tcp" && (len(p.Published) == 0 || p.Published == strconv.Itoa(int(p.Target))) {
+				devContainer.ForwardPorts = append(devContainer.ForwardPorts, int(p.Target))
+				continue
+			}
+		}
+		devContainer.AppPort = append(devContainer.AppPort, value)
+	}
+
+	// mount -> mounts
+	devContainer.Mounts = append(devContainer.Mounts, c.Mount...)
+
+	// volume -> mounts, or runArgs when the options have no mount equivalent
+	for _, value := range c.Volume {
+		mount, ok := devContainerMount(value)
+		if !ok {
+			devContainer.RunArgs = append(devContainer.RunArgs, "--volume", value)
+			continue
+		}
+		devContainer.Mounts = append(devContainer.Mounts, mount)
+	}
+
+	// every other flag -> runArgs
+	for _, flag := range dockerRunFlags(c) {
+		switch flag.Name {
+		case "cap-add", "env", "init", "mount", "privileged", "publish", "security-opt", "user", "volume":
+			continue
+		}
+		if devContainerIgnoredFlags[flag.Name] {
+			continue
+		}
+		devContainer.RunArgs = append(devContainer.RunArgs, flag.Args()...)
+	}
+
+	return devContainer, warnings, errs
+}
+
+// MarshalDevContainer marshals a devcontainer.json file
+func MarshalDevContainer(devContainer *DevContainer) ([]byte, error) {
+	return json.MarshalIndent(devContainer, "", "  ")
+}
+
+// devContainerMount converts a --volume value to the --mount string syntax
+// used by the mounts property. It returns false when the volume options have
+// no --mount equivalent, e.g., the z and Z SELinux labels, or when a path
+// holds a comma.
+func devContainerMount(value string) (string, bool) {
+	parts := strings.SplitN(value, ":", 3)
+	if strings.Contains(parts[0], ",") || (len(parts) > 1 && strings.Contains(parts[1], ",")) {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return fmt.Sprintf("target=%s,type=volume", parts[0]), true
+	}
+
+	mountType := "bind"
+	if isNamedVolume(parts[0]) {
+		mountType = "volume"
+	}
+	mount := []string{
+		"source=" + parts[0],
+		"target=" + parts[1],
+		"type=" + mountType,
+	}
+
+	if len(parts) == 3 {
+		for _, option := range strings.Split(parts[2], ",") {
+			switch {
+			case option == "rw":
+			case option == "ro":
+				mount = append(mount, "readonly")
+			case option == "nocopy" && mountType == "volume":
+				mount = append(mount, "volume-nocopy")
+			case devContainerBindPropagations[option] && mountType == "bind":
+				mount = append(mount, "bind-propagation="+option)
+			default:
+				return "", false
+			}
+		}
+	}
+
+	return strings.Join(mount, ","), true
+}
@@ -0,0 +1,240 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// dockerRunFlag represents a single flag of a docker run command
+type dockerRunFlag struct {
+	// Name holds the long name of the flag without leading dashes
+	Name string
+	// Value holds the value of the flag, or the explicit value of a boolean flag
+	Value string
+	// Bool is set for boolean flags, which are rendered as `--name` or `--name=value`
+	Bool bool
+}
+
+// Args returns the command line arguments of the flag
+func (f dockerRunFlag) Args() []string {
+	if f.Bool {
+		if len(f.Value) == 0 {
+			return []string{"--" + f.Name}
+		}
+		return []string{fmt.Sprintf("--%s=%s", f.Name, f.Value)}
+	}
+
+	return []string{"--" + f.Name, f.Value}
+}
+
+// DockerRunCommands holds the arguments that follow `docker run`, one
+// command per container
+type DockerRunCommands struct {
+	Commands [][]string
+}
+
+// ToDockerRun converts docker run arguments to a canonical docker run command,
+// with long flag names sorted by name and default values removed
+func ToDockerRun(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	commands := &DockerRunCommands{
+		Commands: [][]string{dockerRunArgs(dockerRunFlags(c), arguments)},
+	}
+
+	return commands, nil, nil
+}
+
+// ToDockerRunContainers converts several docker run invocations to one
+// canonical docker run command per container
+func ToDockerRunContainers(projectName string, containers []Container) (interface{}, *multierror.Error, *multierror.Error) {
+	commands := &DockerRunCommands{}
+	for _, container := range containers {
+		commands.Commands = append(commands.Commands, dockerRunArgs(dockerRunFlags(container.Args), container.Arguments))
+	}
+
+	return commands, nil, nil
+}
+
+// MarshalDockerRun marshals docker run commands to shell command lines, one
+// line per container
+func MarshalDockerRun(commands *DockerRunCommands) ([]byte, error) {
+	var b strings.Builder
+	for _, args := range commands.Commands {
+		b.WriteString("docker run ")
+		b.WriteString(shellJoin(args))
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// dockerRunFlags returns the docker run flags that differ from their
+// defaults, using long flag names and sorted by name. Repeated flags keep the
+// order in which they were specified.
+func dockerRunFlags(c *arguments.Args) []dockerRunFlag {
+	flags := []dockerRunFlag{}
+	addString := func(name string, value string, defaultValue string) {
+		if value != defaultValue {
+			flags = append(flags, dockerRunFlag{Name: name, Value: value})
+		}
+	}
+	addList := func(name string, values []string) {
+		for _, value := range values {
+			flags = append(flags, dockerRunFlag{Name: name, Value: value})
+		}
+	}
+	addInt := func(name string, value int64) {
+		if value != 0 {
+			flags = append(flags, dockerRunFlag{Name: name, Value: strconv.FormatInt(value, 10)})
+		}
+	}
+	addBool := func(name string, value bool, defaultValue bool) {
+		if value == defaultValue {
+			return
+		}
+		if value {
+			flags = append(flags, dockerRunFlag{Name: name, Bool: true})
+		} else {
+			flags = append(flags, dockerRunFlag{Name: name, Value: "false", Bool: true})
+		}
+	}
+
+	addList("add-host", c.AddHost)
+	addList("annotation", c.Annotation)
+	addList("attach", c.Attach)
+	addInt("blkio-weight", int64(c.BlkioWeight))
+	addList("blkio-weight-device", c.BlkioWeightDevice)
+	addList("cap-add", c.CapAdd)
+	addList("cap-drop", c.CapDrop)
+	addString("cgroup-parent", c.CgroupParent, "")
+	addString("cgroupns", c.Cgroupns, "")
+	addString("cidfile", c.Cidfile, "")
+	addInt("cpu-period", int64(c.CpuPeriod))
+	addInt("cpu-quota", int64(c.CpuQuota))
+	addInt("cpu-rt-period", int64(c.CpuRtPeriod))
+	addInt("cpu-rt-runtime", int64(c.CpuRtRuntime))
+	addInt("cpu-shares", int64(c.CpuShares))
+	if c.Cpus != 0 {
+		flags = append(flags, dockerRunFlag{Name: "cpus", Value: strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32)})
+	}
+	addString("cpuset-cpus", c.CpusetCpus, "")
+	addString("cpuset-mems", c.CpusetMems, "")
+	addBool("detach", c.Detach, false)
+	addString("detach-keys", c.DetachKeys, "")
+	addList("device", c.Device)
+	addList("device-cgroup-rule", c.DeviceCgroupRule)
+	addList("device-read-bps", c.DeviceReadBps)
+	addList("device-read-iops", c.DeviceReadIops)
+	addList("device-write-bps", c.DeviceWriteBps)
+	addList("device-write-iops", c.DeviceWriteIops)
+	addBool("disable-content-trust", c.DisableContentTrust, true)
+	addList("dns", c.Dns)
+	addList("dns-option", c.DnsOption)
+	addList("dns-search", c.DnsSearch)
+	addString("domainname", c.Domainname, "")
+	addString("entrypoint", c.Entrypoint, "")
+	if c.EntrypointCleared && len(c.Entrypoint) == 0 {
+		flags = append(flags, dockerRunFlag{Name: "entrypoint"})
+	}
+	addList("env", c.Env)
+	addList("env-file", c.EnvFile)
+	addList("expose", c.Expose)
+	addString("gpus", c.Gpus, "")
+	addList("group-add", c.GroupAdd)
+	addString("health-cmd", c.HealthCmd, "")
+	addString("health-interval", c.HealthInterval, "0s")
+	addInt("health-retries", int64(c.HealthRetries))
+	addString("health-start-period", c.HealthStartPeriod, "0s")
+	addString("health-timeout", c.HealthTimeout, "0s")
+	addString("hostname", c.Hostname, "")
+	addBool("init", c.Init, false)
+	addBool("interactive", c.Interactive, false)
+	addString("ip", c.Ip, "")
+	addString("ip6", c.Ip6, "")
+	addString("ipc", c.Ipc, "")
+	addString("isolation", c.Isolation, "")
+	addInt("kernel-memory", int64(c.KernelMemory))
+	addList("label", c.Label)
+	addList("label-file", c.LabelFile)
+	addList("link", c.Link)
+	addList("link-local-ip", c.LinkLocalIP)
+	addString("log-driver", c.LogDriver, "")
+	addList("log-opt", c.LogOpt)
+	addString("mac-address", c.Mac, "")
+	addInt("memory", c.Memory)
+	addInt("memory-reservation", c.MemoryReservation)
+	addInt("memory-swap", c.MemorySwap)
+	addInt("memory-swappiness", c.MemorySwappiness)
+	addList("mount", c.Mount)
+	addString("name", c.ContainerName, "")
+	addString("network", c.Network, "")
+	addList("network-alias", c.NetworkAlias)
+	addBool("no-healthcheck", c.NoHealthcheck, false)
+	addBool("oom-kill-disable", c.OomKillDisable, false)
+	addInt("oom-score-adj", int64(c.OomScore))
+	addString("pid", c.Pid, "")
+	addInt("pids-limit", int64(c.PidsLimit))
+	addString("platform", c.Platform, "")
+	addBool("privileged", c.Privileged, false)
+	addList("publish", c.Publish)
+	addBool("publish-all", c.PublishAll, false)
+	addString("pull", c.Pull, "missing")
+	addBool("read-only", c.ReadOnly, false)
+	addString("restart", c.Restart, "no")
+	addBool("rm", c.Rm, false)
+	addString("runtime", c.Runtime, "")
+	addList("security-opt", c.SecurityOpt)
+	addInt("shm-size", int64(c.ShmSize))
+	addBool("sig-proxy", c.SigProxy, true)
+	addString("stop-signal", c.StopSignal, "SIGTERM")
+	addInt("stop-timeout", int64(c.StopTimeout))
+	addList("storage-opt", c.StorageOpt)
+	for _, key := range sortedKeys(c.Sysctl) {
+		flags = append(flags, dockerRunFlag{Name: "sysctl", Value: fmt.Sprintf("%s=%s", key, c.Sysctl[key])})
+	}
+	addList("tmpfs", c.Tmpfs)
+	addBool("tty", c.Tty, false)
+	addList("ulimit", c.Ulimit)
+	addString("user", c.User, "")
+	addString("userns", c.Userns, "")
+	addString("uts", c.Uts, "")
+	addList("volume", c.Volume)
+	addString("volume-driver", c.VolumeDriver, "")
+	addList("volumes-from", c.VolumesFrom)
+	addString("workdir", c.Workdir, "")
+
+	sortDockerRunFlags(flags)
+	return flags
+}
+
+// sortDockerRunFlags sorts flags by name, keeping the order of repeated flags
+func sortDockerRunFlags(flags []dockerRunFlag) {
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+}
+
+// dockerRunArgs returns the arguments that follow `docker run` for the given
+// flags and the image and command positional arguments
+func dockerRunArgs(flags []dockerRunFlag, arguments map[string]command.Argument) []string {
+	args := []string{}
+	for _, flag := range flags {
+		args = append(args, flag.Args()...)
+	}
+
+	args = append(args, arguments["image"].StringValue())
+	args = append(args, arguments["command"].ListValue()...)
+	return args
+}
+
+// shellJoin quotes each word for a POSIX shell and joins them with spaces.
+// Converters name their positional arguments `arguments`, which shadows the
+// package that holds the quoting rules.
+func shellJoin(words []string) string {
+	return arguments.ShellJoin(words)
+}
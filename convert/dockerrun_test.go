@@ -0,0 +1,45 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"docker-run-export/arguments"
+)
+
+// TestToDockerRun verifies that the canonical command uses sorted long flag
+// names without defaults, and that its quoting survives tokenization.
+func TestToDockerRun(t *testing.T) {
+	args := withDefaults(arguments.Args{
+		ContainerName: "web",
+		Cpus:          1.5,
+		Detach:        true,
+		Env:           []string{"MSG=hello world", "HOME_DIR=$HOME", "QUOTE=it's"},
+		Publish:       []string{"8080:80"},
+		Sysctl:        map[string]string{"net.core.somaxconn": "1024", "kernel.msgmax": "65536"},
+	})
+	args.SigProxy = false
+
+	output, _, errs := ToDockerRun("", args, makeArgs("nginx:latest", "sh", "-c", "echo hi"))
+	if errs != nil {
+		t.Fatalf("ToDockerRun returned errors: %v", errs)
+	}
+
+	out, err := MarshalDockerRun(output.(*DockerRunCommands))
+	if err != nil {
+		t.Fatalf("MarshalDockerRun returned error: %v", err)
+	}
+
+	want := `docker run --cpus 1.5 --detach --env 'MSG=hello world' --env 'HOME_DIR=$HOME' --env 'QUOTE=it'"'"'s' --name web --publish 8080:80 --sig-proxy=false --sysctl kernel.msgmax=65536 --sysctl net.core.somaxconn=1024 nginx:latest sh -c 'echo hi'` + "\n"
+	if string(out) != want {
+		t.Errorf("MarshalDockerRun() = %q, want %q", string(out), want)
+	}
+
+	words, err := arguments.ParseCommandLine(string(out))
+	if err != nil {
+		t.Fatalf("ParseCommandLine returned error: %v", err)
+	}
+	if !reflect.DeepEqual(words, output.(*DockerRunCommands).Commands[0]) {
+		t.Errorf("ParseCommandLine() = %q, want %q", words, output.(*DockerRunCommands).Commands[0])
+	}
+}
@@ -0,0 +1,311 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// dokkuStorageDirectory holds the directory in which Dokku creates persistent
+// storage directories with storage:ensure-directory
+const dokkuStorageDirectory = "/var/lib/dokku/data/storage"
+
+// DokkuApp holds the dokku commands that reproduce a docker run invocation on
+// a Dokku app
+type DokkuApp struct {
+	// Name holds the name of the Dokku app
+	Name string
+	// Commands holds the arguments of each dokku command
+	Commands [][]string
+	// AppJSON holds the app.json settings that have no dokku command
+	AppJSON *DokkuAppJSON
+}
+
+// DokkuAppJSON represents the app.json file read by Dokku during a deploy
+type DokkuAppJSON struct {
+	Healthchecks map[string][]DokkuHealthcheck `json:"healthchecks,omitempty"`
+}
+
+// DokkuHealthcheck represents an app.json healthcheck
+type DokkuHealthcheck struct {
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	Command      []string `json:"command,omitempty"`
+	Attempts     int      `json:"attempts,omitempty"`
+	Timeout      int      `json:"timeout,omitempty"`
+	Wait         int      `json:"wait,omitempty"`
+	InitialDelay int      `json:"initialDelay,omitempty"`
+}
+
+// ToDokku converts docker run arguments to the dokku commands that create
+// and configure an app running the same container
+func ToDokku(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	image := arguments["image"].StringValue()
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(image)
+	}
+	// dokku app names follow the same rules as kubernetes object names
+	name = kubernetesName(name)
+
+	app := &DokkuApp{Name: name}
+	app.Commands = append(app.Commands, []string{"apps:create", name})
+
+	// env -> config:set
+	if len(c.Env) > 0 {
+		command := []string{"config:set", "--no-restart", name}
+		for _, value := range c.Env {
+			if !strings.Contains(value, "=") {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in dokku as passing through host environment variables is not supported", value))
+				continue
+			}
+			command = append(command, value)
+		}
+		if len(command) > 3 {
+			app.Commands = append(app.Commands, command)
+		}
+	}
+
+	// publish -> ports:set
+	if len(c.Publish) > 0 {
+		command := []string{"ports:set", name}
+		for _, value := range c.Publish {
+			parsed, err := types.ParsePortConfig(value)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+				continue
+			}
+			for _, p := range parsed {
+				published := p.Published
+				if len(published) == 0 {
+					published = strconv.Itoa(int(p.Target))
+				}
+				if len(p.HostIP) > 0 {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set host ip %s of --publish %s in dokku as the property is not supported", p.HostIP, value))
+				}
+
+				scheme := "http"
+				if p.Protocol == "udp" {
+					scheme = "udp"
+				}
+				command = append(command, fmt.Sprintf("%s:%s:%d", scheme, published, p.Target))
+			}
+		}
+		if len(command) > 2 {
+			app.Commands = append(app.Commands, command)
+		}
+	}
+
+	// volume -> storage:ensure-directory / storage:mount
+	for _, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) == 1 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in dokku as anonymous volumes are not supported", value))
+			continue
+		}
+
+		source := parts[0]
+		if isNamedVolume(source) {
+			app.Commands = append(app.Commands, []string{"storage:ensure-directory", source})
+			source = fmt.Sprintf("%s/%s", dokkuStorageDirectory, source)
+		}
+
+		mount := fmt.Sprintf("%s:%s", source, parts[1])
+		if len(parts) == 3 {
+			mount = fmt.Sprintf("%s:%s", mount, parts[2])
+		}
+		app.Commands = append(app.Commands, []string{"storage:mount", name, mount})
+	}
+
+	// mount -> storage:ensure-directory / storage:mount, or docker-options:add
+	// for mounts that are not backed by a host directory
+	var dockerOptions [][]string
+	for _, value := range c.Mount {
+		data := map[string]string{}
+		for _, part := range strings.Split(value, ",") {
+			k, v := extractParts(part, "=")
+			data[k] = v
+		}
+
+		mountType := data["type"]
+		if len(mountType) == 0 {
+			mountType = "volume"
+		}
+		var source, target string
+		for _, key := range []string{"src", "source"} {
+			if v, ok := data[key]; ok {
+				source = v
+			}
+		}
+		for _, key := range []string{"dst", "destination", "target"} {
+			if v, ok := data[key]; ok {
+				target = v
+			}
+		}
+
+		if (mountType != "bind" && mountType != "volume") || len(source) == 0 || len(target) == 0 {
+			dockerOptions = append(dockerOptions, []string{"--mount", value})
+			continue
+		}
+
+		if mountType == "volume" {
+			app.Commands = append(app.Commands, []string{"storage:ensure-directory", source})
+			source = fmt.Sprintf("%s/%s", dokkuStorageDirectory, source)
+		}
+
+		mount := fmt.Sprintf("%s:%s", source, target)
+		for _, key := range []string{"readonly", "ro"} {
+			if v, ok := data[key]; ok && (v == "" || v == "true" || v == "1") {
+				mount = fmt.Sprintf("%s:ro", mount)
+			}
+		}
+		app.Commands = append(app.Commands, []string{"storage:mount", name, mount})
+	}
+
+	// memory / cpus -> resource:limit
+	if c.Memory > 0 || c.Cpus > 0 {
+		command := []string{"resource:limit"}
+		if c.Cpus > 0 {
+			command = append(command, "--cpu", strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32))
+		}
+		if c.Memory > 0 {
+			command = append(command, "--memory", fmt.Sprintf("%dm", bytesToMiB(c.Memory)))
+		}
+		app.Commands = append(app.Commands, append(command, name))
+	}
+
+	// memory-reservation -> resource:reserve
+	if c.MemoryReservation > 0 {
+		app.Commands = append(app.Commands, []string{"resource:reserve", "--memory", fmt.Sprintf("%dm", bytesToMiB(c.MemoryReservation)), name})
+	}
+
+	// network -> network:set initial-network
+	if len(c.Network) > 0 {
+		app.Commands = append(app.Commands, []string{"network:set", name, "initial-network", c.Network})
+	}
+
+	// restart -> ps:set restart-policy
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		if _, _, err := parseDockerRestart(c.Restart); err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			app.Commands = append(app.Commands, []string{"ps:set", name, "restart-policy", c.Restart})
+		}
+	}
+
+	// health-cmd / health-* -> app.json healthchecks
+	if len(c.HealthCmd) > 0 && !c.NoHealthcheck {
+		healthcheck := DokkuHealthcheck{
+			Type:    "startup",
+			Name:    "docker healthcheck",
+			Command: []string{"/bin/sh", "-c", c.HealthCmd},
+		}
+
+		if c.HealthInterval != "0s" {
+			seconds, err := durationToSeconds(c.HealthInterval)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-interval flag to duration: %w", err))
+			} else {
+				healthcheck.Wait = seconds
+			}
+		}
+
+		if c.HealthTimeout != "0s" {
+			seconds, err := durationToSeconds(c.HealthTimeout)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-timeout flag to duration: %w", err))
+			} else {
+				healthcheck.Timeout = seconds
+			}
+		}
+
+		if c.HealthStartPeriod != "0s" {
+			seconds, err := durationToSeconds(c.HealthStartPeriod)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-start-period flag to duration: %w", err))
+			} else {
+				healthcheck.InitialDelay = seconds
+			}
+		}
+
+		if c.HealthRetries > 0 {
+			healthcheck.Attempts = int(c.HealthRetries)
+		}
+
+		app.AppJSON = &DokkuAppJSON{
+			Healthchecks: map[string][]DokkuHealthcheck{
+				"web": {healthcheck},
+			},
+		}
+	} else if len(c.HealthCmd) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+	}
+
+	// unsupported: command
+	if len(arguments["command"].ListValue()) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set command in dokku as the command of the image or a Procfile is used"))
+	}
+
+	// everything else -> docker-options:add for the deploy and run phases
+	for _, flag := range dockerRunFlags(c) {
+		switch flag.Name {
+		case "cpus", "env", "memory", "memory-reservation", "mount", "network", "publish", "restart", "volume":
+			// handled by the dokku commands above
+			continue
+		case "health-cmd", "health-interval", "health-retries", "health-start-period", "health-timeout":
+			// handled by app.json healthchecks
+			continue
+		case "detach", "name", "rm":
+			// dokku names, detaches and removes containers itself
+			continue
+		case "pull":
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pull property in dokku as images are pulled by git:from-image"))
+			continue
+		}
+		dockerOptions = append(dockerOptions, flag.Args())
+	}
+	for _, option := range dockerOptions {
+		app.Commands = append(app.Commands, []string{"docker-options:add", name, "deploy,run", shellJoin(option)})
+	}
+
+	app.Commands = append(app.Commands, []string{"git:from-image", name, image})
+
+	return app, warnings, errs
+}
+
+// MarshalDokku marshals a Dokku app to a shell script of dokku commands
+func MarshalDokku(app *DokkuApp) ([]byte, error) {
+	var b strings.Builder
+	for _, command := range app.Commands {
+		b.WriteString("dokku ")
+		b.WriteString(shellJoin(command))
+		b.WriteString("\n")
+	}
+
+	if app.AppJSON != nil {
+		out, err := json.MarshalIndent(app.AppJSON, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteString("\n")
+		b.WriteString("# app.json must be placed in the working directory of the image for dokku to run the healthchecks\n")
+		b.WriteString("cat > app.json <<'EOF'\n")
+		b.Write(out)
+		b.WriteString("\nEOF\n")
+	}
+
+	return []byte(b.String()), nil
+}
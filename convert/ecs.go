@@ -188,7 +188,8 @@ type ECSRuntimePlatform struct {
 	OperatingSystemFamily  string `json:"operatingSystemFamily"  yaml:"OperatingSystemFamily"`
 }
 
-// CloudFormationTemplate represents a CloudFormation template wrapping an ECS task definition
+// CloudFormationTemplate represents a CloudFormation template wrapping an ECS
+// task definition or an App Runner service
 type CloudFormationTemplate struct {
 	AWSTemplateFormatVersion string                              `yaml:"AWSTemplateFormatVersion"`
 	Resources                map[string]CloudFormationResource   `yaml:"Resources"`
@@ -196,8 +197,8 @@ type CloudFormationTemplate struct {
 
 // CloudFormationResource represents a CloudFormation resource
 type CloudFormationResource struct {
-	Type       string             `yaml:"Type"`
-	Properties *ECSTaskDefinition `yaml:"Properties"`
+	Type       string      `yaml:"Type"`
+	Properties interface{} `yaml:"Properties"`
 }
 
 // ToECS converts docker run arguments to an ECS task definition
@@ -413,6 +414,8 @@ func ToECS(projectName string, c *arguments.Args, arguments map[string]command.A
 		} else {
 			container.EntryPoint = args
 		}
+	} else if c.EntrypointCleared {
+		container.EntryPoint = []string{""}
 	}
 
 	// env
@@ -684,11 +687,14 @@ func ToECS(projectName string, c *arguments.Args, arguments map[string]command.A
 
 			if mountType == "bind" || mountType == "volume" {
 				volumeName := fmt.Sprintf("mount-%d", i)
+				if mountType == "volume" && len(source) > 0 {
+					volumeName = source
+				}
 				vol := ECSVolume{Name: volumeName}
 				if mountType == "bind" && len(source) > 0 {
 					vol.Host = &ECSHostVolumeProperties{SourcePath: source}
 				}
-				taskVolumes = append(taskVolumes, vol)
+				taskVolumes = appendECSVolume(taskVolumes, vol)
 
 				container.MountPoints = append(container.MountPoints, ECSMountPoint{
 					SourceVolume:  volumeName,
@@ -974,12 +980,18 @@ func ToECS(projectName string, c *arguments.Args, arguments map[string]command.A
 					}
 				}
 
+				// named volumes keep their docker name so that they can be
+				// shared with other containers in the task
+				if isNamedVolume(parts[0]) {
+					volumeName = parts[0]
+				}
+
 				vol := ECSVolume{Name: volumeName}
 				// if source looks like an absolute path, it's a bind mount
 				if strings.HasPrefix(parts[0], "/") {
 					vol.Host = &ECSHostVolumeProperties{SourcePath: parts[0]}
 				}
-				taskVolumes = append(taskVolumes, vol)
+				taskVolumes = appendECSVolume(taskVolumes, vol)
 
 				container.MountPoints = append(container.MountPoints, ECSMountPoint{
 					SourceVolume:  volumeName,
@@ -1036,6 +1048,162 @@ func ToECS(projectName string, c *arguments.Args, arguments map[string]command.A
 	return taskDef, warnings, errs
 }
 
+// ToECSContainers converts several docker run invocations to a single ECS
+// task definition with one container definition per container
+func ToECSContainers(projectName string, containers []Container, ecsOpts ECSOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, resolved, references, err := resolveContainers(containers)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+		return &ECSTaskDefinition{Family: projectName}, warnings, errs
+	}
+
+	family := projectName
+	if len(family) == 0 {
+		family = names[0]
+	}
+
+	taskDef := &ECSTaskDefinition{
+		Family:           family,
+		TaskRoleArn:      ecsOpts.TaskRoleArn,
+		ExecutionRoleArn: ecsOpts.ExecutionRoleArn,
+	}
+	if len(ecsOpts.RequiresCompatibilities) > 0 {
+		taskDef.RequiresCompatibilities = ecsOpts.RequiresCompatibilities
+	}
+
+	cpu := 0
+	memory := 0
+	for i, container := range resolved {
+		container.Args.ContainerName = names[i]
+		output, w, e := ToECS(family, container.Args, container.Arguments, ecsOpts)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+
+		single := output.(*ECSTaskDefinition)
+		definition := single.ContainerDefinitions[0]
+
+		// volumes other than named docker volumes belong to a single container
+		named := ecsNamedVolumes(container.Args)
+		for _, volume := range single.Volumes {
+			if volume.Host == nil && named[volume.Name] {
+				taskDef.Volumes = appendECSVolume(taskDef.Volumes, volume)
+				continue
+			}
+
+			volumeName := fmt.Sprintf("%s-%s", names[i], volume.Name)
+			for j := range definition.MountPoints {
+				if definition.MountPoints[j].SourceVolume == volume.Name {
+					definition.MountPoints[j].SourceVolume = volumeName
+				}
+			}
+			volume.Name = volumeName
+			taskDef.Volumes = appendECSVolume(taskDef.Volumes, volume)
+		}
+
+		// cpu and memory are summed across containers at the task level
+		if len(single.CPU) > 0 {
+			value, _ := strconv.Atoi(single.CPU)
+			cpu += value
+		}
+		if len(single.Memory) > 0 {
+			value, _ := strconv.Atoi(single.Memory)
+			memory += value
+		}
+
+		for _, field := range []struct {
+			flag  string
+			name  string
+			value string
+			task  *string
+		}{
+			{"--network", "networkMode", single.NetworkMode, &taskDef.NetworkMode},
+			{"--pid", "pidMode", single.PidMode, &taskDef.PidMode},
+			{"--ipc", "ipcMode", single.IpcMode, &taskDef.IpcMode},
+		} {
+			if len(field.value) == 0 {
+				continue
+			}
+			if len(*field.task) > 0 && *field.task != field.value {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring %s as %s is already set to %q in ecs task definition", names[i], field.flag, field.name, *field.task))
+				continue
+			}
+			*field.task = field.value
+		}
+
+		if single.RuntimePlatform != nil {
+			if taskDef.RuntimePlatform != nil && *taskDef.RuntimePlatform != *single.RuntimePlatform {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring --platform as runtimePlatform is already set in ecs task definition", names[i]))
+			} else {
+				taskDef.RuntimePlatform = single.RuntimePlatform
+			}
+		}
+
+		definition.Links = append(definition.Links, references[i].Links...)
+		for _, value := range references[i].VolumesFrom {
+			name, mode := extractParts(value, ":")
+			definition.VolumesFrom = append(definition.VolumesFrom, ECSVolumeFrom{
+				SourceContainer: name,
+				ReadOnly:        mode == "ro",
+			})
+		}
+
+		taskDef.ContainerDefinitions = append(taskDef.ContainerDefinitions, definition)
+	}
+
+	// containers of a task only share a network namespace in awsvpc mode
+	for i := range resolved {
+		if len(references[i].Network) == 0 {
+			continue
+		}
+		if taskDef.NetworkMode != "awsvpc" && taskDef.NetworkMode != "host" {
+			warnings = multierror.Append(warnings, fmt.Errorf("%s: mapping --network \"container:%s\" to networkMode \"awsvpc\" in ecs task definition", names[i], references[i].Network))
+			taskDef.NetworkMode = "awsvpc"
+		}
+	}
+
+	if cpu > 0 {
+		taskDef.CPU = strconv.Itoa(cpu)
+	}
+	if memory > 0 {
+		taskDef.Memory = strconv.Itoa(memory)
+	}
+
+	return taskDef, warnings, errs
+}
+
+// ecsNamedVolumes returns the names of the docker volumes mounted with the
+// --volume and --mount flags
+func ecsNamedVolumes(c *arguments.Args) map[string]bool {
+	named := map[string]bool{}
+	for _, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) >= 2 && isNamedVolume(parts[0]) {
+			named[parts[0]] = true
+		}
+	}
+
+	for _, value := range c.Mount {
+		data := map[string]string{}
+		for _, part := range strings.Split(value, ",") {
+			k, v := extractParts(part, "=")
+			data[k] = v
+		}
+		if data["type"] != "volume" {
+			continue
+		}
+		for _, key := range []string{"src", "source"} {
+			if v, ok := data[key]; ok && len(v) > 0 {
+				named[v] = true
+			}
+		}
+	}
+
+	return named
+}
+
 // MarshalECS marshals an ECS task definition to JSON
 func MarshalECS(taskDef *ECSTaskDefinition) ([]byte, error) {
 	return json.MarshalIndent(taskDef, "", "  ")
@@ -1055,6 +1223,18 @@ func MarshalECSCloudFormation(taskDef *ECSTaskDefinition) ([]byte, error) {
 	return yaml.Marshal(template)
 }
 
+// appendECSVolume appends a task-level volume unless a volume with the same
+// name is already defined
+func appendECSVolume(volumes []ECSVolume, volume ECSVolume) []ECSVolume {
+	for _, existing := range volumes {
+		if existing.Name == volume.Name {
+			return volumes
+		}
+	}
+
+	return append(volumes, volume)
+}
+
 // durationToSeconds parses a Go duration string and returns the value in seconds
 func durationToSeconds(value string) (int, error) {
 	d, err := time.ParseDuration(value)
@@ -0,0 +1,228 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MarshalECSTerraform marshals an ECS task definition as the HCL of a Terraform
+// aws_ecs_task_definition resource. The container definitions are written as
+// a jsonencode call, while the task-level settings are native attributes and
+// blocks. The role ARNs and container images are exposed as variables.
+func MarshalECSTerraform(taskDef *ECSTaskDefinition) ([]byte, error) {
+	config := &TerraformConfig{
+		Providers: map[string]TerraformProvider{
+			"aws": {
+				Source:  "hashicorp/aws",
+				Version: "~> 5.0",
+			},
+		},
+	}
+
+	images := map[string]TerraformReference{}
+	for _, container := range taskDef.ContainerDefinitions {
+		name := "image"
+		description := "Image of the container"
+		if len(taskDef.ContainerDefinitions) > 1 {
+			name = fmt.Sprintf("%s_image", terraformName(container.Name))
+			description = fmt.Sprintf("Image of the %s container", container.Name)
+		}
+
+		image := container.Image
+		config.Variables = append(config.Variables, TerraformVariable{
+			Name:        name,
+			Type:        "string",
+			Description: description,
+			Default:     &image,
+		})
+		images[container.Name] = TerraformReference{"var", name}
+	}
+
+	config.Variables = append(config.Variables, TerraformVariable{
+		Name:        "task_role_arn",
+		Type:        "string",
+		Description: "ARN of the IAM role assumed by the containers of the task",
+		Default:     ecsTerraformDefault(taskDef.TaskRoleArn),
+	}, TerraformVariable{
+		Name:        "execution_role_arn",
+		Type:        "string",
+		Description: "ARN of the IAM role used by the ECS agent to pull images and write logs",
+		Default:     ecsTerraformDefault(taskDef.ExecutionRoleArn),
+	})
+
+	containerDefinitions, err := ecsContainerDefinitionsTokens(taskDef.ContainerDefinitions, images)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := map[string]interface{}{
+		"family":                taskDef.Family,
+		"container_definitions": TerraformExpression(containerDefinitions),
+		"task_role_arn":         TerraformReference{"var", "task_role_arn"},
+		"execution_role_arn":    TerraformReference{"var", "execution_role_arn"},
+	}
+	if len(taskDef.CPU) > 0 {
+		resource["cpu"] = taskDef.CPU
+	}
+	if len(taskDef.Memory) > 0 {
+		resource["memory"] = taskDef.Memory
+	}
+	if len(taskDef.NetworkMode) > 0 {
+		resource["network_mode"] = taskDef.NetworkMode
+	}
+	if len(taskDef.PidMode) > 0 {
+		resource["pid_mode"] = taskDef.PidMode
+	}
+	if len(taskDef.IpcMode) > 0 {
+		resource["ipc_mode"] = taskDef.IpcMode
+	}
+	if len(taskDef.RequiresCompatibilities) > 0 {
+		resource["requires_compatibilities"] = taskDef.RequiresCompatibilities
+	}
+
+	if taskDef.RuntimePlatform != nil {
+		runtimePlatform := map[string]interface{}{}
+		if len(taskDef.RuntimePlatform.CpuArchitecture) > 0 {
+			runtimePlatform["cpu_architecture"] = taskDef.RuntimePlatform.CpuArchitecture
+		}
+		if len(taskDef.RuntimePlatform.OperatingSystemFamily) > 0 {
+			runtimePlatform["operating_system_family"] = taskDef.RuntimePlatform.OperatingSystemFamily
+		}
+		resource["runtime_platform"] = runtimePlatform
+	}
+
+	if len(taskDef.Volumes) > 0 {
+		volumes := []map[string]interface{}{}
+		for _, volume := range taskDef.Volumes {
+			block := map[string]interface{}{
+				"name": volume.Name,
+			}
+			if volume.Host != nil && len(volume.Host.SourcePath) > 0 {
+				block["host_path"] = volume.Host.SourcePath
+			}
+			volumes = append(volumes, block)
+		}
+		resource["volume"] = volumes
+	}
+
+	config.Resources = append(config.Resources, TerraformResource{
+		Type:       "aws_ecs_task_definition",
+		Name:       terraformName(taskDef.Family),
+		Attributes: resource,
+	})
+
+	return MarshalTerraform(config)
+}
+
+// ecsTerraformDefault returns the default of an optional variable, which is
+// null when the value is not set
+func ecsTerraformDefault(value string) *string {
+	if len(value) == 0 {
+		return nil
+	}
+	return &value
+}
+
+// ecsContainerDefinitionsTokens returns a jsonencode call of the container
+// definitions as HCL, keeping the order of the JSON properties and replacing
+// each image with a reference to its variable
+func ecsContainerDefinitionsTokens(containers []ECSContainerDefinition, images map[string]TerraformReference) (hclwrite.Tokens, error) {
+	elems := []hclwrite.Tokens{}
+	for _, container := range containers {
+		b, err := json.Marshal(container)
+		if err != nil {
+			return nil, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+		overrides := map[string]hclwrite.Tokens{}
+		if image, ok := images[container.Name]; ok {
+			overrides["image"] = hclwrite.Tokens{
+				{Type: hclsyntax.TokenIdent, Bytes: []byte(image[0])},
+				{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
+				{Type: hclsyntax.TokenIdent, Bytes: []byte(image[1])},
+			}
+		}
+
+		tokens, err := jsonValueTokens(decoder, overrides)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, tokens)
+	}
+
+	return hclwrite.TokensForFunctionCall("jsonencode", hclwrite.TokensForTuple(elems)), nil
+}
+
+// jsonValueTokens reads the next JSON value from the decoder and returns it as
+// HCL tokens. The overrides replace the values of the properties of a top-level
+// object.
+func jsonValueTokens(decoder *json.Decoder, overrides map[string]hclwrite.Tokens) (hclwrite.Tokens, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' {
+			attrs := []hclwrite.ObjectAttrTokens{}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyToken.(string)
+
+				tokens, err := jsonValueTokens(decoder, nil)
+				if err != nil {
+					return nil, err
+				}
+				if override, ok := overrides[key]; ok {
+					tokens = override
+				}
+
+				name := hclwrite.TokensForValue(cty.StringVal(key))
+				if hclsyntax.ValidIdentifier(key) {
+					name = hclwrite.TokensForIdentifier(key)
+				}
+				attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: name, Value: tokens})
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return hclwrite.TokensForObject(attrs), nil
+		}
+
+		elems := []hclwrite.Tokens{}
+		for decoder.More() {
+			tokens, err := jsonValueTokens(decoder, nil)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, tokens)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return hclwrite.TokensForTuple(elems), nil
+	case string:
+		return hclwrite.TokensForValue(cty.StringVal(value)), nil
+	case json.Number:
+		number, err := cty.ParseNumberVal(value.String())
+		if err != nil {
+			return nil, err
+		}
+		return hclwrite.TokensForValue(number), nil
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(value)), nil
+	default:
+		return hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType)), nil
+	}
+}
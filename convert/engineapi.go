@@ -0,0 +1,812 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	units "github.com/docker/go-units"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	shellwords "github.com/mattn/go-shellwords"
+)
+
+// engineAPIBuiltinNetworks holds the --network values that do not name a
+// user-defined network, and so cannot hold endpoint settings
+var engineAPIBuiltinNetworks = map[string]bool{
+	"":        true,
+	"bridge":  true,
+	"default": true,
+	"host":    true,
+	"none":    true,
+}
+
+// EngineAPIContainerCreate represents the body of a POST /containers/create
+// request. The container config is sent at the top level of the body, next
+// to HostConfig and NetworkingConfig.
+type EngineAPIContainerCreate struct {
+	EngineAPIContainerConfig
+	HostConfig       EngineAPIHostConfig        `json:"HostConfig"`
+	NetworkingConfig *EngineAPINetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+// EngineAPIContainerConfig represents the portable settings of a container
+type EngineAPIContainerConfig struct {
+	Hostname     string                `json:"Hostname,omitempty"`
+	Domainname   string                `json:"Domainname,omitempty"`
+	User         string                `json:"User,omitempty"`
+	AttachStdin  bool                  `json:"AttachStdin,omitempty"`
+	AttachStdout bool                  `json:"AttachStdout,omitempty"`
+	AttachStderr bool                  `json:"AttachStderr,omitempty"`
+	ExposedPorts map[string]struct{}   `json:"ExposedPorts,omitempty"`
+	Tty          bool                  `json:"Tty,omitempty"`
+	OpenStdin    bool                  `json:"OpenStdin,omitempty"`
+	StdinOnce    bool                  `json:"StdinOnce,omitempty"`
+	Env          []string              `json:"Env,omitempty"`
+	Cmd          []string              `json:"Cmd,omitempty"`
+	Healthcheck  *EngineAPIHealthcheck `json:"Healthcheck,omitempty"`
+	Image        string                `json:"Image"`
+	Volumes      map[string]struct{}   `json:"Volumes,omitempty"`
+	WorkingDir   string                `json:"WorkingDir,omitempty"`
+	Entrypoint   []string              `json:"Entrypoint,omitempty"`
+	MacAddress   string                `json:"MacAddress,omitempty"`
+	Labels       map[string]string     `json:"Labels,omitempty"`
+	StopSignal   string                `json:"StopSignal,omitempty"`
+	StopTimeout  *int                  `json:"StopTimeout,omitempty"`
+}
+
+// EngineAPIHealthcheck represents the health check of a container, with the
+// durations in nanoseconds
+type EngineAPIHealthcheck struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval,omitempty"`
+	Timeout     int64    `json:"Timeout,omitempty"`
+	StartPeriod int64    `json:"StartPeriod,omitempty"`
+	Retries     int      `json:"Retries,omitempty"`
+}
+
+// EngineAPIHostConfig represents the non-portable settings of a container,
+// which depend on the host it runs on
+type EngineAPIHostConfig struct {
+	Binds                []string                          `json:"Binds,omitempty"`
+	LogConfig            *EngineAPILogConfig               `json:"LogConfig,omitempty"`
+	NetworkMode          string                            `json:"NetworkMode,omitempty"`
+	PortBindings         map[string][]EngineAPIPortBinding `json:"PortBindings,omitempty"`
+	RestartPolicy        *EngineAPIRestartPolicy           `json:"RestartPolicy,omitempty"`
+	AutoRemove           bool                              `json:"AutoRemove,omitempty"`
+	VolumeDriver         string                            `json:"VolumeDriver,omitempty"`
+	VolumesFrom          []string                          `json:"VolumesFrom,omitempty"`
+	Annotations          map[string]string                 `json:"Annotations,omitempty"`
+	CapAdd               []string                          `json:"CapAdd,omitempty"`
+	CapDrop              []string                          `json:"CapDrop,omitempty"`
+	CgroupnsMode         string                            `json:"CgroupnsMode,omitempty"`
+	Dns                  []string                          `json:"Dns,omitempty"`
+	DnsOptions           []string                          `json:"DnsOptions,omitempty"`
+	DnsSearch            []string                          `json:"DnsSearch,omitempty"`
+	ExtraHosts           []string                          `json:"ExtraHosts,omitempty"`
+	GroupAdd             []string                          `json:"GroupAdd,omitempty"`
+	IpcMode              string                            `json:"IpcMode,omitempty"`
+	Links                []string                          `json:"Links,omitempty"`
+	OomScoreAdj          int                               `json:"OomScoreAdj,omitempty"`
+	PidMode              string                            `json:"PidMode,omitempty"`
+	Privileged           bool                              `json:"Privileged,omitempty"`
+	PublishAllPorts      bool                              `json:"PublishAllPorts,omitempty"`
+	ReadonlyRootfs       bool                              `json:"ReadonlyRootfs,omitempty"`
+	SecurityOpt          []string                          `json:"SecurityOpt,omitempty"`
+	StorageOpt           map[string]string                 `json:"StorageOpt,omitempty"`
+	Tmpfs                map[string]string                 `json:"Tmpfs,omitempty"`
+	UTSMode              string                            `json:"UTSMode,omitempty"`
+	UsernsMode           string                            `json:"UsernsMode,omitempty"`
+	ShmSize              int64                             `json:"ShmSize,omitempty"`
+	Sysctls              map[string]string                 `json:"Sysctls,omitempty"`
+	Runtime              string                            `json:"Runtime,omitempty"`
+	Isolation            string                            `json:"Isolation,omitempty"`
+	CpuShares            int64                             `json:"CpuShares,omitempty"`
+	Memory               int64                             `json:"Memory,omitempty"`
+	NanoCpus             int64                             `json:"NanoCpus,omitempty"`
+	CgroupParent         string                            `json:"CgroupParent,omitempty"`
+	BlkioWeight          int                               `json:"BlkioWeight,omitempty"`
+	BlkioWeightDevice    []EngineAPIWeightDevice           `json:"BlkioWeightDevice,omitempty"`
+	BlkioDeviceReadBps   []EngineAPIThrottleDevice         `json:"BlkioDeviceReadBps,omitempty"`
+	BlkioDeviceWriteBps  []EngineAPIThrottleDevice         `json:"BlkioDeviceWriteBps,omitempty"`
+	BlkioDeviceReadIOps  []EngineAPIThrottleDevice         `json:"BlkioDeviceReadIOps,omitempty"`
+	BlkioDeviceWriteIOps []EngineAPIThrottleDevice         `json:"BlkioDeviceWriteIOps,omitempty"`
+	CpuPeriod            int64                             `json:"CpuPeriod,omitempty"`
+	CpuQuota             int64                             `json:"CpuQuota,omitempty"`
+	CpuRealtimePeriod    int64                             `json:"CpuRealtimePeriod,omitempty"`
+	CpuRealtimeRuntime   int64                             `json:"CpuRealtimeRuntime,omitempty"`
+	CpusetCpus           string                            `json:"CpusetCpus,omitempty"`
+	CpusetMems           string                            `json:"CpusetMems,omitempty"`
+	Devices              []EngineAPIDevice                 `json:"Devices,omitempty"`
+	DeviceCgroupRules    []string                          `json:"DeviceCgroupRules,omitempty"`
+	DeviceRequests       []EngineAPIDeviceRequest          `json:"DeviceRequests,omitempty"`
+	KernelMemory         int64                             `json:"KernelMemory,omitempty"`
+	MemoryReservation    int64                             `json:"MemoryReservation,omitempty"`
+	MemorySwap           int64                             `json:"MemorySwap,omitempty"`
+	MemorySwappiness     *int64                            `json:"MemorySwappiness,omitempty"`
+	OomKillDisable       *bool                             `json:"OomKillDisable,omitempty"`
+	PidsLimit            *int64                            `json:"PidsLimit,omitempty"`
+	Ulimits              []EngineAPIUlimit                 `json:"Ulimits,omitempty"`
+	Mounts               []EngineAPIMount                  `json:"Mounts,omitempty"`
+	Init                 *bool                             `json:"Init,omitempty"`
+}
+
+// EngineAPILogConfig represents the logging driver of a container
+type EngineAPILogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config,omitempty"`
+}
+
+// EngineAPIPortBinding represents the host address a container port is
+// published on
+type EngineAPIPortBinding struct {
+	HostIp   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// EngineAPIRestartPolicy represents the restart policy of a container
+type EngineAPIRestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount,omitempty"`
+}
+
+// EngineAPIWeightDevice represents the block IO weight of a device
+type EngineAPIWeightDevice struct {
+	Path   string `json:"Path"`
+	Weight int    `json:"Weight"`
+}
+
+// EngineAPIThrottleDevice represents the block IO rate limit of a device
+type EngineAPIThrottleDevice struct {
+	Path string `json:"Path"`
+	Rate int64  `json:"Rate"`
+}
+
+// EngineAPIDevice represents a host device added to a container
+type EngineAPIDevice struct {
+	PathOnHost        string `json:"PathOnHost"`
+	PathInContainer   string `json:"PathInContainer"`
+	CgroupPermissions string `json:"CgroupPermissions"`
+}
+
+// EngineAPIDeviceRequest represents a request for devices from a device
+// driver, e.g., GPUs
+type EngineAPIDeviceRequest struct {
+	Driver       string     `json:"Driver,omitempty"`
+	Count        int        `json:"Count,omitempty"`
+	DeviceIDs    []string   `json:"DeviceIDs,omitempty"`
+	Capabilities [][]string `json:"Capabilities"`
+}
+
+// EngineAPIUlimit represents a resource limit of a container
+type EngineAPIUlimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+// EngineAPIMount represents a mount added with --mount
+type EngineAPIMount struct {
+	Type          string                  `json:"Type"`
+	Source        string                  `json:"Source,omitempty"`
+	Target        string                  `json:"Target"`
+	ReadOnly      bool                    `json:"ReadOnly,omitempty"`
+	BindOptions   *EngineAPIBindOptions   `json:"BindOptions,omitempty"`
+	VolumeOptions *EngineAPIVolumeOptions `json:"VolumeOptions,omitempty"`
+	TmpfsOptions  *EngineAPITmpfsOptions  `json:"TmpfsOptions,omitempty"`
+}
+
+// EngineAPIBindOptions represents the options of a bind mount
+type EngineAPIBindOptions struct {
+	Propagation string `json:"Propagation,omitempty"`
+}
+
+// EngineAPIVolumeOptions represents the options of a volume mount
+type EngineAPIVolumeOptions struct {
+	NoCopy       bool              `json:"NoCopy,omitempty"`
+	Labels       map[string]string `json:"Labels,omitempty"`
+	DriverConfig *EngineAPIDriver  `json:"DriverConfig,omitempty"`
+}
+
+// EngineAPIDriver represents the volume driver of a volume mount
+type EngineAPIDriver struct {
+	Name    string            `json:"Name"`
+	Options map[string]string `json:"Options,omitempty"`
+}
+
+// EngineAPITmpfsOptions represents the options of a tmpfs mount
+type EngineAPITmpfsOptions struct {
+	SizeBytes int64 `json:"SizeBytes,omitempty"`
+	Mode      int   `json:"Mode,omitempty"`
+}
+
+// EngineAPINetworkingConfig represents the networks a container is connected
+// to on creation
+type EngineAPINetworkingConfig struct {
+	EndpointsConfig map[string]*EngineAPIEndpointSettings `json:"EndpointsConfig"`
+}
+
+// EngineAPIEndpointSettings represents the settings of a container on a
+// network
+type EngineAPIEndpointSettings struct {
+	IPAMConfig *EngineAPIEndpointIPAMConfig `json:"IPAMConfig,omitempty"`
+	Aliases    []string                     `json:"Aliases,omitempty"`
+}
+
+// EngineAPIEndpointIPAMConfig represents the static addresses of a container
+// on a network
+type EngineAPIEndpointIPAMConfig struct {
+	IPv4Address  string   `json:"IPv4Address,omitempty"`
+	IPv6Address  string   `json:"IPv6Address,omitempty"`
+	LinkLocalIPs []string `json:"LinkLocalIPs,omitempty"`
+}
+
+// ToEngineAPI converts docker run arguments to the body of a Docker Engine
+// API POST /containers/create request, as sent by the docker cli. Only the
+// flags handled by the cli itself, or sent as query parameters, are not set.
+func ToEngineAPI(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	body := &EngineAPIContainerCreate{
+		EngineAPIContainerConfig: EngineAPIContainerConfig{
+			Hostname:   c.Hostname,
+			Domainname: c.Domainname,
+			User:       c.User,
+			Tty:        c.Tty,
+			Env:        c.Env,
+			Cmd:        arguments["command"].ListValue(),
+			Image:      arguments["image"].StringValue(),
+			WorkingDir: c.Workdir,
+			MacAddress: c.Mac,
+		},
+		HostConfig: EngineAPIHostConfig{
+			VolumeDriver:       c.VolumeDriver,
+			VolumesFrom:        c.VolumesFrom,
+			CapAdd:             c.CapAdd,
+			CapDrop:            c.CapDrop,
+			CgroupnsMode:       c.Cgroupns,
+			Dns:                c.Dns,
+			DnsOptions:         c.DnsOption,
+			DnsSearch:          c.DnsSearch,
+			ExtraHosts:         c.AddHost,
+			GroupAdd:           c.GroupAdd,
+			IpcMode:            c.Ipc,
+			Links:              c.Link,
+			OomScoreAdj:        c.OomScore,
+			PidMode:            c.Pid,
+			Privileged:         c.Privileged,
+			PublishAllPorts:    c.PublishAll,
+			ReadonlyRootfs:     c.ReadOnly,
+			SecurityOpt:        c.SecurityOpt,
+			UTSMode:            c.Uts,
+			UsernsMode:         c.Userns,
+			ShmSize:            int64(c.ShmSize),
+			Runtime:            c.Runtime,
+			Isolation:          c.Isolation,
+			CpuShares:          int64(c.CpuShares),
+			Memory:             c.Memory,
+			NanoCpus:           engineAPINanoCPUs(c.Cpus),
+			CgroupParent:       c.CgroupParent,
+			BlkioWeight:        c.BlkioWeight,
+			CpuPeriod:          int64(c.CpuPeriod),
+			CpuQuota:           int64(c.CpuQuota),
+			CpuRealtimePeriod:  int64(c.CpuRtPeriod),
+			CpuRealtimeRuntime: int64(c.CpuRtRuntime),
+			CpusetCpus:         c.CpusetCpus,
+			CpusetMems:         c.CpusetMems,
+			DeviceCgroupRules:  c.DeviceCgroupRule,
+			KernelMemory:       int64(c.KernelMemory),
+			MemoryReservation:  c.MemoryReservation,
+			MemorySwap:         c.MemorySwap,
+			AutoRemove:         c.Rm,
+		},
+	}
+	config := &body.EngineAPIContainerConfig
+	hostConfig := &body.HostConfig
+
+	// name / platform are sent as query parameters
+	if len(c.ContainerName) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --name in engine api payload as it is sent as the name query parameter, call POST /containers/create?name=%s", c.ContainerName))
+	}
+	if len(c.Platform) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --platform in engine api payload as it is sent as the platform query parameter, call POST /containers/create?platform=%s", c.Platform))
+	}
+
+	// interactive / attach / detach -> the stdio streams attached on start
+	config.OpenStdin = c.Interactive
+	config.AttachStdin = c.Interactive && !c.Detach
+	config.StdinOnce = config.AttachStdin
+	if len(c.Attach) > 0 {
+		for _, stream := range c.Attach {
+			switch strings.ToLower(stream) {
+			case "stdin":
+				config.AttachStdin = true
+			case "stdout":
+				config.AttachStdout = true
+			case "stderr":
+				config.AttachStderr = true
+			default:
+				errs = multierror.Append(errs, fmt.Errorf("invalid --attach value %q: expected stdin, stdout or stderr", stream))
+			}
+		}
+	} else if !c.Detach {
+		config.AttachStdout = true
+		config.AttachStderr = true
+	}
+
+	// entrypoint -> Entrypoint
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			config.Entrypoint = args
+		}
+	} else if c.EntrypointCleared {
+		// the docker cli sends [""] to clear the image entrypoint
+		config.Entrypoint = []string{""}
+	}
+
+	// label -> Labels
+	for _, value := range c.Label {
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		key, val := extractParts(value, "=")
+		config.Labels[key] = val
+	}
+
+	// annotation -> HostConfig.Annotations
+	for _, value := range c.Annotation {
+		if hostConfig.Annotations == nil {
+			hostConfig.Annotations = map[string]string{}
+		}
+		key, val := extractParts(value, "=")
+		hostConfig.Annotations[key] = val
+	}
+
+	// env-file / label-file are read by the docker cli
+	for _, value := range c.EnvFile {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env-file %s in engine api payload as the file is read by the docker cli, add its variables to Env", value))
+	}
+	for _, value := range c.LabelFile {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --label-file %s in engine api payload as the file is read by the docker cli, add its labels to Labels", value))
+	}
+
+	// publish -> ExposedPorts and HostConfig.PortBindings
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			port := fmt.Sprintf("%d/%s", p.Target, p.Protocol)
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = map[string]struct{}{}
+			}
+			if hostConfig.PortBindings == nil {
+				hostConfig.PortBindings = map[string][]EngineAPIPortBinding{}
+			}
+			config.ExposedPorts[port] = struct{}{}
+			hostConfig.PortBindings[port] = append(hostConfig.PortBindings[port], EngineAPIPortBinding{
+				HostIp:   p.HostIP,
+				HostPort: p.Published,
+			})
+		}
+	}
+
+	// expose -> ExposedPorts
+	for _, value := range c.Expose {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --expose flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = map[string]struct{}{}
+			}
+			config.ExposedPorts[fmt.Sprintf("%d/%s", p.Target, p.Protocol)] = struct{}{}
+		}
+	}
+
+	// volume -> Binds, or Volumes for anonymous volumes
+	for _, value := range c.Volume {
+		if !strings.Contains(value, ":") {
+			if config.Volumes == nil {
+				config.Volumes = map[string]struct{}{}
+			}
+			config.Volumes[value] = struct{}{}
+			continue
+		}
+		hostConfig.Binds = append(hostConfig.Binds, value)
+	}
+
+	// mount -> HostConfig.Mounts
+	for _, value := range c.Mount {
+		parsed, err := parseDockerMount(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, engineAPIMount(parsed))
+	}
+
+	// tmpfs -> HostConfig.Tmpfs
+	for _, value := range c.Tmpfs {
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = map[string]string{}
+		}
+		target, options := extractParts(value, ":")
+		hostConfig.Tmpfs[target] = options
+	}
+
+	// network / network-alias / ip / ip6 / link-local-ip ->
+	// HostConfig.NetworkMode and NetworkingConfig
+	hostConfig.NetworkMode = c.Network
+	hasEndpointSettings := len(c.NetworkAlias) > 0 || len(c.Ip) > 0 || len(c.Ip6) > 0 || len(c.LinkLocalIP) > 0
+	if engineAPIBuiltinNetworks[c.Network] || strings.HasPrefix(c.Network, "container:") {
+		if hasEndpointSettings {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --network-alias, --ip, --ip6 and --link-local-ip properties in engine api payload without a user-defined --network"))
+		}
+	} else {
+		endpoint := &EngineAPIEndpointSettings{
+			Aliases: c.NetworkAlias,
+		}
+		if len(c.Ip) > 0 || len(c.Ip6) > 0 || len(c.LinkLocalIP) > 0 {
+			endpoint.IPAMConfig = &EngineAPIEndpointIPAMConfig{
+				IPv4Address:  c.Ip,
+				IPv6Address:  c.Ip6,
+				LinkLocalIPs: c.LinkLocalIP,
+			}
+		}
+		body.NetworkingConfig = &EngineAPINetworkingConfig{
+			EndpointsConfig: map[string]*EngineAPIEndpointSettings{
+				c.Network: endpoint,
+			},
+		}
+	}
+
+	// restart -> HostConfig.RestartPolicy
+	if len(c.Restart) > 0 {
+		mode, maxRetries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			hostConfig.RestartPolicy = &EngineAPIRestartPolicy{
+				Name:              mode,
+				MaximumRetryCount: maxRetries,
+			}
+			if c.Rm && mode != "no" {
+				errs = multierror.Append(errs, fmt.Errorf("unable to set both --restart and --rm in engine api payload as the daemon rejects the combination"))
+			}
+		}
+	}
+
+	// health-* / no-healthcheck -> Healthcheck
+	hasHealthOptions := len(c.HealthCmd) > 0 || c.HealthInterval != "0s" || c.HealthTimeout != "0s" || c.HealthStartPeriod != "0s" || c.HealthRetries > 0
+	if c.NoHealthcheck {
+		if hasHealthOptions {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --health-* properties in engine api payload as --no-healthcheck is specified"))
+		}
+		config.Healthcheck = &EngineAPIHealthcheck{
+			Test: []string{"NONE"},
+		}
+	} else if hasHealthOptions {
+		healthcheck := &EngineAPIHealthcheck{
+			Retries: int(c.HealthRetries),
+		}
+		if len(c.HealthCmd) > 0 {
+			healthcheck.Test = []string{"CMD-SHELL", c.HealthCmd}
+		}
+		for _, duration := range []struct {
+			flag  string
+			value string
+			field *int64
+		}{
+			{"health-interval", c.HealthInterval, &healthcheck.Interval},
+			{"health-timeout", c.HealthTimeout, &healthcheck.Timeout},
+			{"health-start-period", c.HealthStartPeriod, &healthcheck.StartPeriod},
+		} {
+			val, err := time.ParseDuration(duration.value)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --%s flag to duration: %w", duration.flag, err))
+				continue
+			}
+			*duration.field = int64(val)
+		}
+		config.Healthcheck = healthcheck
+	}
+
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		config.StopSignal = c.StopSignal
+	}
+	if c.StopTimeout > 0 {
+		config.StopTimeout = IntToPtr(c.StopTimeout)
+	}
+
+	// device -> HostConfig.Devices
+	for _, value := range c.Device {
+		parts := strings.SplitN(value, ":", 3)
+		device := EngineAPIDevice{
+			PathOnHost:        parts[0],
+			PathInContainer:   parts[0],
+			CgroupPermissions: "rwm",
+		}
+		if len(parts) >= 2 {
+			device.PathInContainer = parts[1]
+		}
+		if len(parts) == 3 {
+			device.CgroupPermissions = parts[2]
+		}
+		hostConfig.Devices = append(hostConfig.Devices, device)
+	}
+
+	// gpus -> HostConfig.DeviceRequests
+	if len(c.Gpus) > 0 {
+		request, err := engineAPIGpuRequest(c.Gpus)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			hostConfig.DeviceRequests = []EngineAPIDeviceRequest{request}
+		}
+	}
+
+	// blkio-weight-device / device-*-bps / device-*-iops -> HostConfig.Blkio*
+	for _, value := range c.BlkioWeightDevice {
+		path, weight := extractParts(value, ":")
+		number, err := strconv.Atoi(weight)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --blkio-weight-device flag: %w", err))
+			continue
+		}
+		hostConfig.BlkioWeightDevice = append(hostConfig.BlkioWeightDevice, EngineAPIWeightDevice{
+			Path:   path,
+			Weight: number,
+		})
+	}
+	for _, throttle := range []struct {
+		flag    string
+		values  []string
+		devices *[]EngineAPIThrottleDevice
+		parse   func(string) (int64, error)
+	}{
+		{"device-read-bps", c.DeviceReadBps, &hostConfig.BlkioDeviceReadBps, units.RAMInBytes},
+		{"device-write-bps", c.DeviceWriteBps, &hostConfig.BlkioDeviceWriteBps, units.RAMInBytes},
+		{"device-read-iops", c.DeviceReadIops, &hostConfig.BlkioDeviceReadIOps, engineAPIParseRate},
+		{"device-write-iops", c.DeviceWriteIops, &hostConfig.BlkioDeviceWriteIOps, engineAPIParseRate},
+	} {
+		for _, value := range throttle.values {
+			path, rate := extractParts(value, ":")
+			number, err := throttle.parse(rate)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --%s flag: %w", throttle.flag, err))
+				continue
+			}
+			*throttle.devices = append(*throttle.devices, EngineAPIThrottleDevice{
+				Path: path,
+				Rate: number,
+			})
+		}
+	}
+
+	// memory-swappiness / oom-kill-disable / pids-limit / init are only sent
+	// when set, as the daemon treats a zero value differently from unset
+	if c.MemorySwappiness != 0 {
+		swappiness := c.MemorySwappiness
+		hostConfig.MemorySwappiness = &swappiness
+	}
+	if c.OomKillDisable {
+		hostConfig.OomKillDisable = BoolToPtr(true)
+	}
+	if c.PidsLimit != 0 {
+		pidsLimit := int64(c.PidsLimit)
+		hostConfig.PidsLimit = &pidsLimit
+	}
+	if c.Init {
+		hostConfig.Init = BoolToPtr(true)
+	}
+
+	// ulimit -> HostConfig.Ulimits
+	for _, value := range c.Ulimit {
+		name, limits := extractParts(value, "=")
+		soft, hard := extractParts(limits, ":")
+		if len(hard) == 0 {
+			hard = soft
+		}
+		softLimit, err := strconv.ParseInt(soft, 10, 64)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit flag value: %w", err))
+			continue
+		}
+		hardLimit, err := strconv.ParseInt(hard, 10, 64)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit flag value: %w", err))
+			continue
+		}
+		hostConfig.Ulimits = append(hostConfig.Ulimits, EngineAPIUlimit{
+			Name: name,
+			Soft: softLimit,
+			Hard: hardLimit,
+		})
+	}
+
+	if len(c.Sysctl) > 0 {
+		hostConfig.Sysctls = c.Sysctl
+	}
+
+	// storage-opt -> HostConfig.StorageOpt
+	for _, value := range c.StorageOpt {
+		if hostConfig.StorageOpt == nil {
+			hostConfig.StorageOpt = map[string]string{}
+		}
+		key, val := extractParts(value, "=")
+		hostConfig.StorageOpt[key] = val
+	}
+
+	// log-driver / log-opt -> HostConfig.LogConfig
+	if len(c.LogDriver) > 0 || len(c.LogOpt) > 0 {
+		hostConfig.LogConfig = &EngineAPILogConfig{
+			Type: c.LogDriver,
+		}
+		for _, value := range c.LogOpt {
+			if hostConfig.LogConfig.Config == nil {
+				hostConfig.LogConfig.Config = map[string]string{}
+			}
+			key, val := extractParts(value, "=")
+			hostConfig.LogConfig.Config[key] = val
+		}
+	}
+
+	// security-opt seccomp profiles are read by the docker cli
+	for _, value := range c.SecurityOpt {
+		key, val := extractParts(value, "=")
+		if key == "seccomp" && val != "unconfined" && val != "builtin" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --security-opt %s in engine api payload as the profile is read by the docker cli, replace the path with the JSON profile", value))
+		}
+	}
+
+	// flags handled by the docker cli
+	if len(c.Cidfile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cidfile property in engine api payload as the file is written by the docker cli"))
+	}
+	if len(c.DetachKeys) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --detach-keys property in engine api payload as it is sent when attaching to the container"))
+	}
+	if c.Pull != "missing" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pull property in engine api payload as the image is pulled by the docker cli, call POST /images/create first"))
+	}
+	if !c.SigProxy {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --sig-proxy property in engine api payload as signals are proxied by the docker cli"))
+	}
+	if !c.DisableContentTrust {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --disable-content-trust property in engine api payload as images are verified by the docker cli"))
+	}
+
+	return body, warnings, errs
+}
+
+// MarshalEngineAPI marshals the body of a POST /containers/create request
+func MarshalEngineAPI(body *EngineAPIContainerCreate) ([]byte, error) {
+	return json.MarshalIndent(body, "", "  ")
+}
+
+// engineAPINanoCPUs converts a --cpus value to billionths of a cpu. The value
+// is rounded at float32 precision first so that e.g. 0.1 is not sent as
+// 100000001.
+func engineAPINanoCPUs(cpus float32) int64 {
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(cpus), 'f', -1, 32), 64)
+	return int64(math.Round(value * 1e9))
+}
+
+// engineAPIParseRate parses the rate of a --device-*-iops value
+func engineAPIParseRate(value string) (int64, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// engineAPIGpuRequest parses a --gpus value like "all", "2", or
+// "device=0,1" / "count=2,driver=nvidia" into a device request. As with the
+// docker cli, the value is read as CSV so that quoted fields may hold a
+// comma, and the gpu capability is always requested.
+func engineAPIGpuRequest(value string) (EngineAPIDeviceRequest, error) {
+	request := EngineAPIDeviceRequest{}
+	if value == "all" {
+		value = "count=all"
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		value = "count=" + value
+	}
+
+	fields, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return request, fmt.Errorf("unable to parse --gpus flag: %w", err)
+	}
+
+	capabilities := []string{}
+	for _, field := range fields {
+		key, val := extractParts(field, "=")
+		switch key {
+		case "count":
+			if val == "all" {
+				request.Count = -1
+				continue
+			}
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return request, fmt.Errorf("unable to parse --gpus count %q: %w", val, err)
+			}
+			request.Count = n
+		case "device":
+			request.DeviceIDs = strings.Split(val, ",")
+		case "driver":
+			request.Driver = val
+		case "capabilities":
+			capabilities = append(capabilities, strings.Split(val, ",")...)
+		default:
+			return request, fmt.Errorf("unknown --gpus option %q in %q", key, value)
+		}
+	}
+	if request.Count != 0 && len(request.DeviceIDs) > 0 {
+		return request, fmt.Errorf("unable to set both count and device in --gpus flag %q", value)
+	}
+	request.Capabilities = [][]string{append(capabilities, "gpu")}
+
+	return request, nil
+}
+
+// engineAPIMount converts a parsed --mount value to a mount
+func engineAPIMount(parsed map[string]interface{}) EngineAPIMount {
+	mount := EngineAPIMount{
+		Type:   parsed["type"].(string),
+		Target: parsed["target"].(string),
+	}
+	if source, ok := parsed["source"].(string); ok {
+		mount.Source = source
+	}
+	if readOnly, ok := parsed["readonly"].(bool); ok {
+		mount.ReadOnly = readOnly
+	}
+	if options, ok := parsed["bind_options"].(map[string]interface{}); ok {
+		mount.BindOptions = &EngineAPIBindOptions{}
+		if propagation, ok := options["propagation"].(string); ok {
+			mount.BindOptions.Propagation = propagation
+		}
+	}
+	if options, ok := parsed["volume_options"].(map[string]interface{}); ok {
+		mount.VolumeOptions = &EngineAPIVolumeOptions{}
+		if noCopy, ok := options["no_copy"].(bool); ok {
+			mount.VolumeOptions.NoCopy = noCopy
+		}
+		if labels, ok := options["labels"].(map[string]string); ok {
+			mount.VolumeOptions.Labels = labels
+		}
+		if driver, ok := options["driver_config"].(map[string]interface{}); ok {
+			mount.VolumeOptions.DriverConfig = &EngineAPIDriver{}
+			if name, ok := driver["name"].(string); ok {
+				mount.VolumeOptions.DriverConfig.Name = name
+			}
+			if driverOptions, ok := driver["options"].(map[string]string); ok {
+				mount.VolumeOptions.DriverConfig.Options = driverOptions
+			}
+		}
+	}
+	if options, ok := parsed["tmpfs_options"].(map[string]interface{}); ok {
+		mount.TmpfsOptions = &EngineAPITmpfsOptions{}
+		if size, ok := options["size"].(int64); ok {
+			mount.TmpfsOptions.SizeBytes = size
+		}
+		if mode, ok := options["mode"].(int); ok {
+			mount.TmpfsOptions.Mode = mode
+		}
+	}
+
+	return mount
+}
@@ -0,0 +1,211 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"docker-run-export/arguments"
+)
+
+// TestEngineAPIGpuRequest verifies that --gpus values are read as CSV, as the
+// docker cli does, and that the gpu capability is always requested.
+func TestEngineAPIGpuRequest(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    EngineAPIDeviceRequest
+		wantErr bool
+	}{
+		{"all", EngineAPIDeviceRequest{Count: -1, Capabilities: [][]string{{"gpu"}}}, false},
+		{"2", EngineAPIDeviceRequest{Count: 2, Capabilities: [][]string{{"gpu"}}}, false},
+		{`"device=0,1"`, EngineAPIDeviceRequest{DeviceIDs: []string{"0", "1"}, Capabilities: [][]string{{"gpu"}}}, false},
+		{`count=1,driver=nvidia,"capabilities=compute,utility"`, EngineAPIDeviceRequest{Count: 1, Driver: "nvidia", Capabilities: [][]string{{"compute", "utility", "gpu"}}}, false},
+		{"count=1,device=0", EngineAPIDeviceRequest{}, true},
+		{"memory=1", EngineAPIDeviceRequest{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := engineAPIGpuRequest(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("engineAPIGpuRequest(%q) expected an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("engineAPIGpuRequest(%q) returned an error: %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("engineAPIGpuRequest(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestToEngineAPI verifies that the parsed docker run flags are mapped to the
+// fields the docker cli sends to POST /containers/create.
+func TestToEngineAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    arguments.Args
+		command []string
+		field   func(*EngineAPIContainerCreate) interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "published port with host ip",
+			args:  arguments.Args{Publish: []string{"127.0.0.1:8080:80"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.PortBindings },
+			want:  map[string][]EngineAPIPortBinding{"80/tcp": {{HostIp: "127.0.0.1", HostPort: "8080"}}},
+		},
+		{
+			name:  "published udp port is exposed",
+			args:  arguments.Args{Publish: []string{"53:53/udp"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.ExposedPorts },
+			want:  map[string]struct{}{"53/udp": {}},
+		},
+		{
+			name:  "port range",
+			args:  arguments.Args{Publish: []string{"8000-8001:9000-9001"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return len(b.HostConfig.PortBindings) },
+			want:  2,
+		},
+		{
+			name:    "invalid port",
+			args:    arguments.Args{Publish: []string{"80:eighty"}},
+			wantErr: true,
+		},
+		{
+			name:  "bind and named volumes become binds",
+			args:  arguments.Args{Volume: []string{"/srv:/data:ro", "cache:/cache"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.Binds },
+			want:  []string{"/srv:/data:ro", "cache:/cache"},
+		},
+		{
+			name:  "anonymous volume",
+			args:  arguments.Args{Volume: []string{"/data"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.Volumes },
+			want:  map[string]struct{}{"/data": {}},
+		},
+		{
+			name:  "volume mount",
+			args:  arguments.Args{Mount: []string{"type=volume,source=data,target=/data,readonly,volume-nocopy"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.Mounts },
+			want:  []EngineAPIMount{{Type: "volume", Source: "data", Target: "/data", ReadOnly: true, VolumeOptions: &EngineAPIVolumeOptions{NoCopy: true}}},
+		},
+		{
+			name:  "bind mount propagation",
+			args:  arguments.Args{Mount: []string{"type=bind,source=/srv,target=/srv,bind-propagation=rshared"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.Mounts },
+			want:  []EngineAPIMount{{Type: "bind", Source: "/srv", Target: "/srv", BindOptions: &EngineAPIBindOptions{Propagation: "rshared"}}},
+		},
+		{
+			name:  "restart on-failure with retries",
+			args:  arguments.Args{Restart: "on-failure:3"},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.RestartPolicy },
+			want:  &EngineAPIRestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+		},
+		{
+			name:  "restart unless-stopped",
+			args:  arguments.Args{Restart: "unless-stopped"},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.RestartPolicy },
+			want:  &EngineAPIRestartPolicy{Name: "unless-stopped"},
+		},
+		{
+			name:    "restart with rm",
+			args:    arguments.Args{Restart: "always", Rm: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown restart policy",
+			args:    arguments.Args{Restart: "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:  "device read rate",
+			args:  arguments.Args{DeviceReadBps: []string{"/dev/sda:1mb"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.BlkioDeviceReadBps },
+			want:  []EngineAPIThrottleDevice{{Path: "/dev/sda", Rate: 1048576}},
+		},
+		{
+			name:  "device write iops",
+			args:  arguments.Args{DeviceWriteIops: []string{"/dev/sda:300"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.BlkioDeviceWriteIOps },
+			want:  []EngineAPIThrottleDevice{{Path: "/dev/sda", Rate: 300}},
+		},
+		{
+			name:    "invalid device iops",
+			args:    arguments.Args{DeviceReadIops: []string{"/dev/sda:1mb"}},
+			wantErr: true,
+		},
+		{
+			name:  "blkio weight device",
+			args:  arguments.Args{BlkioWeightDevice: []string{"/dev/sda:200"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.BlkioWeightDevice },
+			want:  []EngineAPIWeightDevice{{Path: "/dev/sda", Weight: 200}},
+		},
+		{
+			name:  "device permissions",
+			args:  arguments.Args{Device: []string{"/dev/fuse", "/dev/sda:/dev/xvda:r"}},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.Devices },
+			want: []EngineAPIDevice{
+				{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"},
+				{PathOnHost: "/dev/sda", PathInContainer: "/dev/xvda", CgroupPermissions: "r"},
+			},
+		},
+		{
+			name: "capabilities",
+			args: arguments.Args{CapAdd: []string{"NET_ADMIN"}, CapDrop: []string{"ALL"}},
+			field: func(b *EngineAPIContainerCreate) interface{} {
+				return [][]string{b.HostConfig.CapAdd, b.HostConfig.CapDrop}
+			},
+			want: [][]string{{"NET_ADMIN"}, {"ALL"}},
+		},
+		{
+			name:  "entrypoint",
+			args:  arguments.Args{Entrypoint: "/bin/sh -c"},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.Entrypoint },
+			want:  []string{"/bin/sh", "-c"},
+		},
+		{
+			name:  "cleared entrypoint",
+			args:  arguments.Args{EntrypointCleared: true},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.Entrypoint },
+			want:  []string{""},
+		},
+		{
+			name:  "no entrypoint",
+			args:  arguments.Args{},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.Entrypoint },
+			want:  []string(nil),
+		},
+		{
+			name:  "cpus",
+			args:  arguments.Args{Cpus: 0.1},
+			field: func(b *EngineAPIContainerCreate) interface{} { return b.HostConfig.NanoCpus },
+			want:  int64(100000000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := withDefaults(tt.args)
+			if len(tt.args.Restart) > 0 {
+				args.Restart = tt.args.Restart
+			}
+			output, _, errs := ToEngineAPI("", args, makeArgs("alpine:3.20", tt.command...))
+			if tt.wantErr {
+				if errs.ErrorOrNil() == nil {
+					t.Errorf("ToEngineAPI() returned no error")
+				}
+				return
+			}
+			if errs.ErrorOrNil() != nil {
+				t.Fatalf("ToEngineAPI() returned errors: %v", errs)
+			}
+			if got := tt.field(output.(*EngineAPIContainerCreate)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToEngineAPI() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"docker-run-export/arguments"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+)
+
+// TestClearedEntrypoint verifies that an entrypoint cleared with
+// --entrypoint "" is written as an explicit override in every format that
+// can express it, and rejected by the ones that cannot, so the image
+// entrypoint never runs again silently.
+func TestClearedEntrypoint(t *testing.T) {
+	tests := []struct {
+		format  string
+		convert func(*arguments.Args) (interface{}, *multierror.Error, *multierror.Error)
+		marshal func(interface{}) ([]byte, error)
+		want    string
+		wantErr bool
+	}{
+		{
+			format: "compose",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToCompose("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalCompose(v.(*types.Project), "yaml") },
+			want:    "entrypoint:\n    - \"\"",
+		},
+		{
+			format: "ecs",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToECS("", c, makeArgs("alpine:3.20", "sh"), ECSOptions{})
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalECS(v.(*ECSTaskDefinition)) },
+			want:    "\"entryPoint\": [\n        \"\"\n      ]",
+		},
+		{
+			format: "nomad",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToNomad("", c, makeArgs("alpine:3.20", "sh"), NomadOptions{})
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalNomadHCL(v.(*NomadJob)) },
+			want:    "entrypoint = [\"\"]",
+		},
+		{
+			format: "engine-api",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToEngineAPI("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalEngineAPI(v.(*EngineAPIContainerCreate)) },
+			want:    "\"Entrypoint\": [\n    \"\"\n  ]",
+		},
+		{
+			format: "ansible",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToAnsible("", c, makeArgs("alpine:3.20", "sh"), AnsibleOptions{})
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalAnsible(v.(*AnsibleTasks)) },
+			want:    "entrypoint:\n    - \"\"",
+		},
+		{
+			format: "terraform-docker",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToTerraformDocker("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalTerraform(v.(*TerraformConfig)) },
+			want:    "entrypoint = [\"\"]",
+		},
+		{
+			format: "gitlab-ci",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToGitLabCI("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalGitLabCI(v.(*GitLabCIServices)) },
+			want:    "entrypoint:\n  - \"\"",
+		},
+		{
+			format: "quadlet",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToQuadlet("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalSystemdUnit(v.(*SystemdUnit)) },
+			want:    "Entrypoint=[]\n",
+		},
+		{
+			format: "systemd",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToSystemd("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalSystemdUnit(v.(*SystemdUnit)) },
+			want:    "run --entrypoint \"\" ",
+		},
+		{
+			format: "docker-run",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToDockerRun("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalDockerRun(v.(*DockerRunCommands)) },
+			want:    "docker run --entrypoint '' alpine:3.20 sh",
+		},
+		{
+			format: "swarm-service",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToSwarmService("", c, makeArgs("alpine:3.20", "sh"), SwarmOptions{Replicas: 1})
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalSwarmService(v.(*SwarmService)) },
+			want:    "--entrypoint '' ",
+		},
+		{
+			format: "kubernetes",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToKubernetes("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalKubernetes(v.(*KubernetesManifests)) },
+			want:    "command:\n        - sh\n",
+		},
+		{
+			format: "cloudrun",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToCloudRun("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalCloudRun(v.(*CloudRunService)) },
+			want:    "command:\n        - sh\n",
+		},
+		{
+			format: "aci",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToACI("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalACI(v.(*ACIContainerGroup)) },
+			want:    "command:\n      - sh\n",
+		},
+		{
+			format: "devcontainer",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToDevContainer("", c, makeArgs("alpine:3.20"))
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalDevContainer(v.(*DevContainer)) },
+			want:    "\"--entrypoint\",\n    \"\"",
+		},
+		{
+			format: "github-actions",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToGitHubActions("", c, makeArgs("alpine:3.20"), GitHubActionsOptions{})
+			},
+			marshal: func(v interface{}) ([]byte, error) { return MarshalGitHubActions(v.(*GitHubActionsJob)) },
+			want:    "options: --entrypoint \"\"",
+		},
+		{
+			format: "kubernetes without a command",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToKubernetes("", c, makeArgs("alpine:3.20"))
+			},
+			wantErr: true,
+		},
+		{
+			format: "fly",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToFly("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			wantErr: true,
+		},
+		{
+			format: "aws-batch",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToAWSBatch("", c, makeArgs("alpine:3.20", "sh"), ECSOptions{})
+			},
+			wantErr: true,
+		},
+		{
+			format: "apprunner",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToAppRunner("", c, makeArgs("public.ecr.aws/docker/library/alpine:3.20", "sh"))
+			},
+			wantErr: true,
+		},
+		{
+			format: "kamal",
+			convert: func(c *arguments.Args) (interface{}, *multierror.Error, *multierror.Error) {
+				return ToKamal("", c, makeArgs("alpine:3.20", "sh"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			args := withDefaults(arguments.Args{EntrypointCleared: true})
+			output, _, errs := tt.convert(args)
+			if tt.wantErr {
+				if errs.ErrorOrNil() == nil {
+					t.Errorf("%s conversion with a cleared entrypoint returned no error", tt.format)
+				}
+				return
+			}
+			if errs.ErrorOrNil() != nil {
+				t.Fatalf("%s conversion returned errors: %v", tt.format, errs)
+			}
+
+			out, err := tt.marshal(output)
+			if err != nil {
+				t.Fatalf("%s marshal returned error: %v", tt.format, err)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("%s output does not contain %q:\n%s", tt.format, tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// OutputFile represents a single file of a format that is written as a
+// directory tree, such as a Helm chart
+type OutputFile struct {
+	// Path holds the slash-separated path of the file, relative to the
+	// output directory
+	Path    string
+	Content []byte
+}
+
+// marshalOutputFiles marshals files to a multi-document stream, with a
+// comment holding the path of each file as in `helm template` output
+func marshalOutputFiles(root string, files []OutputFile) []byte {
+	var b strings.Builder
+	for _, file := range files {
+		b.WriteString("---\n")
+		b.WriteString(fmt.Sprintf("# Source: %s\n", path.Join(root, file.Path)))
+		b.Write(file.Content)
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,549 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// flyProcessName holds the name of the process group that runs the command
+const flyProcessName = "app"
+
+// flyCPUPresets holds the number of cpus of the shared-cpu-Nx machine presets
+var flyCPUPresets = []int{1, 2, 4, 8}
+
+// flyMemoryStepMB and flyMaxMemoryPerCPUMB hold the granularity of machine
+// memory and the most memory a shared cpu can be given
+const (
+	flyMemoryStepMB      = 256
+	flyMaxMemoryPerCPUMB = 2048
+)
+
+// flyFlags holds the docker run flags that are mapped to the fly.toml file
+var flyFlags = map[string]bool{
+	"cpus":                true,
+	"detach":              true,
+	"entrypoint":          true,
+	"env":                 true,
+	"expose":              true,
+	"health-cmd":          true,
+	"health-interval":     true,
+	"health-retries":      true,
+	"health-start-period": true,
+	"health-timeout":      true,
+	"memory":              true,
+	"name":                true,
+	"no-healthcheck":      true,
+	"platform":            true,
+	"publish":             true,
+	"restart":             true,
+	"stop-signal":         true,
+	"stop-timeout":        true,
+	"volume":              true,
+}
+
+// FlyConfig represents a Fly.io fly.toml app configuration
+type FlyConfig struct {
+	App         string
+	KillSignal  string
+	KillTimeout string
+	Image       string
+	Env         map[string]string
+	Processes   map[string]string
+	Mounts      []FlyMount
+	HTTPService *FlyHTTPService
+	Services    []FlyService
+	Checks      []FlyCheck
+	Restart     *FlyRestart
+	VM          *FlyVM
+}
+
+// FlyMount represents a [[mounts]] section
+type FlyMount struct {
+	Source      string
+	Destination string
+}
+
+// FlyHTTPService represents the [http_service] section
+type FlyHTTPService struct {
+	InternalPort int
+	ForceHTTPS   bool
+}
+
+// FlyService represents a [[services]] section
+type FlyService struct {
+	Protocol     string
+	InternalPort int
+	Ports        []FlyServicePort
+}
+
+// FlyServicePort represents a [[services.ports]] section
+type FlyServicePort struct {
+	Port int
+}
+
+// FlyCheck represents a [checks.NAME] section
+type FlyCheck struct {
+	Name        string
+	Type        string
+	Port        int
+	Method      string
+	Path        string
+	Interval    string
+	Timeout     string
+	GracePeriod string
+}
+
+// FlyRestart represents a [[restart]] section
+type FlyRestart struct {
+	Policy  string
+	Retries int
+}
+
+// FlyVM represents a [[vm]] section
+type FlyVM struct {
+	Size     string
+	MemoryMB int
+}
+
+// ToFly converts docker run arguments to a Fly.io fly.toml file
+func ToFly(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	app := projectName
+	if len(app) == 0 {
+		app = c.ContainerName
+	}
+	if len(app) == 0 {
+		app = imageName(arguments["image"].StringValue())
+	}
+
+	config := &FlyConfig{
+		App:   kubernetesName(app),
+		Image: arguments["image"].StringValue(),
+	}
+
+	// command -> processes
+	if len(arguments["command"].ListValue()) > 0 {
+		config.Processes = map[string]string{
+			flyProcessName: shellJoin(arguments["command"].ListValue()),
+		}
+	}
+
+	// unsupported: entrypoint
+	if len(c.Entrypoint) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --entrypoint property in fly.toml as the property is not supported, build an image with the entrypoint instead"))
+	} else if c.EntrypointCleared {
+		errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in fly.toml as the property is not supported, build an image without the entrypoint instead"))
+	}
+
+	// env -> env
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in fly.toml as passing through host environment variables is not supported, set it with fly secrets set", value))
+			continue
+		}
+		key, val := extractParts(value, "=")
+		if config.Env == nil {
+			config.Env = map[string]string{}
+		}
+		config.Env[key] = val
+	}
+
+	// platform: fly machines run linux/amd64 images
+	if len(c.Platform) > 0 && c.Platform != "linux/amd64" && c.Platform != "linux" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --platform %s in fly.toml as fly machines run linux/amd64 images", c.Platform))
+	}
+
+	// publish -> http_service for ports 80 and 443, services for the others
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			if len(p.HostIP) > 0 {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to bind port %d/%s to %s in fly.toml as services listen on the public addresses of the app", p.Target, p.Protocol, p.HostIP))
+			}
+
+			published := int(p.Target)
+			if len(p.Published) > 0 {
+				published, err = strconv.Atoi(p.Published)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: invalid host port %s", p.Published))
+					continue
+				}
+			}
+
+			if p.Protocol == "tcp" && (published == 80 || published == 443) {
+				if config.HTTPService == nil {
+					config.HTTPService = &FlyHTTPService{InternalPort: int(p.Target)}
+				}
+				if config.HTTPService.InternalPort == int(p.Target) {
+					if published == 443 {
+						config.HTTPService.ForceHTTPS = true
+					}
+					continue
+				}
+			}
+
+			config.Services = appendFlyService(config.Services, p.Protocol, int(p.Target), published)
+		}
+	}
+
+	// health-cmd -> checks
+	if len(c.HealthCmd) > 0 {
+		if c.NoHealthcheck {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+		} else {
+			check, err := flyCheck(c.HealthCmd, config)
+			if err != nil {
+				warnings = multierror.Append(warnings, err)
+			}
+			if check != nil {
+				for _, field := range []struct {
+					flag  string
+					value string
+					check *string
+				}{
+					{"--health-interval", c.HealthInterval, &check.Interval},
+					{"--health-timeout", c.HealthTimeout, &check.Timeout},
+					{"--health-start-period", c.HealthStartPeriod, &check.GracePeriod},
+				} {
+					if field.value == "0s" {
+						continue
+					}
+					if _, err := durationToSeconds(field.value); err != nil {
+						errs = multierror.Append(errs, fmt.Errorf("unable to parse %s flag to duration: %w", field.flag, err))
+						continue
+					}
+					*field.check = field.value
+				}
+				if c.HealthRetries > 0 {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set --health-retries property in fly.toml as the property is not supported"))
+				}
+				config.Checks = append(config.Checks, *check)
+			}
+		}
+	}
+
+	// cpus / memory -> vm
+	if c.Cpus > 0 || c.Memory > 0 {
+		vm, vmWarnings := flyVM(c.Cpus, c.Memory)
+		for _, warning := range vmWarnings {
+			warnings = multierror.Append(warnings, warning)
+		}
+		config.VM = vm
+	}
+
+	// restart -> restart
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, retries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			switch mode {
+			case "always", "unless-stopped":
+				config.Restart = &FlyRestart{Policy: "always"}
+			case "on-failure":
+				config.Restart = &FlyRestart{Policy: "on-failure", Retries: retries}
+			}
+		}
+	}
+
+	// stop-signal / stop-timeout -> kill_signal / kill_timeout
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		signal := strings.ToUpper(c.StopSignal)
+		if !strings.HasPrefix(signal, "SIG") {
+			signal = "SIG" + signal
+		}
+		config.KillSignal = signal
+	}
+	if c.StopTimeout > 0 {
+		config.KillTimeout = fmt.Sprintf("%ds", c.StopTimeout)
+	}
+
+	// volume -> mounts, as fly machines mount a single volume
+	for _, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) == 1 || !isNamedVolume(parts[0]) {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in fly.toml as only named volumes are supported", value))
+			continue
+		}
+		if len(config.Mounts) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in fly.toml as fly machines mount a single volume", value))
+			continue
+		}
+		if len(parts) == 3 && parts[2] == "ro" {
+			warnings = multierror.Append(warnings, fmt.Errorf("mounting --volume %s read-write in fly.toml as read-only volumes are not supported", value))
+		}
+		config.Mounts = append(config.Mounts, FlyMount{
+			Source:      strings.ReplaceAll(parts[0], "-", "_"),
+			Destination: parts[1],
+		})
+	}
+
+	// every other flag has no fly.toml equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if flyFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in fly.toml as the property is not supported", flag.Name))
+	}
+
+	return config, warnings, errs
+}
+
+// MarshalFly marshals a Fly.io app configuration to the fly.toml format
+func MarshalFly(config *FlyConfig) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "app = %s\n", flyQuote(config.App))
+	if len(config.KillSignal) > 0 {
+		fmt.Fprintf(&b, "kill_signal = %s\n", flyQuote(config.KillSignal))
+	}
+	if len(config.KillTimeout) > 0 {
+		fmt.Fprintf(&b, "kill_timeout = %s\n", flyQuote(config.KillTimeout))
+	}
+
+	b.WriteString("\n[build]\n")
+	fmt.Fprintf(&b, "  image = %s\n", flyQuote(config.Image))
+
+	if len(config.Env) > 0 {
+		b.WriteString("\n[env]\n")
+		for _, key := range sortedKeys(config.Env) {
+			fmt.Fprintf(&b, "  %s = %s\n", flyKey(key), flyQuote(config.Env[key]))
+		}
+	}
+
+	if len(config.Processes) > 0 {
+		b.WriteString("\n[processes]\n")
+		for _, key := range sortedKeys(config.Processes) {
+			fmt.Fprintf(&b, "  %s = %s\n", flyKey(key), flyQuote(config.Processes[key]))
+		}
+	}
+
+	for _, mount := range config.Mounts {
+		b.WriteString("\n[[mounts]]\n")
+		fmt.Fprintf(&b, "  source = %s\n", flyQuote(mount.Source))
+		fmt.Fprintf(&b, "  destination = %s\n", flyQuote(mount.Destination))
+	}
+
+	if config.HTTPService != nil {
+		b.WriteString("\n[http_service]\n")
+		fmt.Fprintf(&b, "  internal_port = %d\n", config.HTTPService.InternalPort)
+		fmt.Fprintf(&b, "  force_https = %t\n", config.HTTPService.ForceHTTPS)
+	}
+
+	for _, service := range config.Services {
+		b.WriteString("\n[[services]]\n")
+		fmt.Fprintf(&b, "  protocol = %s\n", flyQuote(service.Protocol))
+		fmt.Fprintf(&b, "  internal_port = %d\n", service.InternalPort)
+		for _, port := range service.Ports {
+			b.WriteString("\n  [[services.ports]]\n")
+			fmt.Fprintf(&b, "    port = %d\n", port.Port)
+		}
+	}
+
+	if len(config.Checks) > 0 {
+		b.WriteString("\n[checks]\n")
+		for _, check := range config.Checks {
+			fmt.Fprintf(&b, "  [checks.%s]\n", flyKey(check.Name))
+			fmt.Fprintf(&b, "    type = %s\n", flyQuote(check.Type))
+			fmt.Fprintf(&b, "    port = %d\n", check.Port)
+			if len(check.Method) > 0 {
+				fmt.Fprintf(&b, "    method = %s\n", flyQuote(check.Method))
+			}
+			if len(check.Path) > 0 {
+				fmt.Fprintf(&b, "    path = %s\n", flyQuote(check.Path))
+			}
+			if len(check.Interval) > 0 {
+				fmt.Fprintf(&b, "    interval = %s\n", flyQuote(check.Interval))
+			}
+			if len(check.Timeout) > 0 {
+				fmt.Fprintf(&b, "    timeout = %s\n", flyQuote(check.Timeout))
+			}
+			if len(check.GracePeriod) > 0 {
+				fmt.Fprintf(&b, "    grace_period = %s\n", flyQuote(check.GracePeriod))
+			}
+		}
+	}
+
+	if config.Restart != nil {
+		b.WriteString("\n[[restart]]\n")
+		fmt.Fprintf(&b, "  policy = %s\n", flyQuote(config.Restart.Policy))
+		if config.Restart.Retries > 0 {
+			fmt.Fprintf(&b, "  retries = %d\n", config.Restart.Retries)
+		}
+	}
+
+	if config.VM != nil {
+		b.WriteString("\n[[vm]]\n")
+		fmt.Fprintf(&b, "  size = %s\n", flyQuote(config.VM.Size))
+		if config.VM.MemoryMB > 0 {
+			fmt.Fprintf(&b, "  memory = %s\n", flyQuote(fmt.Sprintf("%dmb", config.VM.MemoryMB)))
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+// appendFlyService adds a public port to the service of the internal port,
+// adding the service when it does not exist yet
+func appendFlyService(services []FlyService, protocol string, internalPort int, port int) []FlyService {
+	for i, service := range services {
+		if service.Protocol != protocol || service.InternalPort != internalPort {
+			continue
+		}
+		for _, existing := range service.Ports {
+			if existing.Port == port {
+				return services
+			}
+		}
+		services[i].Ports = append(services[i].Ports, FlyServicePort{Port: port})
+		return services
+	}
+
+	return append(services, FlyService{
+		Protocol:     protocol,
+		InternalPort: internalPort,
+		Ports:        []FlyServicePort{{Port: port}},
+	})
+}
+
+// flyCheck converts a health check command to a fly.toml check. Fly only
+// runs http and tcp checks, so curl and wget commands against a local URL
+// become http checks and any other command becomes a tcp check of the first
+// published port.
+func flyCheck(healthCmd string, config *FlyConfig) (*FlyCheck, error) {
+	if port, path, ok := healthCheckURL(healthCmd); ok {
+		return &FlyCheck{
+			Name:   "health",
+			Type:   "http",
+			Port:   port,
+			Method: "get",
+			Path:   path,
+		}, nil
+	}
+
+	port := 0
+	if config.HTTPService != nil {
+		port = config.HTTPService.InternalPort
+	} else {
+		for _, service := range config.Services {
+			if service.Protocol == "tcp" {
+				port = service.InternalPort
+				break
+			}
+		}
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("unable to set --health-cmd property in fly.toml as checks only support http and tcp, and no tcp port is published")
+	}
+
+	return &FlyCheck{
+		Name: "health",
+		Type: "tcp",
+		Port: port,
+	}, fmt.Errorf("unable to run --health-cmd in fly.toml as checks only support http and tcp, checking tcp port %d instead", port)
+}
+
+// flyVM rounds cpus and memory to the closest shared-cpu-Nx machine preset
+// that fits them, and memory to a multiple of 256 MB
+func flyVM(cpus float32, memory int64) (*FlyVM, []error) {
+	var warnings []error
+
+	cpuIndex := len(flyCPUPresets) - 1
+	for i, preset := range flyCPUPresets {
+		if float64(cpus) <= float64(preset) {
+			cpuIndex = i
+			break
+		}
+	}
+	if cpus > 0 && float64(cpus) > float64(flyCPUPresets[len(flyCPUPresets)-1]) {
+		warnings = append(warnings, fmt.Errorf("unable to set --cpus %s in fly.toml as shared-cpu machines have at most %d cpus", strconv.FormatFloat(float64(cpus), 'f', -1, 32), flyCPUPresets[len(flyCPUPresets)-1]))
+	} else if cpus > 0 && float64(cpus) != float64(flyCPUPresets[cpuIndex]) {
+		warnings = append(warnings, fmt.Errorf("rounding --cpus %s up to the shared-cpu-%dx preset in fly.toml", strconv.FormatFloat(float64(cpus), 'f', -1, 32), flyCPUPresets[cpuIndex]))
+	}
+
+	memoryMB := 0
+	if memory > 0 {
+		memoryMB = int(math.Ceil(float64(memory)/(1024*1024*flyMemoryStepMB))) * flyMemoryStepMB
+		if int64(memoryMB)*1024*1024 != memory {
+			warnings = append(warnings, fmt.Errorf("rounding --memory %d up to %dmb in fly.toml as memory is allocated in steps of %dmb", memory, memoryMB, flyMemoryStepMB))
+		}
+
+		// shared cpus hold between 256mb and 2gb of memory each
+		fitIndex := cpuIndex
+		for fitIndex < len(flyCPUPresets)-1 && memoryMB > flyCPUPresets[fitIndex]*flyMaxMemoryPerCPUMB {
+			fitIndex++
+		}
+		if fitIndex != cpuIndex {
+			cpuIndex = fitIndex
+			warnings = append(warnings, fmt.Errorf("raising the machine size to shared-cpu-%dx in fly.toml to fit --memory %d", flyCPUPresets[cpuIndex], memory))
+		}
+		if maxMemoryMB := flyCPUPresets[cpuIndex] * flyMaxMemoryPerCPUMB; memoryMB > maxMemoryMB {
+			warnings = append(warnings, fmt.Errorf("lowering --memory %d to %dmb in fly.toml as shared-cpu machines have at most %dmb", memory, maxMemoryMB, maxMemoryMB))
+			memoryMB = maxMemoryMB
+		}
+		if minMemoryMB := flyCPUPresets[cpuIndex] * flyMemoryStepMB; memoryMB < minMemoryMB {
+			warnings = append(warnings, fmt.Errorf("raising --memory %d to %dmb in fly.toml as shared-cpu-%dx machines have at least %dmb", memory, minMemoryMB, flyCPUPresets[cpuIndex], minMemoryMB))
+			memoryMB = minMemoryMB
+		}
+	}
+
+	return &FlyVM{
+		Size:     fmt.Sprintf("shared-cpu-%dx", flyCPUPresets[cpuIndex]),
+		MemoryMB: memoryMB,
+	}, warnings
+}
+
+// flyKey returns a TOML key, quoting it when it is not a bare key
+func flyKey(key string) string {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return flyQuote(key)
+		}
+	}
+	if len(key) == 0 {
+		return `""`
+	}
+	return key
+}
+
+// flyQuote returns a value as a TOML basic string
+func flyQuote(value string) string {
+	var b strings.Builder
+	b.WriteString(`"`)
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(`"`)
+	return b.String()
+}
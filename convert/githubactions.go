@@ -0,0 +1,243 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// gitHubActionsInvalidIDChars matches the characters that are not allowed
+// in a GitHub Actions service id
+var gitHubActionsInvalidIDChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// gitHubActionsIgnoredFlags holds the docker run flags that are accepted
+// without a warning, as the runner sets them itself
+var gitHubActionsIgnoredFlags = map[string]bool{
+	"detach": true,
+	"name":   true,
+	"rm":     true,
+}
+
+// gitHubActionsUnsupportedFlags holds the docker run flags that cannot be
+// passed to the runner, mapped to the reason why
+var gitHubActionsUnsupportedFlags = map[string]string{
+	"env-file":      "the file is not available on the runner, set the variables with --env instead",
+	"link":          "containers of a job reach each other by service id",
+	"network":       "the runner attaches containers to the network of the job",
+	"network-alias": "containers of a job reach each other by service id",
+	"restart":       "the runner removes containers when the job ends",
+	"volumes-from":  "the runner names containers itself",
+}
+
+// GitHubActionsOptions holds the DRE flags that apply to the github-actions
+// format
+type GitHubActionsOptions struct {
+	// JobContainer holds the name of the exported container that runs the
+	// steps of the job, rather than running as a service container
+	JobContainer string
+}
+
+// GitHubActionsJob holds the container settings of a GitHub Actions job
+type GitHubActionsJob struct {
+	Container *GitHubActionsContainer            `yaml:"container,omitempty"`
+	Services  map[string]*GitHubActionsContainer `yaml:"services,omitempty"`
+}
+
+// GitHubActionsContainer represents a job container or a service container
+type GitHubActionsContainer struct {
+	Image   string            `yaml:"image"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	Ports   []string          `yaml:"ports,omitempty"`
+	Volumes []string          `yaml:"volumes,omitempty"`
+	Options string            `yaml:"options,omitempty"`
+}
+
+// ToGitHubActions converts docker run arguments to a GitHub Actions service
+// container, or to the job container when it is named by
+// --dre-github-job-container
+func ToGitHubActions(projectName string, c *arguments.Args, arguments map[string]command.Argument, gitHubOpts GitHubActionsOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	container, warnings, errs := toGitHubActionsContainer(c, arguments, name == gitHubOpts.JobContainer)
+	job, err := gitHubActionsJob([]string{name}, []*GitHubActionsContainer{container}, gitHubOpts)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	return job, warnings, errs
+}
+
+// ToGitHubActionsContainers converts several docker run invocations to the
+// containers of a single GitHub Actions job. Services reach each other by
+// service id, so references between the containers are not kept.
+func ToGitHubActionsContainers(projectName string, containers []Container, gitHubOpts GitHubActionsOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, err := containerNames(containers)
+	if err != nil {
+		return nil, nil, multierror.Append(errs, err)
+	}
+
+	gitHubContainers := []*GitHubActionsContainer{}
+	for i, container := range containers {
+		gitHubContainer, w, e := toGitHubActionsContainer(container.Args, container.Arguments, names[i] == gitHubOpts.JobContainer)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+		gitHubContainers = append(gitHubContainers, gitHubContainer)
+	}
+
+	job, err := gitHubActionsJob(names, gitHubContainers, gitHubOpts)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	return job, warnings, errs
+}
+
+// gitHubActionsJob returns the job holding the containers, keyed by a
+// service id derived from their names
+func gitHubActionsJob(names []string, containers []*GitHubActionsContainer, gitHubOpts GitHubActionsOptions) (*GitHubActionsJob, error) {
+	job := &GitHubActionsJob{}
+	for i, container := range containers {
+		if names[i] == gitHubOpts.JobContainer {
+			job.Container = container
+			continue
+		}
+
+		id := strings.Trim(gitHubActionsInvalidIDChars.ReplaceAllString(names[i], "-"), "-")
+		if len(id) == 0 {
+			id = "app"
+		}
+		if job.Services == nil {
+			job.Services = map[string]*GitHubActionsContainer{}
+		}
+		job.Services[id] = container
+	}
+
+	if len(gitHubOpts.JobContainer) > 0 && job.Container == nil {
+		return job, fmt.Errorf("unable to use %s as the job container as no exported container has that name", gitHubOpts.JobContainer)
+	}
+
+	return job, nil
+}
+
+// MarshalGitHubActions marshals the container settings of a GitHub Actions
+// job to YAML
+func MarshalGitHubActions(job *GitHubActionsJob) ([]byte, error) {
+	return yaml.Marshal(job)
+}
+
+// toGitHubActionsContainer converts docker run arguments to a GitHub Actions
+// container. Flags without a dedicated key are passed to the runner in the
+// options string.
+func toGitHubActionsContainer(c *arguments.Args, arguments map[string]command.Argument, isJobContainer bool) (*GitHubActionsContainer, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	target := "github actions service"
+	if isJobContainer {
+		target = "github actions job container"
+	}
+
+	container := &GitHubActionsContainer{
+		Image: arguments["image"].StringValue(),
+	}
+
+	// command: the runner starts services with the command of the image, and
+	// runs the job container with its own command
+	if len(arguments["command"].ListValue()) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set command in %s as the runner does not support overriding it, build an image with the command instead", target))
+	}
+
+	// env -> env
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("passing through --env %s in %s as the secret of the same name", value, target))
+			if container.Env == nil {
+				container.Env = map[string]string{}
+			}
+			container.Env[value] = fmt.Sprintf("${{ secrets.%s }}", value)
+			continue
+		}
+		key, val := extractParts(value, "=")
+		if container.Env == nil {
+			container.Env = map[string]string{}
+		}
+		container.Env[key] = val
+	}
+
+	// publish -> ports
+	container.Ports = append(container.Ports, c.Publish...)
+
+	// volume -> volumes
+	container.Volumes = append(container.Volumes, c.Volume...)
+
+	// every other flag -> options
+	options := []string{}
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		switch flag.Name {
+		case "env", "publish", "volume":
+			continue
+		}
+		if gitHubActionsIgnoredFlags[flag.Name] {
+			continue
+		}
+		if reason, ok := gitHubActionsUnsupportedFlags[flag.Name]; ok {
+			if !seen[flag.Name] {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in %s as %s", flag.Name, target, reason))
+			}
+			seen[flag.Name] = true
+			continue
+		}
+		if flag.Name == "entrypoint" && isJobContainer {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --entrypoint property in %s as the runner overrides it to keep the container running", target))
+			continue
+		}
+
+		for _, arg := range flag.Args() {
+			options = append(options, gitHubActionsQuote(arg))
+		}
+	}
+	container.Options = strings.Join(options, " ")
+
+	return container, warnings, errs
+}
+
+// gitHubActionsQuote quotes a single word of a docker options string. The
+// runner splits options on spaces outside of double quotes, and treats
+// backslashes as escapes only when they precede a double quote.
+func gitHubActionsQuote(value string) string {
+	if arguments.IsShellSafeWord(value) {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteString(`"`)
+	backslashes := 0
+	for _, r := range value {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, backslashes*2))
+	b.WriteString(`"`)
+	return b.String()
+}
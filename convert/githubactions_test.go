@@ -0,0 +1,26 @@
+package convert
+
+import "testing"
+
+// TestGitHubActionsQuote verifies that words are quoted the way the runner
+// splits the options string, escaping double quotes and the backslashes
+// that precede them.
+func TestGitHubActionsQuote(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"10s", "10s"},
+		{"pg_isready -U postgres", `"pg_isready -U postgres"`},
+		{`echo "hi"`, `"echo \"hi\""`},
+		{`C:\data dir\`, `"C:\data dir\\"`},
+		{`a\"b`, `"a\\\"b"`},
+		{"", `""`},
+	}
+
+	for _, tt := range tests {
+		if got := gitHubActionsQuote(tt.value); got != tt.want {
+			t.Errorf("gitHubActionsQuote(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
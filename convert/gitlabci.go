@@ -0,0 +1,169 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// gitLabCIFlags holds the docker run flags that are mapped to the service
+var gitLabCIFlags = map[string]bool{
+	"detach":        true,
+	"entrypoint":    true,
+	"env":           true,
+	"name":          true,
+	"network-alias": true,
+	"platform":      true,
+	"pull":          true,
+	"rm":            true,
+	"user":          true,
+}
+
+// gitLabCIPullPolicies maps docker run --pull values to service pull policies
+var gitLabCIPullPolicies = map[string]string{
+	"always":  "always",
+	"missing": "if-not-present",
+	"never":   "never",
+}
+
+// GitLabCIServices holds the services of a GitLab CI job
+type GitLabCIServices struct {
+	Services []GitLabCIService `yaml:"services"`
+}
+
+// GitLabCIService represents a single entry of a GitLab CI services list
+type GitLabCIService struct {
+	Name       string                 `yaml:"name"`
+	Alias      string                 `yaml:"alias,omitempty"`
+	Entrypoint []string               `yaml:"entrypoint,omitempty"`
+	Command    []string               `yaml:"command,omitempty"`
+	Variables  map[string]string      `yaml:"variables,omitempty"`
+	PullPolicy string                 `yaml:"pull_policy,omitempty"`
+	Docker     *GitLabCIServiceDocker `yaml:"docker,omitempty"`
+}
+
+// GitLabCIServiceDocker represents the options of a service that only apply
+// to the docker executor
+type GitLabCIServiceDocker struct {
+	Platform string `yaml:"platform,omitempty"`
+	User     string `yaml:"user,omitempty"`
+}
+
+// ToGitLabCI converts docker run arguments to a GitLab CI service
+func ToGitLabCI(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	service, warnings, errs := toGitLabCIService(name, c, arguments)
+	return &GitLabCIServices{Services: []GitLabCIService{service}}, warnings, errs
+}
+
+// ToGitLabCIContainers converts several docker run invocations to the
+// services of a single GitLab CI job
+func ToGitLabCIContainers(projectName string, containers []Container) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, err := containerNames(containers)
+	if err != nil {
+		return nil, nil, multierror.Append(errs, err)
+	}
+
+	services := &GitLabCIServices{}
+	for i, container := range containers {
+		service, w, e := toGitLabCIService(names[i], container.Args, container.Arguments)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+		services.Services = append(services.Services, service)
+	}
+
+	return services, warnings, errs
+}
+
+// MarshalGitLabCI marshals the services of a GitLab CI job to YAML
+func MarshalGitLabCI(services *GitLabCIServices) ([]byte, error) {
+	return yaml.Marshal(services)
+}
+
+// toGitLabCIService converts docker run arguments to a GitLab CI service,
+// reachable from the job by the container name and its network aliases
+func toGitLabCIService(name string, c *arguments.Args, arguments map[string]command.Argument) (GitLabCIService, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	service := GitLabCIService{
+		Name:    arguments["image"].StringValue(),
+		Alias:   strings.Join(append([]string{name}, c.NetworkAlias...), ","),
+		Command: arguments["command"].ListValue(),
+	}
+
+	// entrypoint -> entrypoint
+	if len(c.Entrypoint) > 0 {
+		service.Entrypoint = []string{c.Entrypoint}
+	} else if c.EntrypointCleared {
+		service.Entrypoint = []string{""}
+	}
+
+	// env -> variables
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in gitlab ci service as passing through host environment variables is not supported, define it as a ci/cd variable, which is passed to services", value))
+			continue
+		}
+		key, val := extractParts(value, "=")
+		if service.Variables == nil {
+			service.Variables = map[string]string{}
+		}
+		service.Variables[key] = val
+	}
+
+	// pull -> pull_policy
+	if len(c.Pull) > 0 && c.Pull != "missing" {
+		policy, ok := gitLabCIPullPolicies[c.Pull]
+		if ok {
+			service.PullPolicy = policy
+		} else {
+			errs = multierror.Append(errs, fmt.Errorf("invalid --pull value: %s", c.Pull))
+		}
+	}
+
+	// platform / user -> docker
+	if len(c.Platform) > 0 || len(c.User) > 0 {
+		service.Docker = &GitLabCIServiceDocker{
+			Platform: c.Platform,
+			User:     c.User,
+		}
+	}
+
+	// publish: services are reachable from the job by alias on the ports
+	// they listen on
+	if len(c.Publish) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --publish property in gitlab ci service as services are reachable from the job by alias, connect to %s on the container port instead", name))
+	}
+
+	// health-cmd: the runner waits for the exposed ports of services
+	if len(c.HealthCmd) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --health-cmd property in gitlab ci service as the runner waits for the exposed ports of services instead"))
+	}
+
+	// every other flag has no gitlab ci equivalent
+	seen := map[string]bool{
+		"health-cmd": true,
+		"publish":    true,
+	}
+	for _, flag := range dockerRunFlags(c) {
+		if gitLabCIFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in gitlab ci service as the property is not supported", flag.Name))
+	}
+
+	return service, warnings, errs
+}
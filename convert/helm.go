@@ -0,0 +1,336 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// helmChartVersion holds the version of a generated chart
+const helmChartVersion = "0.1.0"
+
+// helmDeploymentTemplate holds templates/deployment.yaml. Every setting is
+// read from .Values, and blocks are skipped when their value is empty.
+const helmDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app.kubernetes.io/name: {{ .Chart.Name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicaCount }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .Chart.Name }}
+      app.kubernetes.io/instance: {{ .Release.Name }}
+  template:
+    metadata:
+      {{- with .Values.podAnnotations }}
+      annotations:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      labels:
+        app.kubernetes.io/name: {{ .Chart.Name }}
+        app.kubernetes.io/instance: {{ .Release.Name }}
+        {{- with .Values.podLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+    spec:
+      {{- with .Values.podSecurityContext }}
+      securityContext:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .Values.hostAliases }}
+      hostAliases:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .Values.nodeSelector }}
+      nodeSelector:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}{{ if .Values.image.digest }}@{{ .Values.image.digest }}{{ else }}:{{ .Values.image.tag | default .Chart.AppVersion }}{{ end }}"
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          {{- with .Values.command }}
+          command:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.args }}
+          args:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.workingDir }}
+          workingDir: {{ . }}
+          {{- end }}
+          {{- with .Values.env }}
+          env:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.ports }}
+          ports:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.resources }}
+          resources:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.startupProbe }}
+          startupProbe:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.livenessProbe }}
+          livenessProbe:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.readinessProbe }}
+          readinessProbe:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.securityContext }}
+          securityContext:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .Values.volumeMounts }}
+          volumeMounts:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+      {{- with .Values.volumes }}
+      volumes:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+`
+
+// helmServiceTemplate holds templates/service.yaml, which is only rendered
+// when service.enabled is set
+const helmServiceTemplate = `{{- if .Values.service.enabled }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app.kubernetes.io/name: {{ .Chart.Name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+spec:
+  type: {{ .Values.service.type }}
+  selector:
+    app.kubernetes.io/name: {{ .Chart.Name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+  ports:
+    {{- toYaml .Values.service.ports | nindent 4 }}
+{{- end }}
+`
+
+// HelmChart holds the files of a Helm chart, keyed by their path relative
+// to the chart directory
+type HelmChart struct {
+	Name  string
+	Files []OutputFile
+}
+
+// HelmChartMetadata represents a Chart.yaml file
+type HelmChartMetadata struct {
+	APIVersion  string `yaml:"apiVersion"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Type        string `yaml:"type"`
+	Version     string `yaml:"version"`
+	AppVersion  string `yaml:"appVersion,omitempty"`
+}
+
+// HelmValues represents a values.yaml file. Probes and security contexts
+// are held as interfaces so that unset ones are written as empty maps.
+type HelmValues struct {
+	ReplicaCount       int                       `yaml:"replicaCount"`
+	Image              HelmImageValues           `yaml:"image"`
+	Command            []string                  `yaml:"command"`
+	Args               []string                  `yaml:"args"`
+	WorkingDir         string                    `yaml:"workingDir"`
+	Env                []KubernetesEnvVar        `yaml:"env"`
+	Ports              []KubernetesContainerPort `yaml:"ports"`
+	Service            HelmServiceValues         `yaml:"service"`
+	Resources          KubernetesResources       `yaml:"resources"`
+	StartupProbe       interface{}               `yaml:"startupProbe"`
+	LivenessProbe      interface{}               `yaml:"livenessProbe"`
+	ReadinessProbe     interface{}               `yaml:"readinessProbe"`
+	SecurityContext    interface{}               `yaml:"securityContext"`
+	PodSecurityContext interface{}               `yaml:"podSecurityContext"`
+	PodAnnotations     map[string]string         `yaml:"podAnnotations"`
+	PodLabels          map[string]string         `yaml:"podLabels"`
+	HostAliases        []KubernetesHostAlias     `yaml:"hostAliases"`
+	NodeSelector       map[string]string         `yaml:"nodeSelector"`
+	Volumes            []KubernetesVolume        `yaml:"volumes"`
+	VolumeMounts       []KubernetesVolumeMount   `yaml:"volumeMounts"`
+}
+
+// HelmImageValues represents the image section of values.yaml
+type HelmImageValues struct {
+	Repository string `yaml:"repository"`
+	Tag        string `yaml:"tag"`
+	Digest     string `yaml:"digest,omitempty"`
+	PullPolicy string `yaml:"pullPolicy"`
+}
+
+// HelmServiceValues represents the service section of values.yaml
+type HelmServiceValues struct {
+	Enabled bool                    `yaml:"enabled"`
+	Type    string                  `yaml:"type"`
+	Ports   []KubernetesServicePort `yaml:"ports"`
+}
+
+// ToHelm converts docker run arguments to a Helm chart scaffold. The
+// manifests are built with ToKubernetes, and their settings are moved to
+// values.yaml so that the templates only reference .Values.
+func ToHelm(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	output, warnings, errs := ToKubernetes(projectName, c, arguments)
+	manifests := output.(*KubernetesManifests)
+	podSpec := manifests.Deployment.Spec.Template.Spec
+	container := podSpec.Containers[0]
+
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+	name = kubernetesName(name)
+
+	repository, tag, digest := helmImage(arguments["image"].StringValue())
+	values := HelmValues{
+		ReplicaCount: manifests.Deployment.Spec.Replicas,
+		Image: HelmImageValues{
+			Repository: repository,
+			Tag:        tag,
+			Digest:     digest,
+			PullPolicy: container.ImagePullPolicy,
+		},
+		Command:            container.Command,
+		Args:               container.Args,
+		WorkingDir:         container.WorkingDir,
+		Env:                container.Env,
+		Ports:              container.Ports,
+		Service:            HelmServiceValues{Type: "ClusterIP"},
+		StartupProbe:       map[string]interface{}{},
+		LivenessProbe:      map[string]interface{}{},
+		ReadinessProbe:     map[string]interface{}{},
+		SecurityContext:    map[string]interface{}{},
+		PodSecurityContext: map[string]interface{}{},
+		PodAnnotations:     manifests.Deployment.Spec.Template.Metadata.Annotations,
+		HostAliases:        podSpec.HostAliases,
+		NodeSelector:       podSpec.NodeSelector,
+		Volumes:            podSpec.Volumes,
+		VolumeMounts:       container.VolumeMounts,
+	}
+	if len(values.Image.PullPolicy) == 0 {
+		values.Image.PullPolicy = "IfNotPresent"
+	}
+	if container.Resources != nil {
+		values.Resources = *container.Resources
+	}
+	if container.StartupProbe != nil {
+		values.StartupProbe = container.StartupProbe
+	}
+	if container.LivenessProbe != nil {
+		values.LivenessProbe = container.LivenessProbe
+	}
+	if container.ReadinessProbe != nil {
+		values.ReadinessProbe = container.ReadinessProbe
+	}
+	if container.SecurityContext != nil {
+		values.SecurityContext = container.SecurityContext
+	}
+	if podSpec.SecurityContext != nil {
+		values.PodSecurityContext = podSpec.SecurityContext
+	}
+	for key, value := range manifests.Deployment.Spec.Template.Metadata.Labels {
+		if key == "app.kubernetes.io/name" {
+			continue
+		}
+		if values.PodLabels == nil {
+			values.PodLabels = map[string]string{}
+		}
+		values.PodLabels[key] = value
+	}
+	if manifests.Service != nil {
+		values.Service.Enabled = true
+		values.Service.Ports = manifests.Service.Spec.Ports
+	}
+
+	// the scaffold templates only cover the common pod settings
+	for _, field := range []struct {
+		name  string
+		isSet bool
+	}{
+		{"hostname", len(podSpec.Hostname) > 0},
+		{"hostNetwork", podSpec.HostNetwork},
+		{"hostPID", podSpec.HostPID},
+		{"hostIPC", podSpec.HostIPC},
+		{"dnsPolicy", len(podSpec.DNSPolicy) > 0},
+		{"dnsConfig", podSpec.DNSConfig != nil},
+		{"runtimeClassName", len(podSpec.RuntimeClassName) > 0},
+		{"terminationGracePeriodSeconds", podSpec.TerminationGracePeriodSeconds != nil},
+		{"stdin", container.Stdin},
+		{"tty", container.TTY},
+	} {
+		if field.isSet {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set %s in helm chart as the templates do not support it, add it to templates/deployment.yaml", field.name))
+		}
+	}
+
+	chartMetadata, err := yaml.Marshal(HelmChartMetadata{
+		APIVersion:  "v2",
+		Name:        name,
+		Description: fmt.Sprintf("A Helm chart for %s", name),
+		Type:        "application",
+		Version:     helmChartVersion,
+		AppVersion:  tag,
+	})
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	valuesFile, err := yaml.Marshal(values)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	chart := &HelmChart{
+		Name: name,
+		Files: []OutputFile{
+			{Path: "Chart.yaml", Content: chartMetadata},
+			{Path: "values.yaml", Content: valuesFile},
+			{Path: "templates/deployment.yaml", Content: []byte(helmDeploymentTemplate)},
+			{Path: "templates/service.yaml", Content: []byte(helmServiceTemplate)},
+		},
+	}
+
+	return chart, warnings, errs
+}
+
+// MarshalHelm marshals the files of a Helm chart to a multi-document stream,
+// with a comment holding the path of each file as in `helm template` output
+func MarshalHelm(chart *HelmChart) ([]byte, error) {
+	return marshalOutputFiles(chart.Name, chart.Files), nil
+}
+
+// helmImage splits an image reference into its repository, tag and digest.
+// The tag defaults to latest when neither a tag nor a digest is set.
+func helmImage(image string) (string, string, string) {
+	repository, digest := extractParts(image, "@")
+
+	tag := ""
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		tag = repository[i+1:]
+		repository = repository[:i]
+	}
+	if len(tag) == 0 && len(digest) == 0 {
+		tag = "latest"
+	}
+
+	return repository, tag, digest
+}
@@ -0,0 +1,123 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// volumeNameRegexp matches the names docker accepts for local volumes
+var volumeNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// BoolToPtr returns the pointer to a bool
+func BoolToPtr(b bool) *bool {
+	return &b
+}
+
+// IntToPtr returns the pointer to an int
+func IntToPtr(i int) *int {
+	return &i
+}
+
+// Int64ToPtr returns the pointer to an int64
+func Int64ToPtr(i int64) *int64 {
+	return &i
+}
+
+// isNamedVolume returns whether the source of a --volume flag is a docker
+// volume name rather than a host path
+func isNamedVolume(source string) bool {
+	return volumeNameRegexp.MatchString(source)
+}
+
+// healthCheckURL returns the port and path requested by a curl or wget
+// health check against the container itself, e.g.,
+// curl -f http://localhost:8080/health
+func healthCheckURL(healthCmd string) (int, string, bool) {
+	words, err := shellwords.Parse(healthCmd)
+	if err != nil || len(words) == 0 || (words[0] != "curl" && words[0] != "wget") {
+		return 0, "", false
+	}
+
+	for _, word := range words[1:] {
+		if !strings.HasPrefix(word, "http://") && !strings.HasPrefix(word, "https://") {
+			continue
+		}
+		u, err := url.Parse(word)
+		if err != nil {
+			return 0, "", false
+		}
+		host := u.Hostname()
+		if host != "localhost" && host != "127.0.0.1" && host != "0.0.0.0" {
+			return 0, "", false
+		}
+
+		port := 80
+		if u.Scheme == "https" {
+			port = 443
+		}
+		if len(u.Port()) > 0 {
+			port, _ = strconv.Atoi(u.Port())
+		}
+		path := u.EscapedPath()
+		if len(path) == 0 {
+			path = "/"
+		}
+		if len(u.RawQuery) > 0 {
+			path += "?" + u.RawQuery
+		}
+
+		return port, path, true
+	}
+
+	return 0, "", false
+}
+
+// cronMacros holds the schedule shorthands accepted by both Kubernetes
+// CronJobs and Nomad periodic jobs
+var cronMacros = map[string]bool{
+	"@yearly":   true,
+	"@annually": true,
+	"@monthly":  true,
+	"@weekly":   true,
+	"@daily":    true,
+	"@midnight": true,
+	"@hourly":   true,
+}
+
+// validateCronSchedule checks that a --dre-schedule value is either a cron
+// macro or a standard five field cron expression
+func validateCronSchedule(schedule string) error {
+	if cronMacros[schedule] || len(strings.Fields(schedule)) == 5 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid --dre-schedule value %q: expected a cron expression with five fields, e.g., \"0 3 * * *\", or a macro such as @daily", schedule)
+}
+
+// noNewPrivileges parses a --security-opt no-new-privileges value in the
+// key=value or legacy key:value form, as docker does. The second value is
+// false when the option is not no-new-privileges.
+func noNewPrivileges(opt string) (bool, bool, error) {
+	separator := "="
+	if !strings.Contains(opt, "=") {
+		separator = ":"
+	}
+	key, value := extractParts(opt, separator)
+	if key != "no-new-privileges" {
+		return false, false, nil
+	}
+	if len(value) == 0 {
+		return true, true, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, true, fmt.Errorf("invalid --security-opt %s: %w", opt, err)
+	}
+	return enabled, true, nil
+}
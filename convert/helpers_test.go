@@ -0,0 +1,34 @@
+package convert
+
+import "testing"
+
+// TestNoNewPrivileges verifies that the value of a no-new-privileges
+// security option is read in both the key=value and legacy key:value forms.
+func TestNoNewPrivileges(t *testing.T) {
+	tests := []struct {
+		opt         string
+		wantEnabled bool
+		wantOk      bool
+		wantErr     bool
+	}{
+		{"no-new-privileges", true, true, false},
+		{"no-new-privileges=true", true, true, false},
+		{"no-new-privileges:true", true, true, false},
+		{"no-new-privileges=false", false, true, false},
+		{"no-new-privileges:false", false, true, false},
+		{"no-new-privileges=maybe", false, true, true},
+		{"seccomp=unconfined", false, false, false},
+		{"label:disable", false, false, false},
+	}
+
+	for _, tt := range tests {
+		enabled, ok, err := noNewPrivileges(tt.opt)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("noNewPrivileges(%q) error = %v, want error %v", tt.opt, err, tt.wantErr)
+			continue
+		}
+		if enabled != tt.wantEnabled || ok != tt.wantOk {
+			t.Errorf("noNewPrivileges(%q) = %v, %v, want %v, %v", tt.opt, enabled, ok, tt.wantEnabled, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// kamalSecretEnvKey matches environment variable names that look like
+// credentials, which are moved to env.secret
+var kamalSecretEnvKey = regexp.MustCompile(`(?i)(^|_)(PASS|PASSWD|PASSWORD|SECRET|TOKEN|KEY|APIKEY|CREDENTIALS?|PRIVATE|AUTH|CERT|DSN)(_|$)`)
+
+// kamalRoleName holds the name of the role that runs the container
+const kamalRoleName = "web"
+
+// kamalPlaceholderHost holds the documentation address written as the host of
+// the role, to be replaced with the addresses of the servers
+const kamalPlaceholderHost = "192.0.2.1"
+
+// kamalManagedFlags holds the docker run flags that kamal sets itself, mapped
+// to the reason why they cannot be passed as options
+var kamalManagedFlags = map[string]string{
+	"env-file": "kamal writes its own env file from the env section",
+	"network":  "kamal attaches containers to the kamal network",
+	"rm":       "kamal runs containers with --restart unless-stopped",
+}
+
+// kamalMappedFlags holds the docker run flags that are mapped to keys of the
+// deploy.yml file rather than passed as options
+var kamalMappedFlags = map[string]bool{
+	"detach":     true,
+	"env":        true,
+	"label":      true,
+	"log-driver": true,
+	"log-opt":    true,
+	"name":       true,
+	"platform":   true,
+	"publish":    true,
+	"restart":    true,
+	"volume":     true,
+}
+
+// KamalConfig represents a kamal config/deploy.yml file
+type KamalConfig struct {
+	Service  string               `yaml:"service"`
+	Image    string               `yaml:"image"`
+	Servers  map[string]KamalRole `yaml:"servers"`
+	Proxy    *KamalProxy          `yaml:"proxy,omitempty"`
+	Registry KamalRegistry        `yaml:"registry"`
+	Builder  KamalBuilder         `yaml:"builder"`
+	Env      *KamalEnv            `yaml:"env,omitempty"`
+	Volumes  []string             `yaml:"volumes,omitempty"`
+	Labels   map[string]string    `yaml:"labels,omitempty"`
+	Logging  *KamalLogging        `yaml:"logging,omitempty"`
+}
+
+// KamalRole represents a role of the servers section
+type KamalRole struct {
+	Hosts   []string      `yaml:"hosts"`
+	Cmd     string        `yaml:"cmd,omitempty"`
+	Proxy   *bool         `yaml:"proxy,omitempty"`
+	Options yaml.MapSlice `yaml:"options,omitempty"`
+}
+
+// KamalProxy represents the proxy section
+type KamalProxy struct {
+	AppPort int `yaml:"app_port"`
+}
+
+// KamalRegistry represents the registry section
+type KamalRegistry struct {
+	Server   string   `yaml:"server,omitempty"`
+	Username []string `yaml:"username"`
+	Password []string `yaml:"password"`
+}
+
+// KamalBuilder represents the builder section
+type KamalBuilder struct {
+	Arch string `yaml:"arch"`
+}
+
+// KamalEnv represents the env section
+type KamalEnv struct {
+	Clear  map[string]string `yaml:"clear,omitempty"`
+	Secret []string          `yaml:"secret,omitempty"`
+}
+
+// KamalLogging represents the logging section
+type KamalLogging struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// ToKamal converts docker run arguments to a kamal config/deploy.yml file
+func ToKamal(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	service := projectName
+	if len(service) == 0 {
+		service = c.ContainerName
+	}
+	if len(service) == 0 {
+		service = imageName(arguments["image"].StringValue())
+	}
+
+	config := &KamalConfig{
+		Service: kubernetesName(service),
+		Registry: KamalRegistry{
+			Username: []string{"KAMAL_REGISTRY_USERNAME"},
+			Password: []string{"KAMAL_REGISTRY_PASSWORD"},
+		},
+		Builder: KamalBuilder{
+			Arch: "amd64",
+		},
+	}
+
+	// image -> registry.server and image, without the tag
+	server, image, tag := kamalImage(arguments["image"].StringValue())
+	config.Registry.Server = server
+	config.Image = image
+	if len(tag) > 0 && tag != "latest" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set image tag %s in kamal deploy.yml as kamal tags images with the deployed version", tag))
+	}
+
+	role := KamalRole{
+		Hosts: []string{kamalPlaceholderHost},
+	}
+	if len(arguments["command"].ListValue()) > 0 {
+		role.Cmd = shellJoin(arguments["command"].ListValue())
+	}
+
+	// platform -> builder.arch
+	if len(c.Platform) > 0 {
+		platformOS, arch := extractParts(c.Platform, "/")
+		arch, _ = extractParts(arch, "/")
+		if platformOS != "linux" || (arch != "amd64" && arch != "arm64") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --platform %s in kamal deploy.yml as only linux/amd64 and linux/arm64 are supported", c.Platform))
+		} else {
+			config.Builder.Arch = arch
+		}
+	}
+
+	// env -> env.clear, or env.secret for credentials and host variables
+	if len(c.Env) > 0 {
+		env := &KamalEnv{}
+		for _, value := range c.Env {
+			key, val := extractParts(value, "=")
+			if !strings.Contains(value, "=") || kamalSecretEnvKey.MatchString(key) {
+				env.Secret = append(env.Secret, key)
+				continue
+			}
+			if env.Clear == nil {
+				env.Clear = map[string]string{}
+			}
+			env.Clear[key] = val
+		}
+		if len(env.Secret) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("moved --env %s to env.secret in kamal deploy.yml, set their values in .kamal/secrets", strings.Join(env.Secret, ", ")))
+		}
+		config.Env = env
+	}
+
+	// volume -> volumes
+	config.Volumes = c.Volume
+
+	// label -> labels
+	if len(c.Label) > 0 {
+		config.Labels = map[string]string{}
+		for _, value := range c.Label {
+			key, val := extractParts(value, "=")
+			config.Labels[key] = val
+		}
+	}
+
+	// log-driver / log-opt -> logging
+	if len(c.LogDriver) > 0 || len(c.LogOpt) > 0 {
+		logging := &KamalLogging{
+			Driver: c.LogDriver,
+		}
+		if len(c.LogOpt) > 0 {
+			logging.Options = map[string]string{}
+			for _, value := range c.LogOpt {
+				key, val := extractParts(value, "=")
+				logging.Options[key] = val
+			}
+		}
+		config.Logging = logging
+	}
+
+	// restart is always unless-stopped
+	if len(c.Restart) > 0 && c.Restart != "no" && c.Restart != "always" && c.Restart != "unless-stopped" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in kamal deploy.yml as kamal runs containers with --restart unless-stopped", c.Restart))
+	}
+
+	// publish -> proxy.app_port for the first port, options for the others
+	publish := []string{}
+	for _, value := range c.Publish {
+		if config.Proxy != nil {
+			publish = append(publish, value)
+			continue
+		}
+
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		if len(parsed) != 1 || parsed[0].Protocol != "tcp" {
+			publish = append(publish, value)
+			continue
+		}
+		config.Proxy = &KamalProxy{
+			AppPort: int(parsed[0].Target),
+		}
+	}
+	if config.Proxy == nil {
+		disabled := false
+		role.Proxy = &disabled
+	}
+
+	// every other flag is passed to docker run as an option
+	options := yaml.MapSlice{}
+	optionIndex := map[string]int{}
+	addOption := func(name string, value string) {
+		if i, ok := optionIndex[name]; ok {
+			switch existing := options[i].Value.(type) {
+			case string:
+				options[i].Value = []string{existing, value}
+			case []string:
+				options[i].Value = append(existing, value)
+			}
+			return
+		}
+		optionIndex[name] = len(options)
+		options = append(options, yaml.MapItem{Key: name, Value: value})
+	}
+	for _, flag := range dockerRunFlags(c) {
+		if kamalMappedFlags[flag.Name] {
+			continue
+		}
+		if reason, ok := kamalManagedFlags[flag.Name]; ok {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in kamal deploy.yml as %s", flag.Name, reason))
+			continue
+		}
+		if flag.Name == "entrypoint" && len(flag.Value) == 0 {
+			// kamal drops the value of an empty option
+			errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in kamal deploy.yml as options cannot be empty, build an image without the entrypoint instead"))
+			continue
+		}
+		if flag.Bool {
+			if len(flag.Value) > 0 {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s=%s property in kamal deploy.yml as options cannot disable flags", flag.Name, flag.Value))
+				continue
+			}
+			optionIndex[flag.Name] = len(options)
+			options = append(options, yaml.MapItem{Key: flag.Name, Value: true})
+			continue
+		}
+		addOption(flag.Name, flag.Value)
+	}
+	for _, value := range publish {
+		addOption("publish", value)
+	}
+	if len(options) > 0 {
+		role.Options = options
+	}
+
+	config.Servers = map[string]KamalRole{
+		kamalRoleName: role,
+	}
+
+	return config, warnings, errs
+}
+
+// MarshalKamal marshals a kamal configuration to YAML format
+func MarshalKamal(config *KamalConfig) ([]byte, error) {
+	return yaml.Marshal(config)
+}
+
+// kamalImage splits an image reference into the registry server, the image
+// name and the tag or digest
+func kamalImage(ref string) (string, string, string) {
+	server := ""
+	name := ref
+	if i := strings.Index(ref, "/"); i >= 0 {
+		host := ref[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			server = host
+			name = ref[i+1:]
+		}
+	}
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		return server, name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return server, name[:i], name[i+1:]
+	}
+	return server, name, ""
+}
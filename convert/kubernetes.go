@@ -0,0 +1,1255 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mattn/go-shellwords"
+	"gopkg.in/yaml.v2"
+)
+
+// KubernetesManifests holds the Kubernetes objects generated for a container
+type KubernetesManifests struct {
+	Deployment *KubernetesDeployment
+	Service    *KubernetesService
+}
+
+// KubernetesObjectMeta represents the metadata of a Kubernetes object
+type KubernetesObjectMeta struct {
+	Name        string            `yaml:"name,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// KubernetesDeployment represents an apps/v1 Deployment
+type KubernetesDeployment struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   KubernetesObjectMeta     `yaml:"metadata"`
+	Spec       KubernetesDeploymentSpec `yaml:"spec"`
+}
+
+// KubernetesDeploymentSpec represents the spec of a Deployment
+type KubernetesDeploymentSpec struct {
+	Replicas int                       `yaml:"replicas,omitempty"`
+	Selector KubernetesLabelSelector   `yaml:"selector"`
+	Template KubernetesPodTemplateSpec `yaml:"template"`
+}
+
+// KubernetesLabelSelector represents a label selector
+type KubernetesLabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+// KubernetesPodTemplateSpec represents the pod template of a workload
+type KubernetesPodTemplateSpec struct {
+	Metadata KubernetesObjectMeta `yaml:"metadata"`
+	Spec     KubernetesPodSpec    `yaml:"spec"`
+}
+
+// KubernetesPodSpec represents the spec of a pod
+type KubernetesPodSpec struct {
+	Hostname                      string                        `yaml:"hostname,omitempty"`
+	HostNetwork                   bool                          `yaml:"hostNetwork,omitempty"`
+	HostPID                       bool                          `yaml:"hostPID,omitempty"`
+	HostIPC                       bool                          `yaml:"hostIPC,omitempty"`
+	HostAliases                   []KubernetesHostAlias         `yaml:"hostAliases,omitempty"`
+	DNSPolicy                     string                        `yaml:"dnsPolicy,omitempty"`
+	DNSConfig                     *KubernetesPodDNSConfig       `yaml:"dnsConfig,omitempty"`
+	NodeSelector                  map[string]string             `yaml:"nodeSelector,omitempty"`
+	RuntimeClassName              string                        `yaml:"runtimeClassName,omitempty"`
+	RestartPolicy                 string                        `yaml:"restartPolicy,omitempty"`
+	TerminationGracePeriodSeconds *int                          `yaml:"terminationGracePeriodSeconds,omitempty"`
+	SecurityContext               *KubernetesPodSecurityContext `yaml:"securityContext,omitempty"`
+	Containers                    []KubernetesContainer         `yaml:"containers"`
+	Volumes                       []KubernetesVolume            `yaml:"volumes,omitempty"`
+}
+
+// KubernetesHostAlias represents an /etc/hosts entry added to a pod
+type KubernetesHostAlias struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// KubernetesPodDNSConfig represents the DNS configuration of a pod
+type KubernetesPodDNSConfig struct {
+	Nameservers []string                       `yaml:"nameservers,omitempty"`
+	Searches    []string                       `yaml:"searches,omitempty"`
+	Options     []KubernetesPodDNSConfigOption `yaml:"options,omitempty"`
+}
+
+// KubernetesPodDNSConfigOption represents a resolver option of a pod
+type KubernetesPodDNSConfigOption struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value,omitempty"`
+}
+
+// KubernetesPodSecurityContext represents the pod-level security context
+type KubernetesPodSecurityContext struct {
+	SupplementalGroups []int              `yaml:"supplementalGroups,omitempty"`
+	Sysctls            []KubernetesSysctl `yaml:"sysctls,omitempty"`
+}
+
+// KubernetesSysctl represents a namespaced sysctl set on a pod
+type KubernetesSysctl struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// KubernetesContainer represents a container within a pod
+type KubernetesContainer struct {
+	Name            string                     `yaml:"name"`
+	Image           string                     `yaml:"image"`
+	ImagePullPolicy string                     `yaml:"imagePullPolicy,omitempty"`
+	Command         []string                   `yaml:"command,omitempty"`
+	Args            []string                   `yaml:"args,omitempty"`
+	WorkingDir      string                     `yaml:"workingDir,omitempty"`
+	Env             []KubernetesEnvVar         `yaml:"env,omitempty"`
+	EnvFrom         []KubernetesEnvFromSource  `yaml:"envFrom,omitempty"`
+	Ports           []KubernetesContainerPort  `yaml:"ports,omitempty"`
+	Resources       *KubernetesResources       `yaml:"resources,omitempty"`
+	VolumeMounts    []KubernetesVolumeMount    `yaml:"volumeMounts,omitempty"`
+	StartupProbe    *KubernetesProbe           `yaml:"startupProbe,omitempty"`
+	LivenessProbe   *KubernetesProbe           `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *KubernetesProbe           `yaml:"readinessProbe,omitempty"`
+	SecurityContext *KubernetesSecurityContext `yaml:"securityContext,omitempty"`
+	Stdin           bool                       `yaml:"stdin,omitempty"`
+	TTY             bool                       `yaml:"tty,omitempty"`
+}
+
+// KubernetesEnvVar represents an environment variable of a container
+type KubernetesEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// KubernetesEnvFromSource represents a source of environment variables of
+// a container
+type KubernetesEnvFromSource struct {
+	ConfigMapRef *KubernetesConfigMapEnvSource `yaml:"configMapRef,omitempty"`
+}
+
+// KubernetesConfigMapEnvSource represents a ConfigMap whose keys are set as
+// environment variables
+type KubernetesConfigMapEnvSource struct {
+	Name string `yaml:"name"`
+}
+
+// KubernetesContainerPort represents a port exposed by a container
+type KubernetesContainerPort struct {
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+// KubernetesResources represents the resource limits and requests of a container
+type KubernetesResources struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+// KubernetesVolumeMount represents a volume mounted into a container
+type KubernetesVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// KubernetesProbe represents a liveness or readiness probe
+type KubernetesProbe struct {
+	Exec                *KubernetesExecAction    `yaml:"exec,omitempty"`
+	HTTPGet             *KubernetesHTTPGetAction `yaml:"httpGet,omitempty"`
+	InitialDelaySeconds int                      `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int                      `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int                      `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int                      `yaml:"failureThreshold,omitempty"`
+}
+
+// KubernetesExecAction represents a command run inside a container
+type KubernetesExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+// KubernetesHTTPGetAction represents an HTTP GET request sent to a container
+type KubernetesHTTPGetAction struct {
+	Path string `yaml:"path,omitempty"`
+	Port int    `yaml:"port,omitempty"`
+}
+
+// KubernetesSecurityContext represents the container-level security context
+type KubernetesSecurityContext struct {
+	Capabilities             *KubernetesCapabilities    `yaml:"capabilities,omitempty"`
+	Privileged               *bool                      `yaml:"privileged,omitempty"`
+	ReadOnlyRootFilesystem   *bool                      `yaml:"readOnlyRootFilesystem,omitempty"`
+	AllowPrivilegeEscalation *bool                      `yaml:"allowPrivilegeEscalation,omitempty"`
+	RunAsUser                *int                       `yaml:"runAsUser,omitempty"`
+	RunAsGroup               *int                       `yaml:"runAsGroup,omitempty"`
+	SeccompProfile           *KubernetesSecurityProfile `yaml:"seccompProfile,omitempty"`
+	AppArmorProfile          *KubernetesSecurityProfile `yaml:"appArmorProfile,omitempty"`
+}
+
+// KubernetesCapabilities represents the Linux capabilities added to or
+// dropped from a container
+type KubernetesCapabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+// KubernetesSecurityProfile represents a seccomp or AppArmor profile
+type KubernetesSecurityProfile struct {
+	Type string `yaml:"type"`
+}
+
+// KubernetesVolume represents a volume of a pod
+type KubernetesVolume struct {
+	Name                  string                                 `yaml:"name"`
+	HostPath              *KubernetesHostPathVolumeSource        `yaml:"hostPath,omitempty"`
+	EmptyDir              *KubernetesEmptyDirVolumeSource        `yaml:"emptyDir,omitempty"`
+	PersistentVolumeClaim *KubernetesPersistentVolumeClaimSource `yaml:"persistentVolumeClaim,omitempty"`
+}
+
+// KubernetesHostPathVolumeSource represents a directory on the node
+type KubernetesHostPathVolumeSource struct {
+	Path string `yaml:"path"`
+}
+
+// KubernetesEmptyDirVolumeSource represents a scratch directory that lives
+// as long as the pod
+type KubernetesEmptyDirVolumeSource struct {
+	Medium    string `yaml:"medium,omitempty"`
+	SizeLimit string `yaml:"sizeLimit,omitempty"`
+}
+
+// KubernetesPersistentVolumeClaimSource represents a reference to a
+// PersistentVolumeClaim
+type KubernetesPersistentVolumeClaimSource struct {
+	ClaimName string `yaml:"claimName"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// KubernetesService represents a v1 Service
+type KubernetesService struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   KubernetesObjectMeta  `yaml:"metadata"`
+	Spec       KubernetesServiceSpec `yaml:"spec"`
+}
+
+// KubernetesServiceSpec represents the spec of a Service
+type KubernetesServiceSpec struct {
+	Selector map[string]string       `yaml:"selector"`
+	Ports    []KubernetesServicePort `yaml:"ports"`
+}
+
+// KubernetesServicePort represents a port exposed by a Service
+type KubernetesServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol,omitempty"`
+}
+
+// kubernetesInvalidNameChars matches characters that are not allowed in the
+// name of a Kubernetes object
+var kubernetesInvalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// kubernetesPod holds the pod template shared by the Kubernetes workloads,
+// along with the ports to expose through a Service
+type kubernetesPod struct {
+	Name         string
+	Template     KubernetesPodTemplateSpec
+	ServicePorts []KubernetesServicePort
+}
+
+// ToKubernetes converts docker run arguments to a Kubernetes Deployment and,
+// when ports are published or exposed, a Service
+func ToKubernetes(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	pod, warnings, errs := toKubernetesPod(projectName, c, arguments)
+
+	// unsupported: detach
+	if c.Detach {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --detach property in kubernetes manifest as the property is not supported"))
+	}
+
+	// restart: deployments always restart their containers, and `no` is
+	// the docker default so it is not worth a warning
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, _, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else if mode != "always" && mode != "unless-stopped" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in kubernetes manifest as deployments always restart containers", c.Restart))
+		}
+	}
+
+	// unsupported: rm
+	if c.Rm {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --rm property in kubernetes manifest as the property is not supported"))
+	}
+
+	selector := map[string]string{
+		"app.kubernetes.io/name": pod.Name,
+	}
+	manifests := &KubernetesManifests{
+		Deployment: &KubernetesDeployment{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Metadata: KubernetesObjectMeta{
+				Name:   pod.Name,
+				Labels: selector,
+			},
+			Spec: KubernetesDeploymentSpec{
+				Replicas: 1,
+				Selector: KubernetesLabelSelector{MatchLabels: selector},
+				Template: pod.Template,
+			},
+		},
+	}
+
+	if len(pod.ServicePorts) > 0 {
+		manifests.Service = &KubernetesService{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Metadata: KubernetesObjectMeta{
+				Name:   pod.Name,
+				Labels: selector,
+			},
+			Spec: KubernetesServiceSpec{
+				Selector: selector,
+				Ports:    pod.ServicePorts,
+			},
+		}
+	}
+
+	return manifests, warnings, errs
+}
+
+// toKubernetesPod converts docker run arguments to the pod template of a
+// Kubernetes workload. Flags whose meaning depends on the workload, such as
+// --restart and --rm, are left to the caller.
+func toKubernetesPod(projectName string, c *arguments.Args, arguments map[string]command.Argument) (kubernetesPod, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	containerName := "app"
+	if len(c.ContainerName) > 0 {
+		containerName = kubernetesName(c.ContainerName)
+	}
+
+	name := containerName
+	if len(projectName) > 0 {
+		name = kubernetesName(projectName)
+	}
+
+	container := KubernetesContainer{
+		Name: containerName,
+	}
+	podSpec := KubernetesPodSpec{}
+	podMeta := KubernetesObjectMeta{
+		Labels: map[string]string{
+			"app.kubernetes.io/name": name,
+		},
+	}
+	var servicePorts []KubernetesServicePort
+
+	// add-host -> spec.hostAliases
+	for _, hostMap := range c.AddHost {
+		parts := strings.SplitN(hostMap, ":", 2)
+		if len(parts) != 2 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --add-host flag: invalid value %s", hostMap))
+			continue
+		}
+
+		found := false
+		for i := range podSpec.HostAliases {
+			if podSpec.HostAliases[i].IP == parts[1] {
+				podSpec.HostAliases[i].Hostnames = append(podSpec.HostAliases[i].Hostnames, parts[0])
+				found = true
+			}
+		}
+		if !found {
+			podSpec.HostAliases = append(podSpec.HostAliases, KubernetesHostAlias{
+				IP:        parts[1],
+				Hostnames: []string{parts[0]},
+			})
+		}
+	}
+
+	// annotation -> pod annotations
+	if len(c.Annotation) > 0 {
+		if podMeta.Annotations == nil {
+			podMeta.Annotations = map[string]string{}
+		}
+		for _, annotation := range c.Annotation {
+			key, value := extractParts(annotation, "=")
+			podMeta.Annotations[key] = value
+		}
+	}
+
+	// unsupported: attach
+	if len(c.Attach) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --attach property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: blkio-weight
+	if c.BlkioWeight != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --blkio-weight property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: blkio-weight-device
+	if len(c.BlkioWeightDevice) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --blkio-weight-device property in kubernetes manifest as the property is not supported"))
+	}
+
+	// cap-add / cap-drop -> securityContext.capabilities
+	if len(c.CapAdd) > 0 || len(c.CapDrop) > 0 {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &KubernetesSecurityContext{}
+		}
+		container.SecurityContext.Capabilities = &KubernetesCapabilities{
+			Add:  kubernetesCapabilities(c.CapAdd),
+			Drop: kubernetesCapabilities(c.CapDrop),
+		}
+	}
+
+	// unsupported: cgroupns
+	if len(c.Cgroupns) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cgroupns property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cgroup-parent
+	if len(c.CgroupParent) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cgroup-parent property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cidfile
+	if len(c.Cidfile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cidfile property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cpu-period
+	if c.CpuPeriod > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpu-period property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cpu-quota
+	if c.CpuQuota > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpu-quota property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cpu-rt-period
+	if c.CpuRtPeriod > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpu-rt-period property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cpu-rt-runtime
+	if c.CpuRtRuntime > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpu-rt-runtime property in kubernetes manifest as the property is not supported"))
+	}
+
+	// cpus -> resources.limits.cpu
+	if c.Cpus > 0 {
+		container.Resources = kubernetesResources(container.Resources)
+		container.Resources.Limits["cpu"] = strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32)
+	}
+
+	// cpu-shares -> resources.requests.cpu (1024 shares = 1 CPU)
+	if c.CpuShares > 0 {
+		container.Resources = kubernetesResources(container.Resources)
+		container.Resources.Requests["cpu"] = fmt.Sprintf("%dm", c.CpuShares*1000/1024)
+	}
+
+	// unsupported: cpuset-cpus
+	if len(c.CpusetCpus) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpuset-cpus property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: cpuset-mems
+	if len(c.CpusetMems) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cpuset-mems property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: detach-keys
+	if len(c.DetachKeys) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --detach-keys property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device
+	if len(c.Device) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device-cgroup-rule
+	if len(c.DeviceCgroupRule) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device-cgroup-rule property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device-read-bps
+	if len(c.DeviceReadBps) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device-read-bps property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device-read-iops
+	if len(c.DeviceReadIops) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device-read-iops property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device-write-bps
+	if len(c.DeviceWriteBps) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device-write-bps property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: device-write-iops
+	if len(c.DeviceWriteIops) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --device-write-iops property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: disable-content-trust
+	if !c.DisableContentTrust {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --disable-content-trust property in kubernetes manifest as the property is not supported"))
+	}
+
+	// dns -> spec.dnsConfig.nameservers with dnsPolicy None
+	if len(c.Dns) > 0 {
+		if podSpec.DNSConfig == nil {
+			podSpec.DNSConfig = &KubernetesPodDNSConfig{}
+		}
+		podSpec.DNSPolicy = "None"
+		podSpec.DNSConfig.Nameservers = c.Dns
+	}
+
+	// dns-option -> spec.dnsConfig.options
+	if len(c.DnsOption) > 0 {
+		if podSpec.DNSConfig == nil {
+			podSpec.DNSConfig = &KubernetesPodDNSConfig{}
+		}
+		for _, option := range c.DnsOption {
+			key, value := extractParts(option, ":")
+			podSpec.DNSConfig.Options = append(podSpec.DNSConfig.Options, KubernetesPodDNSConfigOption{
+				Name:  key,
+				Value: value,
+			})
+		}
+	}
+
+	// dns-search -> spec.dnsConfig.searches
+	if len(c.DnsSearch) > 0 {
+		if podSpec.DNSConfig == nil {
+			podSpec.DNSConfig = &KubernetesPodDNSConfig{}
+		}
+		podSpec.DNSConfig.Searches = c.DnsSearch
+	}
+
+	// unsupported: domainname
+	if len(c.Domainname) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --domainname property in kubernetes manifest as the property is not supported"))
+	}
+
+	// entrypoint -> command
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			container.Command = args
+		}
+	}
+
+	// env -> env
+	for _, env := range c.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in kubernetes manifest as passing through host environment variables is not supported", env))
+			continue
+		}
+		container.Env = append(container.Env, KubernetesEnvVar{
+			Name:  parts[0],
+			Value: parts[1],
+		})
+	}
+
+	// unsupported: env-file
+	if len(c.EnvFile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env-file property in kubernetes manifest as the property is not supported"))
+	}
+
+	// expose -> container ports and service ports
+	for _, value := range c.Expose {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --expose flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			container.Ports, servicePorts = addKubernetesPort(container.Ports, servicePorts, int(p.Target), int(p.Target), p.Protocol)
+		}
+	}
+
+	// gpus -> resources.limits.<vendor>.com/gpu
+	if len(c.Gpus) > 0 {
+		device, err := parseDockerGpus(c.Gpus)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			vendor, _ := extractParts(device.Name, "/")
+			container.Resources = kubernetesResources(container.Resources)
+			container.Resources.Limits[fmt.Sprintf("%s.com/gpu", vendor)] = strconv.FormatUint(device.Count, 10)
+		}
+	}
+
+	// group-add -> spec.securityContext.supplementalGroups
+	for _, group := range c.GroupAdd {
+		gid, err := strconv.Atoi(group)
+		if err != nil {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --group-add %s in kubernetes manifest as only numeric group ids are supported", group))
+			continue
+		}
+		if podSpec.SecurityContext == nil {
+			podSpec.SecurityContext = &KubernetesPodSecurityContext{}
+		}
+		podSpec.SecurityContext.SupplementalGroups = append(podSpec.SecurityContext.SupplementalGroups, gid)
+	}
+
+	// health-cmd / health-* -> livenessProbe and readinessProbe
+	if len(c.HealthCmd) > 0 && !c.NoHealthcheck {
+		probe := &KubernetesProbe{
+			Exec: &KubernetesExecAction{
+				Command: []string{"/bin/sh", "-c", c.HealthCmd},
+			},
+		}
+
+		if c.HealthInterval != "0s" {
+			seconds, err := durationToSeconds(c.HealthInterval)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-interval flag to duration: %w", err))
+			} else {
+				probe.PeriodSeconds = seconds
+			}
+		}
+
+		if c.HealthTimeout != "0s" {
+			seconds, err := durationToSeconds(c.HealthTimeout)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-timeout flag to duration: %w", err))
+			} else {
+				probe.TimeoutSeconds = seconds
+			}
+		}
+
+		if c.HealthStartPeriod != "0s" {
+			seconds, err := durationToSeconds(c.HealthStartPeriod)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --health-start-period flag to duration: %w", err))
+			} else {
+				probe.InitialDelaySeconds = seconds
+			}
+		}
+
+		if c.HealthRetries > 0 {
+			probe.FailureThreshold = int(c.HealthRetries)
+		}
+
+		readinessProbe := *probe
+		container.LivenessProbe = probe
+		container.ReadinessProbe = &readinessProbe
+	} else if len(c.HealthCmd) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+	}
+
+	// hostname -> spec.hostname
+	if len(c.Hostname) > 0 {
+		podSpec.Hostname = c.Hostname
+	}
+
+	// unsupported: init
+	if c.Init {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --init property in kubernetes manifest as the property is not supported"))
+	}
+
+	// interactive -> stdin
+	if c.Interactive {
+		container.Stdin = true
+	}
+
+	// unsupported: ip
+	if len(c.Ip) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --ip property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: ip6
+	if len(c.Ip6) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --ip6 property in kubernetes manifest as the property is not supported"))
+	}
+
+	// ipc -> spec.hostIPC
+	if c.Ipc == "host" {
+		podSpec.HostIPC = true
+	} else if len(c.Ipc) > 0 && c.Ipc != "private" && c.Ipc != "shareable" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --ipc %s in kubernetes manifest as only host is supported", c.Ipc))
+	}
+
+	// unsupported: isolation
+	if len(c.Isolation) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --isolation property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: kernel-memory
+	if c.KernelMemory != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --kernel-memory property in kubernetes manifest as the property is not supported"))
+	}
+
+	// label -> pod annotations (label values are not restricted in docker)
+	if len(c.Label) > 0 {
+		if podMeta.Annotations == nil {
+			podMeta.Annotations = map[string]string{}
+		}
+		for _, label := range c.Label {
+			key, value := extractParts(label, "=")
+			podMeta.Annotations[key] = value
+		}
+	}
+
+	// unsupported: label-file
+	if len(c.LabelFile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --label-file property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: link
+	if len(c.Link) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --link property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: link-local-ip
+	if len(c.LinkLocalIP) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --link-local-ip property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: log-driver
+	if len(c.LogDriver) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --log-driver property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: log-opt
+	if len(c.LogOpt) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --log-opt property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: mac-address
+	if len(c.Mac) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --mac-address property in kubernetes manifest as the property is not supported"))
+	}
+
+	// memory -> resources.limits.memory
+	if c.Memory > 0 {
+		container.Resources = kubernetesResources(container.Resources)
+		container.Resources.Limits["memory"] = strconv.FormatInt(c.Memory, 10)
+	}
+
+	// memory-reservation -> resources.requests.memory
+	if c.MemoryReservation > 0 {
+		container.Resources = kubernetesResources(container.Resources)
+		container.Resources.Requests["memory"] = strconv.FormatInt(c.MemoryReservation, 10)
+	}
+
+	// unsupported: memory-swap
+	if c.MemorySwap != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --memory-swap property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: memory-swappiness
+	if c.MemorySwappiness > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --memory-swappiness property in kubernetes manifest as the property is not supported"))
+	}
+
+	// mount -> volumes and volumeMounts
+	for i, value := range c.Mount {
+		data := map[string]string{}
+		for _, part := range strings.Split(value, ",") {
+			k, v := extractParts(part, "=")
+			data[k] = v
+		}
+
+		mountType := data["type"]
+		if len(mountType) == 0 {
+			mountType = "volume"
+		}
+		var source, target string
+		for _, key := range []string{"src", "source"} {
+			if v, ok := data[key]; ok {
+				source = v
+			}
+		}
+		for _, key := range []string{"dst", "destination", "target"} {
+			if v, ok := data[key]; ok {
+				target = v
+			}
+		}
+		if len(target) == 0 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --mount flag: missing target in %s", value))
+			continue
+		}
+
+		readOnly := false
+		for _, key := range []string{"readonly", "ro"} {
+			if v, ok := data[key]; ok {
+				readOnly = v == "" || v == "true" || v == "1"
+			}
+		}
+
+		volume := KubernetesVolume{Name: fmt.Sprintf("mount-%d", i)}
+		switch mountType {
+		case "bind":
+			volume.HostPath = &KubernetesHostPathVolumeSource{Path: source}
+		case "volume":
+			if len(source) > 0 {
+				volume.PersistentVolumeClaim = &KubernetesPersistentVolumeClaimSource{ClaimName: source}
+			} else {
+				volume.EmptyDir = &KubernetesEmptyDirVolumeSource{}
+			}
+		case "tmpfs":
+			volume.EmptyDir = &KubernetesEmptyDirVolumeSource{Medium: "Memory"}
+			if size, ok := data["tmpfs-size"]; ok {
+				bytes, err := toSize(size)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("unable to parse --mount flag due to invalid tmpfs-size value: %w", err))
+				} else {
+					volume.EmptyDir.SizeLimit = strconv.FormatInt(bytes, 10)
+				}
+			}
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --mount type=%s in kubernetes manifest as the mount type is not supported", mountType))
+			continue
+		}
+
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, KubernetesVolumeMount{
+			Name:      volume.Name,
+			MountPath: target,
+			ReadOnly:  readOnly,
+		})
+	}
+
+	// network -> spec.hostNetwork
+	if c.Network == "host" {
+		podSpec.HostNetwork = true
+	} else if len(c.Network) > 0 && c.Network != "bridge" && c.Network != "default" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network %s in kubernetes manifest as only host is supported", c.Network))
+	}
+
+	// unsupported: network-alias
+	if len(c.NetworkAlias) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: oom-kill-disable
+	if c.OomKillDisable {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --oom-kill-disable property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: oom-score-adj
+	if c.OomScore != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --oom-score-adj property in kubernetes manifest as the property is not supported"))
+	}
+
+	// pid -> spec.hostPID
+	if c.Pid == "host" {
+		podSpec.HostPID = true
+	} else if len(c.Pid) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pid %s in kubernetes manifest as only host is supported", c.Pid))
+	}
+
+	// unsupported: pids-limit
+	if c.PidsLimit != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pids-limit property in kubernetes manifest as the property is not supported"))
+	}
+
+	// platform -> spec.nodeSelector
+	if len(c.Platform) > 0 {
+		os, arch := extractParts(c.Platform, "/")
+		arch, _ = extractParts(arch, "/")
+		podSpec.NodeSelector = map[string]string{
+			"kubernetes.io/os": strings.ToLower(os),
+		}
+		if len(arch) > 0 {
+			podSpec.NodeSelector["kubernetes.io/arch"] = strings.ToLower(arch)
+		}
+	}
+
+	// privileged -> securityContext.privileged
+	if c.Privileged {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &KubernetesSecurityContext{}
+		}
+		container.SecurityContext.Privileged = BoolToPtr(true)
+	}
+
+	// publish -> container ports and service ports
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			port := int(p.Target)
+			if len(p.Published) > 0 {
+				published, err := strconv.Atoi(p.Published)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish host port: %w", err))
+					continue
+				}
+				if published > 0 {
+					port = published
+				}
+			}
+			if len(p.HostIP) > 0 {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set host ip %s of --publish %s in kubernetes manifest as the property is not supported", p.HostIP, value))
+			}
+			container.Ports, servicePorts = addKubernetesPort(container.Ports, servicePorts, port, int(p.Target), p.Protocol)
+		}
+	}
+
+	// unsupported: publish-all
+	if c.PublishAll {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --publish-all property in kubernetes manifest as the property is not supported"))
+	}
+
+	// pull -> imagePullPolicy
+	switch c.Pull {
+	case "", "missing":
+		// IfNotPresent is the default for tagged images
+	case "always":
+		container.ImagePullPolicy = "Always"
+	case "never":
+		container.ImagePullPolicy = "Never"
+	default:
+		warnings = multierror.Append(warnings, fmt.Errorf("unknown --pull value %q; ignoring", c.Pull))
+	}
+
+	// read-only -> securityContext.readOnlyRootFilesystem
+	if c.ReadOnly {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &KubernetesSecurityContext{}
+		}
+		container.SecurityContext.ReadOnlyRootFilesystem = BoolToPtr(true)
+	}
+
+	// runtime -> spec.runtimeClassName
+	if len(c.Runtime) > 0 {
+		podSpec.RuntimeClassName = c.Runtime
+	}
+
+	// security-opt -> securityContext
+	for _, opt := range c.SecurityOpt {
+		key, value := extractParts(opt, "=")
+		if enabled, ok, err := noNewPrivileges(opt); ok {
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			if container.SecurityContext == nil {
+				container.SecurityContext = &KubernetesSecurityContext{}
+			}
+			container.SecurityContext.AllowPrivilegeEscalation = BoolToPtr(!enabled)
+		} else if key == "seccomp" && value == "unconfined" {
+			if container.SecurityContext == nil {
+				container.SecurityContext = &KubernetesSecurityContext{}
+			}
+			container.SecurityContext.SeccompProfile = &KubernetesSecurityProfile{Type: "Unconfined"}
+		} else if key == "apparmor" && value == "unconfined" {
+			if container.SecurityContext == nil {
+				container.SecurityContext = &KubernetesSecurityContext{}
+			}
+			container.SecurityContext.AppArmorProfile = &KubernetesSecurityProfile{Type: "Unconfined"}
+		} else {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --security-opt %s in kubernetes manifest as the option is not supported", opt))
+		}
+	}
+
+	// shm-size -> memory-backed emptyDir mounted at /dev/shm
+	if c.ShmSize != 0 {
+		podSpec.Volumes = append(podSpec.Volumes, KubernetesVolume{
+			Name: "dshm",
+			EmptyDir: &KubernetesEmptyDirVolumeSource{
+				Medium:    "Memory",
+				SizeLimit: strconv.Itoa(c.ShmSize),
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, KubernetesVolumeMount{
+			Name:      "dshm",
+			MountPath: "/dev/shm",
+		})
+	}
+
+	// unsupported: sig-proxy
+	if !c.SigProxy {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --sig-proxy property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: stop-signal
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --stop-signal property in kubernetes manifest as the property is not supported"))
+	}
+
+	// stop-timeout -> spec.terminationGracePeriodSeconds
+	if c.StopTimeout > 0 {
+		podSpec.TerminationGracePeriodSeconds = IntToPtr(c.StopTimeout)
+	}
+
+	// unsupported: storage-opt
+	if len(c.StorageOpt) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --storage-opt property in kubernetes manifest as the property is not supported"))
+	}
+
+	// sysctl -> spec.securityContext.sysctls
+	if len(c.Sysctl) > 0 {
+		if podSpec.SecurityContext == nil {
+			podSpec.SecurityContext = &KubernetesPodSecurityContext{}
+		}
+		for _, key := range sortedKeys(c.Sysctl) {
+			podSpec.SecurityContext.Sysctls = append(podSpec.SecurityContext.Sysctls, KubernetesSysctl{
+				Name:  key,
+				Value: c.Sysctl[key],
+			})
+		}
+	}
+
+	// tmpfs -> memory-backed emptyDir volumes
+	for i, value := range c.Tmpfs {
+		target, options := extractParts(value, ":")
+		volume := KubernetesVolume{
+			Name:     fmt.Sprintf("tmpfs-%d", i),
+			EmptyDir: &KubernetesEmptyDirVolumeSource{Medium: "Memory"},
+		}
+		for _, option := range strings.Split(options, ",") {
+			key, size := extractParts(option, "=")
+			if key != "size" {
+				continue
+			}
+			bytes, err := parseTmpfsSize(size)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --tmpfs flag size: %w", err))
+			} else {
+				volume.EmptyDir.SizeLimit = strconv.FormatInt(bytes, 10)
+			}
+		}
+
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, KubernetesVolumeMount{
+			Name:      volume.Name,
+			MountPath: target,
+		})
+	}
+
+	// tty -> tty
+	if c.Tty {
+		container.TTY = true
+	}
+
+	// unsupported: ulimit
+	if len(c.Ulimit) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --ulimit property in kubernetes manifest as the property is not supported"))
+	}
+
+	// user -> securityContext.runAsUser / runAsGroup
+	if len(c.User) > 0 {
+		user, group := extractParts(c.User, ":")
+		uid, err := strconv.Atoi(user)
+		if err != nil {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --user %s in kubernetes manifest as only numeric user ids are supported", c.User))
+		} else {
+			if container.SecurityContext == nil {
+				container.SecurityContext = &KubernetesSecurityContext{}
+			}
+			container.SecurityContext.RunAsUser = IntToPtr(uid)
+
+			if len(group) > 0 {
+				gid, err := strconv.Atoi(group)
+				if err != nil {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set group of --user %s in kubernetes manifest as only numeric group ids are supported", c.User))
+				} else {
+					container.SecurityContext.RunAsGroup = IntToPtr(gid)
+				}
+			}
+		}
+	}
+
+	// unsupported: userns
+	if len(c.Userns) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --userns property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: uts
+	if len(c.Uts) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --uts property in kubernetes manifest as the property is not supported"))
+	}
+
+	// volume -> hostPath, persistentVolumeClaim or emptyDir volumes
+	for i, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		volume := KubernetesVolume{Name: fmt.Sprintf("volume-%d", i)}
+		mount := KubernetesVolumeMount{Name: volume.Name}
+
+		if len(parts) == 1 {
+			volume.EmptyDir = &KubernetesEmptyDirVolumeSource{}
+			mount.MountPath = parts[0]
+		} else {
+			if isNamedVolume(parts[0]) {
+				volume.PersistentVolumeClaim = &KubernetesPersistentVolumeClaimSource{ClaimName: parts[0]}
+			} else {
+				volume.HostPath = &KubernetesHostPathVolumeSource{Path: parts[0]}
+			}
+			mount.MountPath = parts[1]
+
+			if len(parts) == 3 {
+				if parts[2] == "ro" {
+					mount.ReadOnly = true
+				} else if parts[2] != "rw" {
+					errs = multierror.Append(errs, fmt.Errorf("unable to parse --volume flag as volume: invalid read mode %s", parts[2]))
+					continue
+				}
+			}
+		}
+
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+
+	// unsupported: volume-driver
+	if len(c.VolumeDriver) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume-driver property in kubernetes manifest as the property is not supported"))
+	}
+
+	// unsupported: volumes-from
+	if len(c.VolumesFrom) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volumes-from property in kubernetes manifest as the property is not supported"))
+	}
+
+	// workdir -> workingDir
+	if len(c.Workdir) > 0 {
+		container.WorkingDir = c.Workdir
+	}
+
+	// positional arguments: command and image
+	if c.EntrypointCleared {
+		// command replaces the image entrypoint, so the command runs on its own
+		if len(arguments["command"].ListValue()) == 0 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to clear --entrypoint in kubernetes manifest without a command to run"))
+		}
+		container.Command = arguments["command"].ListValue()
+	} else if len(arguments["command"].ListValue()) > 0 {
+		container.Args = arguments["command"].ListValue()
+	}
+	container.Image = arguments["image"].StringValue()
+
+	// assemble
+	podSpec.Containers = []KubernetesContainer{container}
+	pod := kubernetesPod{
+		Name: name,
+		Template: KubernetesPodTemplateSpec{
+			Metadata: podMeta,
+			Spec:     podSpec,
+		},
+		ServicePorts: servicePorts,
+	}
+
+	return pod, warnings, errs
+}
+
+// MarshalKubernetes marshals Kubernetes manifests to a multi-document YAML stream
+func MarshalKubernetes(manifests *KubernetesManifests) ([]byte, error) {
+	out, err := yaml.Marshal(manifests.Deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifests.Service != nil {
+		service, err := yaml.Marshal(manifests.Service)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, []byte("---\n")...)
+		out = append(out, service...)
+	}
+
+	return out, nil
+}
+
+// addKubernetesPort adds a container port and a matching service port unless
+// they are already defined
+func addKubernetesPort(containerPorts []KubernetesContainerPort, servicePorts []KubernetesServicePort, port int, targetPort int, protocol string) ([]KubernetesContainerPort, []KubernetesServicePort) {
+	protocol = strings.ToUpper(protocol)
+	if len(protocol) == 0 {
+		protocol = "TCP"
+	}
+
+	found := false
+	for _, existing := range containerPorts {
+		if existing.ContainerPort == targetPort && existing.Protocol == protocol {
+			found = true
+		}
+	}
+	if !found {
+		containerPorts = append(containerPorts, KubernetesContainerPort{
+			ContainerPort: targetPort,
+			Protocol:      protocol,
+		})
+	}
+
+	for _, existing := range servicePorts {
+		if existing.Port == port && existing.Protocol == protocol {
+			return containerPorts, servicePorts
+		}
+	}
+
+	servicePorts = append(servicePorts, KubernetesServicePort{
+		Name:       fmt.Sprintf("%s-%d", strings.ToLower(protocol), port),
+		Port:       port,
+		TargetPort: targetPort,
+		Protocol:   protocol,
+	})
+
+	return containerPorts, servicePorts
+}
+
+// kubernetesCapabilities converts docker capability names to the form used by
+// Kubernetes, which omits the CAP_ prefix
+func kubernetesCapabilities(capabilities []string) []string {
+	var result []string
+	for _, capability := range capabilities {
+		result = append(result, strings.TrimPrefix(strings.ToUpper(capability), "CAP_"))
+	}
+	return result
+}
+
+// kubernetesResources returns resources with initialized limits and requests
+func kubernetesResources(resources *KubernetesResources) *KubernetesResources {
+	if resources == nil {
+		resources = &KubernetesResources{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = map[string]string{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = map[string]string{}
+	}
+	return resources
+}
+
+// kubernetesName converts a name to a valid Kubernetes object name
+func kubernetesName(name string) string {
+	name = kubernetesInvalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	if len(name) == 0 {
+		return "app"
+	}
+	return name
+}
@@ -0,0 +1,154 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// KubernetesJobOptions holds the DRE flags that apply to the kubernetes-job
+// format
+type KubernetesJobOptions struct {
+	// Schedule holds a cron expression, which wraps the Job in a CronJob
+	Schedule string
+}
+
+// KubernetesJobManifests holds the Kubernetes object generated for a
+// run-to-completion container. Only one of Job and CronJob is set.
+type KubernetesJobManifests struct {
+	Job     *KubernetesJob
+	CronJob *KubernetesCronJob
+}
+
+// KubernetesJob represents a batch/v1 Job
+type KubernetesJob struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   KubernetesObjectMeta `yaml:"metadata"`
+	Spec       KubernetesJobSpec    `yaml:"spec"`
+}
+
+// KubernetesJobSpec represents the spec of a Job
+type KubernetesJobSpec struct {
+	BackoffLimit            *int                      `yaml:"backoffLimit,omitempty"`
+	TTLSecondsAfterFinished *int                      `yaml:"ttlSecondsAfterFinished,omitempty"`
+	Template                KubernetesPodTemplateSpec `yaml:"template"`
+}
+
+// KubernetesCronJob represents a batch/v1 CronJob
+type KubernetesCronJob struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   KubernetesObjectMeta  `yaml:"metadata"`
+	Spec       KubernetesCronJobSpec `yaml:"spec"`
+}
+
+// KubernetesCronJobSpec represents the spec of a CronJob
+type KubernetesCronJobSpec struct {
+	Schedule    string                    `yaml:"schedule"`
+	JobTemplate KubernetesJobTemplateSpec `yaml:"jobTemplate"`
+}
+
+// KubernetesJobTemplateSpec represents the Job created by a CronJob on each
+// run
+type KubernetesJobTemplateSpec struct {
+	Metadata KubernetesObjectMeta `yaml:"metadata"`
+	Spec     KubernetesJobSpec    `yaml:"spec"`
+}
+
+// ToKubernetesJob converts docker run arguments to a Kubernetes Job, or to a
+// CronJob when a schedule is set. Docker runs a container once unless a
+// restart policy is set, so pods are not retried by default.
+func ToKubernetesJob(projectName string, c *arguments.Args, arguments map[string]command.Argument, jobOpts KubernetesJobOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	pod, warnings, errs := toKubernetesPod(projectName, c, arguments)
+
+	labels := map[string]string{
+		"app.kubernetes.io/name": pod.Name,
+	}
+	spec := KubernetesJobSpec{
+		Template: pod.Template,
+	}
+
+	// restart -> spec.template.spec.restartPolicy and spec.backoffLimit
+	spec.Template.Spec.RestartPolicy = "Never"
+	spec.BackoffLimit = IntToPtr(0)
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, maxRetries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else if mode == "on-failure" {
+			spec.Template.Spec.RestartPolicy = "OnFailure"
+			spec.BackoffLimit = nil
+			if maxRetries > 0 {
+				spec.BackoffLimit = IntToPtr(maxRetries)
+			} else {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to retry indefinitely for --restart on-failure in kubernetes job, the default backoffLimit of 6 retries applies"))
+			}
+		} else {
+			spec.Template.Spec.RestartPolicy = "OnFailure"
+			spec.BackoffLimit = nil
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --restart %s in kubernetes job as jobs run to completion, restarting the container on failure only", c.Restart))
+		}
+	}
+
+	// rm -> spec.ttlSecondsAfterFinished
+	if c.Rm {
+		spec.TTLSecondsAfterFinished = IntToPtr(0)
+	}
+
+	// publish / expose: jobs are not reached through a service
+	if len(pod.ServicePorts) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to create a service for the published or exposed ports in kubernetes job, the ports are only set on the container"))
+	}
+
+	if len(jobOpts.Schedule) == 0 {
+		return &KubernetesJobManifests{
+			Job: &KubernetesJob{
+				APIVersion: "batch/v1",
+				Kind:       "Job",
+				Metadata: KubernetesObjectMeta{
+					Name:   pod.Name,
+					Labels: labels,
+				},
+				Spec: spec,
+			},
+		}, warnings, errs
+	}
+
+	// schedule -> CronJob
+	if err := validateCronSchedule(jobOpts.Schedule); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	return &KubernetesJobManifests{
+		CronJob: &KubernetesCronJob{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+			Metadata: KubernetesObjectMeta{
+				Name:   pod.Name,
+				Labels: labels,
+			},
+			Spec: KubernetesCronJobSpec{
+				Schedule: jobOpts.Schedule,
+				JobTemplate: KubernetesJobTemplateSpec{
+					Metadata: KubernetesObjectMeta{
+						Labels: labels,
+					},
+					Spec: spec,
+				},
+			},
+		},
+	}, warnings, errs
+}
+
+// MarshalKubernetesJob marshals a Kubernetes Job or CronJob to YAML
+func MarshalKubernetesJob(manifests *KubernetesJobManifests) ([]byte, error) {
+	if manifests.CronJob != nil {
+		return yaml.Marshal(manifests.CronJob)
+	}
+
+	return yaml.Marshal(manifests.Job)
+}
@@ -0,0 +1,206 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// KustomizeOptions holds the DRE flags that apply to the kustomize format
+type KustomizeOptions struct {
+	// Overlay holds the name of the overlay directory that patches the base
+	// with the environment-specific settings
+	Overlay string
+}
+
+// KustomizeLayout holds the files of a kustomize base and overlay, keyed by
+// their path relative to the output directory
+type KustomizeLayout struct {
+	Files []OutputFile
+}
+
+// Kustomization represents a kustomization.yaml file
+type Kustomization struct {
+	APIVersion         string                        `yaml:"apiVersion"`
+	Kind               string                        `yaml:"kind"`
+	Resources          []string                      `yaml:"resources"`
+	ConfigMapGenerator []KustomizeConfigMapGenerator `yaml:"configMapGenerator,omitempty"`
+	Patches            []KustomizePatch              `yaml:"patches,omitempty"`
+}
+
+// KustomizeConfigMapGenerator represents a ConfigMap generated from literals
+// and env files
+type KustomizeConfigMapGenerator struct {
+	Name     string   `yaml:"name"`
+	Literals []string `yaml:"literals,omitempty"`
+	Envs     []string `yaml:"envs,omitempty"`
+}
+
+// KustomizePatch represents a patch file applied by a kustomization
+type KustomizePatch struct {
+	Path string `yaml:"path"`
+}
+
+// KustomizeDeploymentPatch represents a strategic merge patch of a
+// Deployment, holding the settings that vary between environments
+type KustomizeDeploymentPatch struct {
+	APIVersion string                       `yaml:"apiVersion"`
+	Kind       string                       `yaml:"kind"`
+	Metadata   KubernetesObjectMeta         `yaml:"metadata"`
+	Spec       KustomizeDeploymentPatchSpec `yaml:"spec"`
+}
+
+// KustomizeDeploymentPatchSpec represents the spec of a Deployment patch
+type KustomizeDeploymentPatchSpec struct {
+	Replicas int                        `yaml:"replicas"`
+	Template *KustomizePodTemplatePatch `yaml:"template,omitempty"`
+}
+
+// KustomizePodTemplatePatch represents the pod template of a Deployment patch
+type KustomizePodTemplatePatch struct {
+	Spec KustomizePodSpecPatch `yaml:"spec"`
+}
+
+// KustomizePodSpecPatch represents the pod spec of a Deployment patch
+type KustomizePodSpecPatch struct {
+	Containers []KustomizeContainerPatch `yaml:"containers"`
+}
+
+// KustomizeContainerPatch represents a container of a Deployment patch,
+// merged with the base container of the same name
+type KustomizeContainerPatch struct {
+	Name      string               `yaml:"name"`
+	Resources *KubernetesResources `yaml:"resources"`
+}
+
+// ToKustomize converts docker run arguments to a kustomize base holding the
+// Deployment, Service and a ConfigMap generator for the environment, and an
+// overlay patching in the resources and replica count.
+func ToKustomize(projectName string, c *arguments.Args, arguments map[string]command.Argument, kustomizeOpts KustomizeOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	// env files are referenced by the generator rather than warned about
+	args := *c
+	args.EnvFile = nil
+
+	output, warnings, errs := ToKubernetes(projectName, &args, arguments)
+	manifests := output.(*KubernetesManifests)
+	deployment := manifests.Deployment
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	name := deployment.Metadata.Name
+
+	overlay := kustomizeOpts.Overlay
+	if len(overlay) == 0 {
+		overlay = "production"
+	}
+	if overlay == "." || overlay == ".." || strings.ContainsAny(overlay, `/\`) {
+		errs = multierror.Append(errs, fmt.Errorf("invalid --dre-kustomize-overlay value %q: expected a directory name", overlay))
+	}
+
+	base := Kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"deployment.yaml"},
+	}
+	if manifests.Service != nil {
+		base.Resources = append(base.Resources, "service.yaml")
+	}
+
+	// env / env-file -> configMapGenerator, loaded with envFrom
+	generator := KustomizeConfigMapGenerator{
+		Name: name + "-env",
+	}
+	for _, env := range container.Env {
+		generator.Literals = append(generator.Literals, fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+
+	// kustomize only loads env files from within the kustomization
+	// directory, so each one is copied next to the base kustomization.yaml
+	envFiles := []OutputFile{}
+	for _, envFile := range c.EnvFile {
+		content, err := os.ReadFile(envFile)
+		if err != nil {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env-file %s in kustomize base as the file could not be read: %w", envFile, err))
+			continue
+		}
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			content = append(content, '\n')
+		}
+
+		fileName := filepath.Base(envFile)
+		for _, existing := range generator.Envs {
+			if existing == fileName {
+				fileName = fmt.Sprintf("%d-%s", len(envFiles), fileName)
+				break
+			}
+		}
+		generator.Envs = append(generator.Envs, fileName)
+		envFiles = append(envFiles, OutputFile{Path: "base/" + fileName, Content: content})
+	}
+	if len(generator.Literals) > 0 || len(generator.Envs) > 0 {
+		base.ConfigMapGenerator = []KustomizeConfigMapGenerator{generator}
+		container.Env = nil
+		container.EnvFrom = []KubernetesEnvFromSource{
+			{ConfigMapRef: &KubernetesConfigMapEnvSource{Name: generator.Name}},
+		}
+	}
+
+	// replicas / resources -> overlay patch
+	patch := KustomizeDeploymentPatch{
+		APIVersion: deployment.APIVersion,
+		Kind:       deployment.Kind,
+		Metadata:   KubernetesObjectMeta{Name: name},
+		Spec: KustomizeDeploymentPatchSpec{
+			Replicas: deployment.Spec.Replicas,
+		},
+	}
+	if container.Resources != nil {
+		patch.Spec.Template = &KustomizePodTemplatePatch{
+			Spec: KustomizePodSpecPatch{
+				Containers: []KustomizeContainerPatch{
+					{Name: container.Name, Resources: container.Resources},
+				},
+			},
+		}
+		container.Resources = nil
+	}
+	deployment.Spec.Replicas = 0
+
+	overlayKustomization := Kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"../../base"},
+		Patches:    []KustomizePatch{{Path: "deployment-patch.yaml"}},
+	}
+
+	layout := &KustomizeLayout{}
+	addFile := func(path string, value interface{}) {
+		content, err := yaml.Marshal(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		layout.Files = append(layout.Files, OutputFile{Path: path, Content: content})
+	}
+
+	addFile("base/kustomization.yaml", base)
+	layout.Files = append(layout.Files, envFiles...)
+	addFile("base/deployment.yaml", deployment)
+	if manifests.Service != nil {
+		addFile("base/service.yaml", manifests.Service)
+	}
+	addFile("overlays/"+overlay+"/kustomization.yaml", overlayKustomization)
+	addFile("overlays/"+overlay+"/deployment-patch.yaml", patch)
+
+	return layout, warnings, errs
+}
+
+// MarshalKustomize marshals the files of a kustomize base and overlay to a
+// multi-document stream
+func MarshalKustomize(layout *KustomizeLayout) ([]byte, error) {
+	return marshalOutputFiles("", layout.Files), nil
+}
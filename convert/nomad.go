@@ -24,6 +24,8 @@ type NomadOptions struct {
 	Namespace   string
 	Type        string
 	Count       int
+	// Schedule holds a cron expression, which makes a batch job periodic
+	Schedule string
 }
 
 // NomadJob wraps the top-level Job object for the Nomad JSON API format
@@ -39,9 +41,18 @@ type NomadJobSpec struct {
 	Datacenters []string         `json:"Datacenters"`
 	Region      string           `json:"Region,omitempty"`
 	Namespace   string           `json:"Namespace,omitempty"`
+	Periodic    *NomadPeriodic   `json:"Periodic,omitempty"`
 	TaskGroups  []NomadTaskGroup `json:"TaskGroups"`
 }
 
+// NomadPeriodic represents a periodic stanza, which launches the job on a
+// cron schedule
+type NomadPeriodic struct {
+	Enabled  bool   `json:"Enabled"`
+	SpecType string `json:"SpecType"`
+	Spec     string `json:"Spec"`
+}
+
 // NomadTaskGroup represents a Nomad task group
 type NomadTaskGroup struct {
 	Name          string              `json:"Name"`
@@ -163,6 +174,15 @@ func ToNomad(projectName string, c *arguments.Args, arguments map[string]command
 		Namespace:   nomadOpts.Namespace,
 	}
 
+	// schedule -> periodic
+	if len(nomadOpts.Schedule) > 0 {
+		periodic, err := nomadPeriodic(jobType, nomadOpts.Schedule)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		job.Periodic = periodic
+	}
+
 	task := NomadTask{
 		Name:   taskName,
 		Driver: "docker",
@@ -361,6 +381,8 @@ func ToNomad(projectName string, c *arguments.Args, arguments map[string]command
 		} else {
 			task.Config["entrypoint"] = parsed
 		}
+	} else if c.EntrypointCleared {
+		task.Config["entrypoint"] = []string{""}
 	}
 
 	// env -> task.Env
@@ -903,6 +925,163 @@ func ToNomad(projectName string, c *arguments.Args, arguments map[string]command
 	return &NomadJob{Job: job}, warnings, errs
 }
 
+// ToNomadTasks converts several docker run invocations to a Nomad job
+// specification with a single group holding one task per container
+func ToNomadTasks(projectName string, containers []Container, nomadOpts NomadOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	names, resolved, references, err := resolveContainers(containers)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+		return &NomadJob{Job: &NomadJobSpec{ID: projectName, Name: projectName}}, warnings, errs
+	}
+
+	jobName := projectName
+	if len(jobName) == 0 {
+		jobName = names[0]
+	}
+
+	// the schedule applies to the whole job, so it is only validated once
+	taskOpts := nomadOpts
+	taskOpts.Schedule = ""
+
+	var job *NomadJobSpec
+	var group NomadTaskGroup
+	network := NomadNetwork{}
+	labels := map[string]bool{}
+	for i, container := range resolved {
+		container.Args.ContainerName = names[i]
+		output, w, e := ToNomad(jobName, container.Args, container.Arguments, taskOpts)
+		warnings = appendContainerErrors(warnings, names[i], w)
+		errs = appendContainerErrors(errs, names[i], e)
+
+		single := output.(*NomadJob).Job
+		singleGroup := single.TaskGroups[0]
+		task := singleGroup.Tasks[0]
+		if job == nil {
+			job = single
+			group = NomadTaskGroup{
+				Name:  jobName,
+				Count: singleGroup.Count,
+			}
+		}
+
+		for _, singleNetwork := range singleGroup.Networks {
+			if len(singleNetwork.Mode) > 0 {
+				if len(network.Mode) > 0 && network.Mode != singleNetwork.Mode {
+					warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring network mode %q as the group network mode is already set to %q", names[i], singleNetwork.Mode, network.Mode))
+				} else {
+					network.Mode = singleNetwork.Mode
+				}
+			}
+
+			// port labels are scoped to the group, so prefix any label that
+			// is already used by another task with the task name
+			renamed := map[string]string{}
+			for _, ports := range []struct {
+				from []NomadPort
+				to   *[]NomadPort
+			}{
+				{singleNetwork.DynamicPorts, &network.DynamicPorts},
+				{singleNetwork.ReservedPorts, &network.ReservedPorts},
+			} {
+				for _, port := range ports.from {
+					if labels[port.Label] {
+						label := fmt.Sprintf("%s_%s", names[i], port.Label)
+						renamed[port.Label] = label
+						port.Label = label
+					}
+					labels[port.Label] = true
+					*ports.to = append(*ports.to, port)
+				}
+			}
+
+			if portLabels, ok := task.Config["ports"].([]string); ok && len(renamed) > 0 {
+				for j, label := range portLabels {
+					if newLabel, ok := renamed[label]; ok {
+						portLabels[j] = newLabel
+					}
+				}
+			}
+		}
+
+		group.Services = append(group.Services, singleGroup.Services...)
+
+		if singleGroup.RestartPolicy != nil {
+			if group.RestartPolicy == nil {
+				group.RestartPolicy = singleGroup.RestartPolicy
+			} else if *group.RestartPolicy != *singleGroup.RestartPolicy {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: ignoring --restart as the group restart policy is already set by %s", names[i], names[0]))
+			}
+		}
+
+		group.Tasks = append(group.Tasks, task)
+	}
+
+	// tasks of a group share a network namespace in bridge mode, so linked
+	// containers are reachable on localhost
+	for i := range resolved {
+		task := &group.Tasks[i]
+		if len(references[i].Links) > 0 {
+			extraHosts, _ := task.Config["extra_hosts"].([]string)
+			for _, link := range references[i].Links {
+				name, alias := extractParts(link, ":")
+				extraHosts = append(extraHosts, fmt.Sprintf("%s:127.0.0.1", name))
+				if len(alias) > 0 && alias != name {
+					extraHosts = append(extraHosts, fmt.Sprintf("%s:127.0.0.1", alias))
+				}
+			}
+			task.Config["extra_hosts"] = extraHosts
+		}
+
+		if len(references[i].VolumesFrom) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("%s: unable to set --volumes-from property in nomad job spec as the property is not supported", names[i]))
+		}
+
+		if len(references[i].Links) > 0 || len(references[i].Network) > 0 {
+			if len(network.Mode) == 0 {
+				network.Mode = "bridge"
+			} else if network.Mode != "bridge" && network.Mode != "host" {
+				warnings = multierror.Append(warnings, fmt.Errorf("%s: tasks do not share a network namespace in network mode %q", names[i], network.Mode))
+			}
+		}
+	}
+
+	if network.Mode != "" || len(network.DynamicPorts) > 0 || len(network.ReservedPorts) > 0 {
+		group.Networks = []NomadNetwork{network}
+	}
+
+	if len(nomadOpts.Schedule) > 0 {
+		periodic, err := nomadPeriodic(job.Type, nomadOpts.Schedule)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		job.Periodic = periodic
+	}
+
+	job.TaskGroups = []NomadTaskGroup{group}
+
+	return &NomadJob{Job: job}, warnings, errs
+}
+
+// nomadPeriodic returns the periodic stanza for a schedule. Only batch jobs
+// run to completion, so other job types cannot be periodic.
+func nomadPeriodic(jobType string, schedule string) (*NomadPeriodic, error) {
+	if jobType != "batch" {
+		return nil, fmt.Errorf("unable to set --dre-schedule in nomad job spec as only batch jobs can be periodic, pass --dre-nomad-type batch")
+	}
+	if err := validateCronSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	return &NomadPeriodic{
+		Enabled:  true,
+		SpecType: "cron",
+		Spec:     schedule,
+	}, nil
+}
+
 // MarshalNomadJSON marshals a Nomad job to the Nomad API-compatible JSON format
 func MarshalNomadJSON(job *NomadJob) ([]byte, error) {
 	return json.MarshalIndent(job, "", "  ")
@@ -924,6 +1103,11 @@ func MarshalNomadHCL(job *NomadJob) ([]byte, error) {
 	if spec.Namespace != "" {
 		jobBody.SetAttributeValue("namespace", cty.StringVal(spec.Namespace))
 	}
+	if spec.Periodic != nil {
+		jobBody.AppendNewline()
+		periodicBody := jobBody.AppendNewBlock("periodic", nil).Body()
+		periodicBody.SetAttributeValue("cron", cty.StringVal(spec.Periodic.Spec))
+	}
 
 	for _, tg := range spec.TaskGroups {
 		writeTaskGroup(jobBody, tg)
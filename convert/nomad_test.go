@@ -2,6 +2,7 @@ package convert
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"docker-run-export/arguments"
@@ -267,6 +268,26 @@ var nomadTestCases = []nomadTestCase{
 			StopSignal:          "SIGTERM",
 		},
 	},
+	{
+		name:    "periodic_batch",
+		project: "nightly",
+		image:   "alpine:latest",
+		command: []string{"echo", "backup"},
+		opts: NomadOptions{
+			Type:     "batch",
+			Schedule: "0 3 * * *",
+		},
+		args: arguments.Args{
+			Rm:                  true,
+			Pull:                "missing",
+			HealthInterval:      "0s",
+			HealthStartPeriod:   "0s",
+			HealthTimeout:       "0s",
+			DisableContentTrust: true,
+			SigProxy:            true,
+			StopSignal:          "SIGTERM",
+		},
+	},
 }
 
 // TestMarshalNomadHCL_ParseSyntax verifies that every generated HCL document
@@ -349,3 +370,56 @@ func TestMarshalNomadHCL_NomadSchema(t *testing.T) {
 		})
 	}
 }
+
+// TestToNomadTasks_NomadSchema verifies that several containers are exported
+// as tasks of a single group that parses through Nomad's own HCL2 parser, and
+// that links between containers are reachable on localhost.
+func TestToNomadTasks_NomadSchema(t *testing.T) {
+	containers := []Container{
+		{
+			Args:      withDefaults(arguments.Args{ContainerName: "redis", Publish: []string{"6379:6379"}}),
+			Arguments: makeArgs("redis:7"),
+		},
+		{
+			Args:      withDefaults(arguments.Args{ContainerName: "web", Link: []string{"redis:cache"}, Publish: []string{"8080:6379"}}),
+			Arguments: makeArgs("nginx:latest"),
+		},
+	}
+
+	out, _, errs := ToNomadTasks("stack", containers, NomadOptions{})
+	if errs != nil && errs.ErrorOrNil() != nil {
+		t.Fatalf("ToNomadTasks returned errors: %v", errs)
+	}
+
+	hcl, err := MarshalNomadHCL(out.(*NomadJob))
+	if err != nil {
+		t.Fatalf("MarshalNomadHCL failed: %v", err)
+	}
+
+	parsed, err := jobspec2.Parse("stack.nomad", bytes.NewReader(hcl))
+	if err != nil {
+		t.Fatalf("Nomad schema parse failed: %v\n--- generated HCL ---\n%s", err, hcl)
+	}
+
+	if len(parsed.TaskGroups) != 1 {
+		t.Fatalf("expected 1 task group, got %d", len(parsed.TaskGroups))
+	}
+
+	tg := parsed.TaskGroups[0]
+	if len(tg.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks in group, got %d", len(tg.Tasks))
+	}
+	if len(tg.Networks) != 1 || tg.Networks[0].Mode != "bridge" {
+		t.Errorf("expected a single bridge network, got %v", tg.Networks)
+	}
+
+	ports := tg.Tasks[1].Config["ports"]
+	if !reflect.DeepEqual(ports, []interface{}{"web_port_6379"}) {
+		t.Errorf("web config.ports = %v, want [web_port_6379]", ports)
+	}
+
+	extraHosts := tg.Tasks[1].Config["extra_hosts"]
+	if !reflect.DeepEqual(extraHosts, []interface{}{"redis:127.0.0.1", "cache:127.0.0.1"}) {
+		t.Errorf("web config.extra_hosts = %v", extraHosts)
+	}
+}
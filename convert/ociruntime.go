@@ -0,0 +1,947 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	shellwords "github.com/mattn/go-shellwords"
+)
+
+// ociRuntimeVersion holds the runtime-spec version of a generated config
+const ociRuntimeVersion = "1.2.0"
+
+// ociRuntimeDefaultPath holds the PATH set when --env does not set one, as
+// docker does
+const ociRuntimeDefaultPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// ociRuntimeFlags holds the docker run flags that are mapped to the runtime
+// spec, or that need no mapping as the caller runs the container
+var ociRuntimeFlags = map[string]bool{
+	"annotation":          true,
+	"blkio-weight":        true,
+	"blkio-weight-device": true,
+	"cap-add":             true,
+	"cap-drop":            true,
+	"cgroup-parent":       true,
+	"cgroupns":            true,
+	"cpu-period":          true,
+	"cpu-quota":           true,
+	"cpu-rt-period":       true,
+	"cpu-rt-runtime":      true,
+	"cpu-shares":          true,
+	"cpus":                true,
+	"cpuset-cpus":         true,
+	"cpuset-mems":         true,
+	"detach":              true,
+	"device-read-bps":     true,
+	"device-read-iops":    true,
+	"device-write-bps":    true,
+	"device-write-iops":   true,
+	"domainname":          true,
+	"entrypoint":          true,
+	"env":                 true,
+	"env-file":            true,
+	"expose":              true,
+	"group-add":           true,
+	"hostname":            true,
+	"interactive":         true,
+	"ipc":                 true,
+	"kernel-memory":       true,
+	"label":               true,
+	"memory":              true,
+	"memory-reservation":  true,
+	"memory-swap":         true,
+	"memory-swappiness":   true,
+	"mount":               true,
+	"name":                true,
+	"network":             true,
+	"oom-kill-disable":    true,
+	"oom-score-adj":       true,
+	"pid":                 true,
+	"pids-limit":          true,
+	"privileged":          true,
+	"publish":             true,
+	"read-only":           true,
+	"rm":                  true,
+	"security-opt":        true,
+	"shm-size":            true,
+	"sysctl":              true,
+	"tmpfs":               true,
+	"tty":                 true,
+	"ulimit":              true,
+	"user":                true,
+	"userns":              true,
+	"uts":                 true,
+	"volume":              true,
+	"workdir":             true,
+}
+
+// ociRuntimeDefaultCapabilities holds the capabilities docker grants every
+// container, in the order docker sets them
+var ociRuntimeDefaultCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_FSETID",
+	"CAP_FOWNER",
+	"CAP_MKNOD",
+	"CAP_NET_RAW",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETFCAP",
+	"CAP_SETPCAP",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_SYS_CHROOT",
+	"CAP_KILL",
+	"CAP_AUDIT_WRITE",
+}
+
+// ociRuntimeCapabilities holds every linux capability, in the order of their
+// numbers
+var ociRuntimeCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// ociRuntimeMaskedPaths holds the paths docker hides from a container
+var ociRuntimeMaskedPaths = []string{
+	"/proc/acpi",
+	"/proc/asound",
+	"/proc/kcore",
+	"/proc/keys",
+	"/proc/latency_stats",
+	"/proc/timer_list",
+	"/proc/timer_stats",
+	"/proc/sched_debug",
+	"/proc/scsi",
+	"/sys/firmware",
+	"/sys/devices/virtual/powercap",
+}
+
+// ociRuntimeReadonlyPaths holds the paths docker mounts read-only in a
+// container
+var ociRuntimeReadonlyPaths = []string{
+	"/proc/bus",
+	"/proc/fs",
+	"/proc/irq",
+	"/proc/sys",
+	"/proc/sysrq-trigger",
+}
+
+// ociRuntimeRlimits holds the --ulimit names, which are mapped to
+// RLIMIT_<NAME>
+var ociRuntimeRlimits = map[string]bool{
+	"as":         true,
+	"core":       true,
+	"cpu":        true,
+	"data":       true,
+	"fsize":      true,
+	"locks":      true,
+	"memlock":    true,
+	"msgqueue":   true,
+	"nice":       true,
+	"nofile":     true,
+	"nproc":      true,
+	"rss":        true,
+	"rtprio":     true,
+	"rttime":     true,
+	"sigpending": true,
+	"stack":      true,
+}
+
+// OCIRuntimeSpec represents an OCI runtime-spec config.json file
+type OCIRuntimeSpec struct {
+	OCIVersion  string            `json:"ociVersion"`
+	Process     OCIProcess        `json:"process"`
+	Root        OCIRoot           `json:"root"`
+	Hostname    string            `json:"hostname,omitempty"`
+	Domainname  string            `json:"domainname,omitempty"`
+	Mounts      []OCIMount        `json:"mounts"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Linux       OCILinux          `json:"linux"`
+}
+
+// OCIProcess represents the process run in a container
+type OCIProcess struct {
+	Terminal        bool            `json:"terminal,omitempty"`
+	User            OCIUser         `json:"user"`
+	Args            []string        `json:"args"`
+	Env             []string        `json:"env"`
+	Cwd             string          `json:"cwd"`
+	Capabilities    OCICapabilities `json:"capabilities"`
+	Rlimits         []OCIRlimit     `json:"rlimits,omitempty"`
+	NoNewPrivileges bool            `json:"noNewPrivileges,omitempty"`
+	ApparmorProfile string          `json:"apparmorProfile,omitempty"`
+	OomScoreAdj     *int            `json:"oomScoreAdj,omitempty"`
+}
+
+// OCIUser represents the user a process runs as
+type OCIUser struct {
+	UID            uint32   `json:"uid"`
+	GID            uint32   `json:"gid"`
+	AdditionalGids []uint32 `json:"additionalGids,omitempty"`
+}
+
+// OCICapabilities represents the capability sets of a process
+type OCICapabilities struct {
+	Bounding  []string `json:"bounding"`
+	Effective []string `json:"effective"`
+	Permitted []string `json:"permitted"`
+}
+
+// OCIRlimit represents a resource limit of a process
+type OCIRlimit struct {
+	Type string `json:"type"`
+	Hard uint64 `json:"hard"`
+	Soft uint64 `json:"soft"`
+}
+
+// OCIRoot represents the root filesystem of a container
+type OCIRoot struct {
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly,omitempty"`
+}
+
+// OCIMount represents a mount of a container
+type OCIMount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// OCILinux represents the linux-specific settings of a container
+type OCILinux struct {
+	Sysctl        map[string]string `json:"sysctl,omitempty"`
+	Resources     OCIResources      `json:"resources"`
+	CgroupsPath   string            `json:"cgroupsPath,omitempty"`
+	Namespaces    []OCINamespace    `json:"namespaces"`
+	MaskedPaths   []string          `json:"maskedPaths,omitempty"`
+	ReadonlyPaths []string          `json:"readonlyPaths,omitempty"`
+}
+
+// OCIResources represents the cgroup settings of a container
+type OCIResources struct {
+	Devices []OCIDeviceCgroup `json:"devices"`
+	Memory  *OCIMemory        `json:"memory,omitempty"`
+	CPU     *OCICPU           `json:"cpu,omitempty"`
+	Pids    *OCIPids          `json:"pids,omitempty"`
+	BlockIO *OCIBlockIO       `json:"blockIO,omitempty"`
+}
+
+// OCIDeviceCgroup represents a device cgroup rule
+type OCIDeviceCgroup struct {
+	Allow  bool   `json:"allow"`
+	Access string `json:"access"`
+}
+
+// OCIMemory represents the memory cgroup settings of a container
+type OCIMemory struct {
+	Limit            *int64  `json:"limit,omitempty"`
+	Reservation      *int64  `json:"reservation,omitempty"`
+	Swap             *int64  `json:"swap,omitempty"`
+	Kernel           *int64  `json:"kernel,omitempty"`
+	Swappiness       *uint64 `json:"swappiness,omitempty"`
+	DisableOOMKiller *bool   `json:"disableOOMKiller,omitempty"`
+}
+
+// OCICPU represents the cpu cgroup settings of a container
+type OCICPU struct {
+	Shares          *uint64 `json:"shares,omitempty"`
+	Quota           *int64  `json:"quota,omitempty"`
+	Period          *uint64 `json:"period,omitempty"`
+	RealtimeRuntime *int64  `json:"realtimeRuntime,omitempty"`
+	RealtimePeriod  *uint64 `json:"realtimePeriod,omitempty"`
+	Cpus            string  `json:"cpus,omitempty"`
+	Mems            string  `json:"mems,omitempty"`
+}
+
+// OCIPids represents the pids cgroup settings of a container
+type OCIPids struct {
+	Limit int64 `json:"limit"`
+}
+
+// OCIBlockIO represents the blkio cgroup settings of a container
+type OCIBlockIO struct {
+	Weight                  *uint16             `json:"weight,omitempty"`
+	WeightDevice            []OCIWeightDevice   `json:"weightDevice,omitempty"`
+	ThrottleReadBpsDevice   []OCIThrottleDevice `json:"throttleReadBpsDevice,omitempty"`
+	ThrottleWriteBpsDevice  []OCIThrottleDevice `json:"throttleWriteBpsDevice,omitempty"`
+	ThrottleReadIOPSDevice  []OCIThrottleDevice `json:"throttleReadIOPSDevice,omitempty"`
+	ThrottleWriteIOPSDevice []OCIThrottleDevice `json:"throttleWriteIOPSDevice,omitempty"`
+}
+
+// OCIWeightDevice represents the blkio weight of a device
+type OCIWeightDevice struct {
+	Major  int64   `json:"major"`
+	Minor  int64   `json:"minor"`
+	Weight *uint16 `json:"weight,omitempty"`
+}
+
+// OCIThrottleDevice represents the blkio rate limit of a device
+type OCIThrottleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"`
+}
+
+// OCINamespace represents a namespace a container is created in
+type OCINamespace struct {
+	Type string `json:"type"`
+}
+
+// ToOCIRuntime converts docker run arguments to an OCI runtime-spec
+// config.json for runc and crun bundles. The config starts from the settings
+// docker applies to every container, and the flags are applied on top.
+func ToOCIRuntime(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	name := projectName
+	if len(name) == 0 {
+		name = c.ContainerName
+	}
+	if len(name) == 0 {
+		name = imageName(arguments["image"].StringValue())
+	}
+
+	spec := &OCIRuntimeSpec{
+		OCIVersion: ociRuntimeVersion,
+		Process: OCIProcess{
+			Terminal: c.Tty,
+			Cwd:      "/",
+		},
+		Root: OCIRoot{
+			Path:     "rootfs",
+			Readonly: c.ReadOnly,
+		},
+		Hostname:   c.Hostname,
+		Domainname: c.Domainname,
+		Linux: OCILinux{
+			Resources: OCIResources{
+				Devices: []OCIDeviceCgroup{{Allow: false, Access: "rwm"}},
+			},
+			MaskedPaths:   ociRuntimeMaskedPaths,
+			ReadonlyPaths: ociRuntimeReadonlyPaths,
+		},
+	}
+	process := &spec.Process
+	linux := &spec.Linux
+
+	// entrypoint / command -> process.args
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			process.Args = args
+		}
+	}
+	process.Args = append(process.Args, arguments["command"].ListValue()...)
+	if len(process.Args) == 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set process.args in oci runtime config as the image command is not known, pass a command or --entrypoint"))
+	}
+
+	if len(c.Workdir) > 0 {
+		process.Cwd = c.Workdir
+	}
+
+	// env -> process.env, with the PATH and TERM docker sets
+	hasPath := false
+	for _, value := range c.Env {
+		if strings.HasPrefix(value, "PATH=") {
+			hasPath = true
+		}
+	}
+	if !hasPath {
+		process.Env = append(process.Env, ociRuntimeDefaultPath)
+	}
+	if c.Tty {
+		process.Env = append(process.Env, "TERM=xterm")
+	}
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in oci runtime config as passing through host environment variables is not supported", value))
+			continue
+		}
+		process.Env = append(process.Env, value)
+	}
+	if len(c.EnvFile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env-file property in oci runtime config as the file is read by the docker cli, add its variables with --env"))
+	}
+
+	// user / group-add -> process.user
+	if len(c.User) > 0 {
+		uid, gid := extractParts(c.User, ":")
+		parsedUID, uidErr := strconv.ParseUint(uid, 10, 32)
+		parsedGID, gidErr := strconv.ParseUint(gid, 10, 32)
+		if uidErr != nil || (len(gid) > 0 && gidErr != nil) {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --user %s in oci runtime config as only numeric uids and gids are supported", c.User))
+		} else {
+			process.User.UID = uint32(parsedUID)
+			process.User.GID = uint32(parsedGID)
+		}
+	}
+	for _, group := range c.GroupAdd {
+		gid, err := strconv.ParseUint(group, 10, 32)
+		if err != nil {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --group-add %s in oci runtime config as only numeric gids are supported", group))
+			continue
+		}
+		process.User.AdditionalGids = append(process.User.AdditionalGids, uint32(gid))
+	}
+
+	// cap-add / cap-drop / privileged -> process.capabilities
+	capabilities, err := ociRuntimeCapabilitySet(c.CapAdd, c.CapDrop, c.Privileged)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	process.Capabilities = OCICapabilities{
+		Bounding:  capabilities,
+		Effective: capabilities,
+		Permitted: capabilities,
+	}
+	if c.Privileged {
+		linux.MaskedPaths = nil
+		linux.ReadonlyPaths = nil
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to add the host devices for --privileged in oci runtime config, only the capabilities and paths are unrestricted"))
+	}
+
+	// ulimit -> process.rlimits
+	for _, value := range c.Ulimit {
+		limitName, limits := extractParts(value, "=")
+		if !ociRuntimeRlimits[limitName] {
+			errs = multierror.Append(errs, fmt.Errorf("invalid --ulimit name %q", limitName))
+			continue
+		}
+		soft, hard := extractParts(limits, ":")
+		if len(hard) == 0 {
+			hard = soft
+		}
+		softLimit, err := strconv.ParseUint(soft, 10, 64)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit flag value: %w", err))
+			continue
+		}
+		hardLimit, err := strconv.ParseUint(hard, 10, 64)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit flag value: %w", err))
+			continue
+		}
+		process.Rlimits = append(process.Rlimits, OCIRlimit{
+			Type: "RLIMIT_" + strings.ToUpper(limitName),
+			Hard: hardLimit,
+			Soft: softLimit,
+		})
+	}
+
+	if c.OomScore != 0 {
+		process.OomScoreAdj = IntToPtr(c.OomScore)
+	}
+
+	// security-opt -> process.noNewPrivileges / process.apparmorProfile
+	for _, value := range c.SecurityOpt {
+		// the legacy key:value form is accepted as docker does
+		separator := "="
+		if !strings.Contains(value, "=") {
+			separator = ":"
+		}
+		key, val := extractParts(value, separator)
+		switch {
+		case key == "no-new-privileges":
+			enabled, _, err := noNewPrivileges(value)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+			}
+			process.NoNewPrivileges = enabled
+		case key == "apparmor" && val != "unconfined":
+			process.ApparmorProfile = val
+		case key == "apparmor":
+		case key == "seccomp" && val == "unconfined":
+		case key == "seccomp":
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --security-opt %s in oci runtime config as docker seccomp profiles are not in the runtime-spec format, convert it to linux.seccomp", value))
+		case key == "systempaths" && val == "unconfined":
+			linux.MaskedPaths = nil
+			linux.ReadonlyPaths = nil
+		case key == "label" && val == "disable":
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --security-opt %s in oci runtime config as the option is not supported", value))
+		}
+	}
+
+	// default mounts, with shm-size -> /dev/shm
+	shmSize := "65536k"
+	if c.ShmSize > 0 {
+		shmSize = strconv.Itoa(c.ShmSize)
+	}
+	spec.Mounts = []OCIMount{
+		{Destination: "/proc", Type: "proc", Source: "proc", Options: []string{"nosuid", "noexec", "nodev"}},
+		{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
+		{Destination: "/dev/pts", Type: "devpts", Source: "devpts", Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620", "gid=5"}},
+		{Destination: "/dev/shm", Type: "tmpfs", Source: "shm", Options: []string{"nosuid", "noexec", "nodev", "mode=1777", "size=" + shmSize}},
+		{Destination: "/dev/mqueue", Type: "mqueue", Source: "mqueue", Options: []string{"nosuid", "noexec", "nodev"}},
+		{Destination: "/sys", Type: "sysfs", Source: "sysfs", Options: []string{"nosuid", "noexec", "nodev", "ro"}},
+		{Destination: "/sys/fs/cgroup", Type: "cgroup", Source: "cgroup", Options: []string{"nosuid", "noexec", "nodev", "relatime", "ro"}},
+	}
+
+	// tmpfs -> mounts
+	for _, value := range c.Tmpfs {
+		target, options := extractParts(value, ":")
+		spec.Mounts = append(spec.Mounts, ociRuntimeTmpfsMount(target, strings.Split(options, ",")))
+	}
+
+	// volume -> bind mounts
+	for _, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) == 1 || !strings.HasPrefix(parts[0], "/") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume %s in oci runtime config as volumes are created by the docker daemon, bind a host directory instead", value))
+			continue
+		}
+		mount := OCIMount{
+			Destination: parts[1],
+			Type:        "bind",
+			Source:      parts[0],
+		}
+		var options []string
+		if len(parts) == 3 {
+			for _, option := range strings.Split(parts[2], ",") {
+				if option == "z" || option == "Z" {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to relabel --volume %s in oci runtime config, set the SELinux label of the host directory instead", value))
+					continue
+				}
+				options = append(options, option)
+			}
+		}
+		mount.Options = ociRuntimeBindOptions(options)
+		spec.Mounts = append(spec.Mounts, mount)
+	}
+
+	// mount -> bind and tmpfs mounts
+	for _, value := range c.Mount {
+		parsed, err := parseDockerMount(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		target := parsed["target"].(string)
+		switch parsed["type"] {
+		case "bind":
+			source, _ := parsed["source"].(string)
+			var options []string
+			if readOnly, ok := parsed["readonly"].(bool); ok && readOnly {
+				options = append(options, "ro")
+			}
+			if bindOptions, ok := parsed["bind_options"].(map[string]interface{}); ok {
+				if propagation, ok := bindOptions["propagation"].(string); ok {
+					options = append(options, propagation)
+				}
+			}
+			spec.Mounts = append(spec.Mounts, OCIMount{
+				Destination: target,
+				Type:        "bind",
+				Source:      source,
+				Options:     ociRuntimeBindOptions(options),
+			})
+		case "tmpfs":
+			var options []string
+			if tmpfsOptions, ok := parsed["tmpfs_options"].(map[string]interface{}); ok {
+				if size, ok := tmpfsOptions["size"].(int64); ok {
+					options = append(options, fmt.Sprintf("size=%d", size))
+				}
+				if mode, ok := tmpfsOptions["mode"].(int); ok {
+					options = append(options, fmt.Sprintf("mode=%o", mode))
+				}
+			}
+			spec.Mounts = append(spec.Mounts, ociRuntimeTmpfsMount(target, options))
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --mount %s in oci runtime config as volumes are created by the docker daemon, bind a host directory instead", value))
+		}
+	}
+
+	// network / pid / ipc / uts / cgroupns -> linux.namespaces, leaving out
+	// the namespaces shared with the host
+	for _, namespace := range []struct {
+		kind string
+		flag string
+		mode string
+	}{
+		{"pid", "pid", c.Pid},
+		{"network", "network", c.Network},
+		{"ipc", "ipc", c.Ipc},
+		{"uts", "uts", c.Uts},
+		{"mount", "", ""},
+		{"cgroup", "cgroupns", c.Cgroupns},
+	} {
+		if namespace.mode == "host" {
+			continue
+		}
+		if strings.HasPrefix(namespace.mode, "container:") {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s %s in oci runtime config as joining the namespace of another container is not supported, set the path of the %s namespace instead", namespace.flag, namespace.mode, namespace.kind))
+		}
+		linux.Namespaces = append(linux.Namespaces, OCINamespace{Type: namespace.kind})
+	}
+	if len(c.Network) > 0 && c.Network != "host" && c.Network != "none" && !strings.HasPrefix(c.Network, "container:") {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to connect the container to --network %s in oci runtime config, the network namespace is created empty and must be configured by the caller", c.Network))
+	}
+	if c.Network != "host" && (len(c.Publish) > 0 || len(c.Expose) > 0) {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --publish and --expose properties in oci runtime config, the network namespace is created empty and must be configured by the caller"))
+	}
+
+	if len(c.Sysctl) > 0 {
+		linux.Sysctl = c.Sysctl
+	}
+
+	if len(c.CgroupParent) > 0 {
+		linux.CgroupsPath = path.Join(c.CgroupParent, name)
+	}
+
+	// memory-* -> linux.resources.memory
+	memory := &OCIMemory{}
+	if c.Memory > 0 {
+		memory.Limit = Int64ToPtr(c.Memory)
+		// docker allows as much swap as memory unless --memory-swap is set
+		if c.MemorySwap == 0 {
+			memory.Swap = Int64ToPtr(c.Memory * 2)
+		}
+	}
+	if c.MemoryReservation > 0 {
+		memory.Reservation = Int64ToPtr(c.MemoryReservation)
+	}
+	if c.MemorySwap != 0 {
+		memory.Swap = Int64ToPtr(c.MemorySwap)
+	}
+	if c.KernelMemory > 0 {
+		memory.Kernel = Int64ToPtr(int64(c.KernelMemory))
+	}
+	if c.MemorySwappiness > 0 {
+		memory.Swappiness = Uint64ToPtr(uint64(c.MemorySwappiness))
+	}
+	if c.OomKillDisable {
+		memory.DisableOOMKiller = BoolToPtr(true)
+	}
+	if *memory != (OCIMemory{}) {
+		linux.Resources.Memory = memory
+	}
+
+	// cpus / cpu-* / cpuset-* -> linux.resources.cpu
+	cpu := &OCICPU{
+		Cpus: c.CpusetCpus,
+		Mems: c.CpusetMems,
+	}
+	if c.Cpus > 0 {
+		if c.CpuPeriod > 0 || c.CpuQuota > 0 {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --cpus with --cpu-period or --cpu-quota in oci runtime config as the options conflict"))
+		}
+		cpu.Period = Uint64ToPtr(100000)
+		cpu.Quota = Int64ToPtr(int64(math.Round(float64(engineAPINanoCPUs(c.Cpus)) / 1e9 * 100000)))
+	}
+	if c.CpuPeriod > 0 {
+		cpu.Period = Uint64ToPtr(uint64(c.CpuPeriod))
+	}
+	if c.CpuQuota > 0 {
+		cpu.Quota = Int64ToPtr(int64(c.CpuQuota))
+	}
+	if c.CpuShares > 0 {
+		cpu.Shares = Uint64ToPtr(uint64(c.CpuShares))
+	}
+	if c.CpuRtPeriod > 0 {
+		cpu.RealtimePeriod = Uint64ToPtr(uint64(c.CpuRtPeriod))
+	}
+	if c.CpuRtRuntime > 0 {
+		cpu.RealtimeRuntime = Int64ToPtr(int64(c.CpuRtRuntime))
+	}
+	if *cpu != (OCICPU{}) {
+		linux.Resources.CPU = cpu
+	}
+
+	// pids-limit -> linux.resources.pids
+	if c.PidsLimit != 0 {
+		linux.Resources.Pids = &OCIPids{Limit: int64(c.PidsLimit)}
+	}
+
+	// blkio-weight / blkio-weight-device / device-* -> linux.resources.blockIO
+	blockIO := &OCIBlockIO{}
+	if c.BlkioWeight > 0 {
+		weight := uint16(c.BlkioWeight)
+		blockIO.Weight = &weight
+	}
+	for _, value := range c.BlkioWeightDevice {
+		device, rate := ociRuntimeDeviceLimit(value)
+		number, err := strconv.ParseUint(rate, 10, 16)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --blkio-weight-device flag: %w", err))
+			continue
+		}
+		major, minor, placeholder, err := ociRuntimeBlockDevice(device)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --blkio-weight-device flag: %w", err))
+			continue
+		}
+		if placeholder {
+			warnings = multierror.Append(warnings, fmt.Errorf("--blkio-weight-device %s is set on the placeholder device number %d:%d in oci runtime config, replace it with the major and minor number of %s on the target host", value, major, minor, device))
+		}
+		weight := uint16(number)
+		blockIO.WeightDevice = append(blockIO.WeightDevice, OCIWeightDevice{
+			Major:  major,
+			Minor:  minor,
+			Weight: &weight,
+		})
+	}
+	for _, throttle := range []struct {
+		flag    string
+		values  []string
+		devices *[]OCIThrottleDevice
+		parse   func(string) (int64, error)
+	}{
+		{"device-read-bps", c.DeviceReadBps, &blockIO.ThrottleReadBpsDevice, units.RAMInBytes},
+		{"device-write-bps", c.DeviceWriteBps, &blockIO.ThrottleWriteBpsDevice, units.RAMInBytes},
+		{"device-read-iops", c.DeviceReadIops, &blockIO.ThrottleReadIOPSDevice, engineAPIParseRate},
+		{"device-write-iops", c.DeviceWriteIops, &blockIO.ThrottleWriteIOPSDevice, engineAPIParseRate},
+	} {
+		for _, value := range throttle.values {
+			device, rate := ociRuntimeDeviceLimit(value)
+			number, err := throttle.parse(rate)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --%s flag: %w", throttle.flag, err))
+				continue
+			}
+			major, minor, placeholder, err := ociRuntimeBlockDevice(device)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --%s flag: %w", throttle.flag, err))
+				continue
+			}
+			if placeholder {
+				warnings = multierror.Append(warnings, fmt.Errorf("--%s %s is set on the placeholder device number %d:%d in oci runtime config, replace it with the major and minor number of %s on the target host", throttle.flag, value, major, minor, device))
+			}
+			*throttle.devices = append(*throttle.devices, OCIThrottleDevice{
+				Major: major,
+				Minor: minor,
+				Rate:  uint64(number),
+			})
+		}
+	}
+	if blockIO.Weight != nil || len(blockIO.WeightDevice) > 0 || len(blockIO.ThrottleReadBpsDevice) > 0 || len(blockIO.ThrottleWriteBpsDevice) > 0 || len(blockIO.ThrottleReadIOPSDevice) > 0 || len(blockIO.ThrottleWriteIOPSDevice) > 0 {
+		linux.Resources.BlockIO = blockIO
+	}
+
+	// label / annotation -> annotations
+	for _, values := range [][]string{c.Label, c.Annotation} {
+		for _, value := range values {
+			if spec.Annotations == nil {
+				spec.Annotations = map[string]string{}
+			}
+			key, val := extractParts(value, "=")
+			spec.Annotations[key] = val
+		}
+	}
+
+	// every other flag has no runtime-spec equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if ociRuntimeFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in oci runtime config as the property is not supported", flag.Name))
+	}
+
+	return spec, warnings, errs
+}
+
+// MarshalOCIRuntime marshals an OCI runtime-spec config.json file
+func MarshalOCIRuntime(spec *OCIRuntimeSpec) ([]byte, error) {
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// ociRuntimeCapabilitySet applies --cap-add and --cap-drop to the default
+// capabilities the way docker does: ALL in --cap-add grants every capability,
+// ALL in --cap-drop starts from none, and adds win over drops
+func ociRuntimeCapabilitySet(capAdd []string, capDrop []string, privileged bool) ([]string, error) {
+	var errs *multierror.Error
+	known := map[string]bool{}
+	for _, capability := range ociRuntimeCapabilities {
+		known[capability] = true
+	}
+	normalize := func(values []string) (map[string]bool, bool) {
+		set := map[string]bool{}
+		all := false
+		for _, value := range values {
+			capability := strings.ToUpper(value)
+			if capability == "ALL" {
+				all = true
+				continue
+			}
+			if !strings.HasPrefix(capability, "CAP_") {
+				capability = "CAP_" + capability
+			}
+			if !known[capability] {
+				errs = multierror.Append(errs, fmt.Errorf("unknown capability %q", value))
+				continue
+			}
+			set[capability] = true
+		}
+		return set, all
+	}
+	adds, addAll := normalize(capAdd)
+	drops, dropAll := normalize(capDrop)
+
+	if privileged || addAll {
+		return ociRuntimeCapabilities, errs.ErrorOrNil()
+	}
+
+	capabilities := []string{}
+	granted := map[string]bool{}
+	if !dropAll {
+		for _, capability := range ociRuntimeDefaultCapabilities {
+			if !drops[capability] || adds[capability] {
+				capabilities = append(capabilities, capability)
+				granted[capability] = true
+			}
+		}
+	}
+	for _, capability := range ociRuntimeCapabilities {
+		if adds[capability] && !granted[capability] {
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	return capabilities, errs.ErrorOrNil()
+}
+
+// ociRuntimeDeviceLimit splits a --blkio-weight-device or --device-* value
+// into the device and the limit, which follows the last colon
+func ociRuntimeDeviceLimit(value string) (string, string) {
+	index := strings.LastIndex(value, ":")
+	if index == -1 {
+		return value, ""
+	}
+
+	return value[:index], value[index+1:]
+}
+
+// ociRuntimeBlockDevice returns the device number of a --blkio-weight-device
+// or --device-* device. Device paths are not resolved, as the numbers differ
+// between hosts, so a path returns the placeholder number 0:0 and true. 0:0 is
+// not a block device, so the runtime fails to apply a limit that was not
+// replaced instead of throttling the wrong device.
+func ociRuntimeBlockDevice(device string) (int64, int64, bool, error) {
+	if strings.HasPrefix(device, "/dev/") {
+		return 0, 0, true, nil
+	}
+
+	major, minor, ok := ociRuntimeDeviceNumbers(device)
+	if !ok {
+		return 0, 0, false, fmt.Errorf("bad format for device path: %s", device)
+	}
+
+	return major, minor, false, nil
+}
+
+// ociRuntimeDeviceNumbers parses a MAJOR:MINOR device number
+func ociRuntimeDeviceNumbers(device string) (int64, int64, bool) {
+	majorValue, minorValue := extractParts(device, ":")
+	major, err := strconv.ParseInt(majorValue, 10, 64)
+	if err != nil || major < 0 {
+		return 0, 0, false
+	}
+	minor, err := strconv.ParseInt(minorValue, 10, 64)
+	if err != nil || minor < 0 {
+		return 0, 0, false
+	}
+
+	return major, minor, true
+}
+
+// ociRuntimeBindOptions returns the options of a bind mount, defaulting to a
+// recursive, read-write, private bind as docker does
+func ociRuntimeBindOptions(options []string) []string {
+	access := "rw"
+	propagation := "rprivate"
+	extra := []string{}
+	for _, option := range options {
+		switch option {
+		case "ro", "rw":
+			access = option
+		case "private", "rprivate", "shared", "rshared", "slave", "rslave":
+			propagation = option
+		case "nocopy", "":
+		default:
+			extra = append(extra, option)
+		}
+	}
+
+	return append([]string{"rbind", access, propagation}, extra...)
+}
+
+// ociRuntimeTmpfsMount returns a tmpfs mount with the noexec, nosuid and
+// nodev options docker sets, unless exec is requested
+func ociRuntimeTmpfsMount(target string, options []string) OCIMount {
+	mountOptions := []string{"nosuid", "nodev"}
+	exec := false
+	extra := []string{}
+	for _, option := range options {
+		switch option {
+		case "exec":
+			exec = true
+		case "noexec", "nosuid", "nodev", "":
+		default:
+			extra = append(extra, option)
+		}
+	}
+	if !exec {
+		mountOptions = append(mountOptions, "noexec")
+	}
+
+	return OCIMount{
+		Destination: target,
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     append(mountOptions, extra...),
+	}
+}
@@ -0,0 +1,270 @@
+package convert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"docker-run-export/arguments"
+)
+
+// TestOCIRuntimeCapabilitySet verifies that --cap-add and --cap-drop are
+// applied to the default capabilities the way docker applies them.
+func TestOCIRuntimeCapabilitySet(t *testing.T) {
+	tests := []struct {
+		capAdd     []string
+		capDrop    []string
+		privileged bool
+		want       []string
+		wantErr    bool
+	}{
+		{nil, nil, false, ociRuntimeDefaultCapabilities, false},
+		{nil, nil, true, ociRuntimeCapabilities, false},
+		{[]string{"ALL"}, nil, false, ociRuntimeCapabilities, false},
+		{[]string{"net_bind_service"}, []string{"ALL"}, false, []string{"CAP_NET_BIND_SERVICE"}, false},
+		{[]string{"CAP_CHOWN"}, []string{"chown"}, false, ociRuntimeDefaultCapabilities, false},
+		{[]string{"NET_ADMIN"}, []string{"ALL"}, false, []string{"CAP_NET_ADMIN"}, false},
+		{[]string{"NOT_A_CAPABILITY"}, nil, false, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ociRuntimeCapabilitySet(tt.capAdd, tt.capDrop, tt.privileged)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ociRuntimeCapabilitySet(%v, %v, %v) expected an error", tt.capAdd, tt.capDrop, tt.privileged)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ociRuntimeCapabilitySet(%v, %v, %v) returned an error: %v", tt.capAdd, tt.capDrop, tt.privileged, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ociRuntimeCapabilitySet(%v, %v, %v) = %v, want %v", tt.capAdd, tt.capDrop, tt.privileged, got, tt.want)
+		}
+	}
+}
+
+// TestOCIRuntimeBlockDevice verifies that device paths, the only form docker
+// accepts, are set on a placeholder number rather than resolved, so the config
+// does not depend on the exporting host.
+func TestOCIRuntimeBlockDevice(t *testing.T) {
+	tests := []struct {
+		value           string
+		wantMajor       int64
+		wantMinor       int64
+		wantRate        string
+		wantPlaceholder bool
+		wantErr         bool
+	}{
+		{"/dev/sda:1mb", 0, 0, "1mb", true, false},
+		{"/dev/nvme0n1:300", 0, 0, "300", true, false},
+		{"/dev/disk/by-id/wwn-0x5000:1048576", 0, 0, "1048576", true, false},
+		{"8:0:1mb", 8, 0, "1mb", false, false},
+		{"253:16:300", 253, 16, "300", false, false},
+		{"8:-1:1mb", 0, 0, "1mb", false, true},
+		{"sda:1mb", 0, 0, "1mb", false, true},
+	}
+
+	for _, tt := range tests {
+		device, rate := ociRuntimeDeviceLimit(tt.value)
+		if rate != tt.wantRate {
+			t.Errorf("ociRuntimeDeviceLimit(%q) rate = %q, want %q", tt.value, rate, tt.wantRate)
+		}
+		major, minor, placeholder, err := ociRuntimeBlockDevice(device)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ociRuntimeBlockDevice(%q) expected an error", device)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ociRuntimeBlockDevice(%q) returned an error: %v", device, err)
+			continue
+		}
+		if major != tt.wantMajor || minor != tt.wantMinor || placeholder != tt.wantPlaceholder {
+			t.Errorf("ociRuntimeBlockDevice(%q) = %d, %d, %v, want %d, %d, %v", device, major, minor, placeholder, tt.wantMajor, tt.wantMinor, tt.wantPlaceholder)
+		}
+	}
+}
+
+// TestOCIRuntimeBindOptions verifies that bind mounts default to a recursive,
+// read-write, private bind as docker does.
+func TestOCIRuntimeBindOptions(t *testing.T) {
+	tests := []struct {
+		options []string
+		want    []string
+	}{
+		{nil, []string{"rbind", "rw", "rprivate"}},
+		{[]string{"ro"}, []string{"rbind", "ro", "rprivate"}},
+		{[]string{"ro", "rshared"}, []string{"rbind", "ro", "rshared"}},
+		{[]string{"nocopy", ""}, []string{"rbind", "rw", "rprivate"}},
+		{[]string{"rw", "noexec"}, []string{"rbind", "rw", "rprivate", "noexec"}},
+	}
+
+	for _, tt := range tests {
+		if got := ociRuntimeBindOptions(tt.options); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ociRuntimeBindOptions(%v) = %v, want %v", tt.options, got, tt.want)
+		}
+	}
+}
+
+// TestOCIRuntimeTmpfsMount verifies that tmpfs mounts get the noexec, nosuid
+// and nodev options docker sets, unless exec is requested.
+func TestOCIRuntimeTmpfsMount(t *testing.T) {
+	tests := []struct {
+		options []string
+		want    []string
+	}{
+		{nil, []string{"nosuid", "nodev", "noexec"}},
+		{[]string{""}, []string{"nosuid", "nodev", "noexec"}},
+		{[]string{"exec"}, []string{"nosuid", "nodev"}},
+		{[]string{"size=64m", "mode=1777"}, []string{"nosuid", "nodev", "noexec", "size=64m", "mode=1777"}},
+		{[]string{"noexec", "nosuid"}, []string{"nosuid", "nodev", "noexec"}},
+	}
+
+	for _, tt := range tests {
+		got := ociRuntimeTmpfsMount("/tmp", tt.options)
+		want := OCIMount{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs", Options: tt.want}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ociRuntimeTmpfsMount(%v) = %+v, want %+v", tt.options, got, want)
+		}
+	}
+}
+
+// TestToOCIRuntime verifies that the parsed docker run flags are mapped to the
+// runtime config, and that the settings the docker daemon handles warn.
+func TestToOCIRuntime(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        arguments.Args
+		command     []string
+		field       func(*OCIRuntimeSpec) interface{}
+		want        interface{}
+		wantWarning string
+		wantErr     bool
+	}{
+		{
+			name:    "entrypoint and command",
+			args:    arguments.Args{Entrypoint: "/bin/sh -c"},
+			command: []string{"echo hi"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Process.Args },
+			want:    []string{"/bin/sh", "-c", "echo hi"},
+		},
+		{
+			name:    "cleared entrypoint runs the command",
+			args:    arguments.Args{EntrypointCleared: true},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Process.Args },
+			want:    []string{"sh"},
+		},
+		{
+			name:        "unknown image command",
+			args:        arguments.Args{},
+			wantWarning: "unable to set process.args in oci runtime config as the image command is not known",
+		},
+		{
+			name:    "bind volume",
+			args:    arguments.Args{Volume: []string{"/srv/data:/data:ro"}},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Mounts[len(s.Mounts)-1] },
+			want:    OCIMount{Destination: "/data", Type: "bind", Source: "/srv/data", Options: []string{"rbind", "ro", "rprivate"}},
+		},
+		{
+			name:        "named volume",
+			args:        arguments.Args{Volume: []string{"data:/data"}},
+			command:     []string{"sh"},
+			wantWarning: "unable to set --volume data:/data in oci runtime config as volumes are created by the docker daemon",
+		},
+		{
+			name:    "bind mount",
+			args:    arguments.Args{Mount: []string{"type=bind,source=/srv,target=/srv,readonly,bind-propagation=rslave"}},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Mounts[len(s.Mounts)-1] },
+			want:    OCIMount{Destination: "/srv", Type: "bind", Source: "/srv", Options: []string{"rbind", "ro", "rslave"}},
+		},
+		{
+			name:    "tmpfs",
+			args:    arguments.Args{Tmpfs: []string{"/run:exec,size=64m"}},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Mounts[len(s.Mounts)-1] },
+			want:    OCIMount{Destination: "/run", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "nodev", "size=64m"}},
+		},
+		{
+			name:        "published ports",
+			args:        arguments.Args{Publish: []string{"8080:80"}},
+			command:     []string{"sh"},
+			wantWarning: "unable to set --publish and --expose properties in oci runtime config",
+		},
+		{
+			name:        "restart",
+			args:        arguments.Args{Restart: "always"},
+			command:     []string{"sh"},
+			wantWarning: "unable to set --restart property in oci runtime config",
+		},
+		{
+			name:    "device read rate on a device path",
+			args:    arguments.Args{DeviceReadBps: []string{"/dev/sda:1mb"}},
+			command: []string{"sh"},
+			field: func(s *OCIRuntimeSpec) interface{} {
+				return s.Linux.Resources.BlockIO.ThrottleReadBpsDevice
+			},
+			want:        []OCIThrottleDevice{{Major: 0, Minor: 0, Rate: 1048576}},
+			wantWarning: "--device-read-bps /dev/sda:1mb is set on the placeholder device number 0:0",
+		},
+		{
+			name:    "invalid device rate",
+			args:    arguments.Args{DeviceWriteBps: []string{"sda:1mb"}},
+			command: []string{"sh"},
+			wantErr: true,
+		},
+		{
+			name:    "capabilities",
+			args:    arguments.Args{CapAdd: []string{"NET_ADMIN"}, CapDrop: []string{"ALL"}},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Process.Capabilities.Bounding },
+			want:    []string{"CAP_NET_ADMIN"},
+		},
+		{
+			name:    "unknown capability",
+			args:    arguments.Args{CapAdd: []string{"NOT_A_CAPABILITY"}},
+			command: []string{"sh"},
+			wantErr: true,
+		},
+		{
+			name:    "no-new-privileges disabled",
+			args:    arguments.Args{SecurityOpt: []string{"no-new-privileges=false"}},
+			command: []string{"sh"},
+			field:   func(s *OCIRuntimeSpec) interface{} { return s.Process.NoNewPrivileges },
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := withDefaults(tt.args)
+			if len(tt.args.Restart) > 0 {
+				args.Restart = tt.args.Restart
+			}
+			output, warnings, errs := ToOCIRuntime("", args, makeArgs("alpine:3.20", tt.command...))
+			if tt.wantErr {
+				if errs.ErrorOrNil() == nil {
+					t.Errorf("ToOCIRuntime() returned no error")
+				}
+				return
+			}
+			if errs.ErrorOrNil() != nil {
+				t.Fatalf("ToOCIRuntime() returned errors: %v", errs)
+			}
+			if len(tt.wantWarning) > 0 && (warnings.ErrorOrNil() == nil || !strings.Contains(warnings.Error(), tt.wantWarning)) {
+				t.Errorf("ToOCIRuntime() warnings = %v, want %q", warnings, tt.wantWarning)
+			}
+			if tt.field == nil {
+				return
+			}
+			if got := tt.field(output.(*OCIRuntimeSpec)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToOCIRuntime() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
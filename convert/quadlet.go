@@ -0,0 +1,541 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// ToQuadlet converts docker run arguments to a Podman Quadlet `.container`
+// unit. Flags without a Quadlet key are passed to podman via PodmanArgs=.
+func ToQuadlet(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	image := arguments["image"].StringValue()
+
+	unit := &SystemdUnit{}
+	description := projectName
+	if len(description) == 0 {
+		description = c.ContainerName
+	}
+	if len(description) == 0 {
+		description = image
+	}
+	unit.Section("Unit").Add("Description", description)
+
+	container := unit.Section("Container")
+	container.Add("Image", image)
+
+	// name -> ContainerName
+	if len(c.ContainerName) > 0 {
+		container.Add("ContainerName", c.ContainerName)
+	}
+
+	// add-host -> AddHost
+	for _, value := range c.AddHost {
+		container.Add("AddHost", value)
+	}
+
+	// annotation -> Annotation
+	for _, value := range c.Annotation {
+		container.Add("Annotation", systemdQuote(value))
+	}
+
+	// unsupported: attach
+	if len(c.Attach) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --attach property in quadlet unit as quadlet containers always run detached"))
+	}
+
+	// blkio-weight -> PodmanArgs
+	if c.BlkioWeight != 0 {
+		container.Add("PodmanArgs", podmanArg("blkio-weight", strconv.Itoa(c.BlkioWeight)))
+	}
+
+	// blkio-weight-device -> PodmanArgs
+	for _, value := range c.BlkioWeightDevice {
+		container.Add("PodmanArgs", podmanArg("blkio-weight-device", value))
+	}
+
+	// cap-add -> AddCapability
+	for _, value := range c.CapAdd {
+		container.Add("AddCapability", value)
+	}
+
+	// cap-drop -> DropCapability
+	for _, value := range c.CapDrop {
+		container.Add("DropCapability", value)
+	}
+
+	// cgroupns -> PodmanArgs
+	if len(c.Cgroupns) > 0 {
+		container.Add("PodmanArgs", podmanArg("cgroupns", c.Cgroupns))
+	}
+
+	// cgroup-parent -> PodmanArgs
+	if len(c.CgroupParent) > 0 {
+		container.Add("PodmanArgs", podmanArg("cgroup-parent", c.CgroupParent))
+	}
+
+	// unsupported: cidfile
+	if len(c.Cidfile) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --cidfile property in quadlet unit as quadlet manages the cidfile of the container"))
+	}
+
+	// cpu-period -> PodmanArgs
+	if c.CpuPeriod > 0 {
+		container.Add("PodmanArgs", podmanArg("cpu-period", strconv.Itoa(c.CpuPeriod)))
+	}
+
+	// cpu-quota -> PodmanArgs
+	if c.CpuQuota > 0 {
+		container.Add("PodmanArgs", podmanArg("cpu-quota", strconv.Itoa(c.CpuQuota)))
+	}
+
+	// cpu-rt-period -> PodmanArgs
+	if c.CpuRtPeriod > 0 {
+		container.Add("PodmanArgs", podmanArg("cpu-rt-period", strconv.Itoa(c.CpuRtPeriod)))
+	}
+
+	// cpu-rt-runtime -> PodmanArgs
+	if c.CpuRtRuntime > 0 {
+		container.Add("PodmanArgs", podmanArg("cpu-rt-runtime", strconv.Itoa(c.CpuRtRuntime)))
+	}
+
+	// cpus -> PodmanArgs
+	if c.Cpus > 0 {
+		container.Add("PodmanArgs", podmanArg("cpus", strconv.FormatFloat(float64(c.Cpus), 'f', -1, 32)))
+	}
+
+	// cpu-shares -> PodmanArgs
+	if c.CpuShares > 0 {
+		container.Add("PodmanArgs", podmanArg("cpu-shares", strconv.Itoa(c.CpuShares)))
+	}
+
+	// cpuset-cpus -> PodmanArgs
+	if len(c.CpusetCpus) > 0 {
+		container.Add("PodmanArgs", podmanArg("cpuset-cpus", c.CpusetCpus))
+	}
+
+	// cpuset-mems -> PodmanArgs
+	if len(c.CpusetMems) > 0 {
+		container.Add("PodmanArgs", podmanArg("cpuset-mems", c.CpusetMems))
+	}
+
+	// detach: quadlet containers always run detached
+
+	// unsupported: detach-keys
+	if len(c.DetachKeys) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --detach-keys property in quadlet unit as quadlet containers always run detached"))
+	}
+
+	// device -> AddDevice
+	for _, value := range c.Device {
+		container.Add("AddDevice", value)
+	}
+
+	// device-cgroup-rule -> PodmanArgs
+	for _, value := range c.DeviceCgroupRule {
+		container.Add("PodmanArgs", podmanArg("device-cgroup-rule", value))
+	}
+
+	// device-read-bps -> PodmanArgs
+	for _, value := range c.DeviceReadBps {
+		container.Add("PodmanArgs", podmanArg("device-read-bps", value))
+	}
+
+	// device-read-iops -> PodmanArgs
+	for _, value := range c.DeviceReadIops {
+		container.Add("PodmanArgs", podmanArg("device-read-iops", value))
+	}
+
+	// device-write-bps -> PodmanArgs
+	for _, value := range c.DeviceWriteBps {
+		container.Add("PodmanArgs", podmanArg("device-write-bps", value))
+	}
+
+	// device-write-iops -> PodmanArgs
+	for _, value := range c.DeviceWriteIops {
+		container.Add("PodmanArgs", podmanArg("device-write-iops", value))
+	}
+
+	// disable-content-trust -> PodmanArgs
+	if !c.DisableContentTrust {
+		container.Add("PodmanArgs", podmanArg("disable-content-trust", "false"))
+	}
+
+	// dns -> DNS
+	for _, value := range c.Dns {
+		container.Add("DNS", value)
+	}
+
+	// dns-option -> DNSOption
+	for _, value := range c.DnsOption {
+		container.Add("DNSOption", value)
+	}
+
+	// dns-search -> DNSSearch
+	for _, value := range c.DnsSearch {
+		container.Add("DNSSearch", value)
+	}
+
+	// unsupported: domainname
+	if len(c.Domainname) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --domainname property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// entrypoint -> Entrypoint
+	if len(c.Entrypoint) > 0 {
+		container.Add("Entrypoint", c.Entrypoint)
+	} else if c.EntrypointCleared {
+		// podman reads a JSON array as the entrypoint, and an empty array
+		// clears the image entrypoint
+		container.Add("Entrypoint", "[]")
+	}
+
+	// env -> Environment
+	for _, value := range c.Env {
+		if !strings.Contains(value, "=") {
+			container.Add("PodmanArgs", podmanArg("env", value))
+			continue
+		}
+		container.Add("Environment", systemdQuote(value))
+	}
+
+	// env-file -> EnvironmentFile
+	for _, value := range c.EnvFile {
+		container.Add("EnvironmentFile", value)
+	}
+
+	// expose -> ExposeHostPort
+	for _, value := range c.Expose {
+		container.Add("ExposeHostPort", value)
+	}
+
+	// gpus -> PodmanArgs
+	if len(c.Gpus) > 0 {
+		container.Add("PodmanArgs", podmanArg("gpus", c.Gpus))
+	}
+
+	// group-add -> GroupAdd
+	for _, value := range c.GroupAdd {
+		container.Add("GroupAdd", value)
+	}
+
+	// health-cmd / health-* -> HealthCmd / Health*
+	if len(c.HealthCmd) > 0 {
+		container.Add("HealthCmd", c.HealthCmd)
+	}
+	if c.HealthInterval != "0s" {
+		container.Add("HealthInterval", c.HealthInterval)
+	}
+	if c.HealthRetries > 0 {
+		container.Add("HealthRetries", strconv.FormatUint(c.HealthRetries, 10))
+	}
+	if c.HealthStartPeriod != "0s" {
+		container.Add("HealthStartPeriod", c.HealthStartPeriod)
+	}
+	if c.HealthTimeout != "0s" {
+		container.Add("HealthTimeout", c.HealthTimeout)
+	}
+
+	// hostname -> HostName
+	if len(c.Hostname) > 0 {
+		container.Add("HostName", c.Hostname)
+	}
+
+	// init -> RunInit
+	if c.Init {
+		container.Add("RunInit", "true")
+	}
+
+	// interactive -> PodmanArgs
+	if c.Interactive {
+		container.Add("PodmanArgs", "--interactive")
+	}
+
+	// ip -> IP
+	if len(c.Ip) > 0 {
+		container.Add("IP", c.Ip)
+	}
+
+	// ip6 -> IP6
+	if len(c.Ip6) > 0 {
+		container.Add("IP6", c.Ip6)
+	}
+
+	// ipc -> PodmanArgs
+	if len(c.Ipc) > 0 {
+		container.Add("PodmanArgs", podmanArg("ipc", c.Ipc))
+	}
+
+	// unsupported: isolation
+	if len(c.Isolation) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --isolation property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// unsupported: kernel-memory
+	if c.KernelMemory != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --kernel-memory property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// label -> Label
+	for _, value := range c.Label {
+		container.Add("Label", systemdQuote(value))
+	}
+
+	// label-file -> PodmanArgs
+	for _, value := range c.LabelFile {
+		container.Add("PodmanArgs", podmanArg("label-file", value))
+	}
+
+	// unsupported: link
+	if len(c.Link) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --link property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// unsupported: link-local-ip
+	if len(c.LinkLocalIP) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --link-local-ip property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// log-driver -> LogDriver
+	if len(c.LogDriver) > 0 {
+		container.Add("LogDriver", c.LogDriver)
+	}
+
+	// log-opt -> LogOpt
+	for _, value := range c.LogOpt {
+		container.Add("LogOpt", value)
+	}
+
+	// mac-address -> PodmanArgs
+	if len(c.Mac) > 0 {
+		container.Add("PodmanArgs", podmanArg("mac-address", c.Mac))
+	}
+
+	// memory -> PodmanArgs
+	if c.Memory > 0 {
+		container.Add("PodmanArgs", podmanArg("memory", strconv.FormatInt(c.Memory, 10)))
+	}
+
+	// memory-reservation -> PodmanArgs
+	if c.MemoryReservation > 0 {
+		container.Add("PodmanArgs", podmanArg("memory-reservation", strconv.FormatInt(c.MemoryReservation, 10)))
+	}
+
+	// memory-swap -> PodmanArgs
+	if c.MemorySwap != 0 {
+		container.Add("PodmanArgs", podmanArg("memory-swap", strconv.FormatInt(c.MemorySwap, 10)))
+	}
+
+	// memory-swappiness -> PodmanArgs
+	if c.MemorySwappiness > 0 {
+		container.Add("PodmanArgs", podmanArg("memory-swappiness", strconv.FormatInt(c.MemorySwappiness, 10)))
+	}
+
+	// mount -> Mount
+	for _, value := range c.Mount {
+		container.Add("Mount", value)
+	}
+
+	// network -> Network
+	if len(c.Network) > 0 {
+		container.Add("Network", c.Network)
+	}
+
+	// network-alias -> NetworkAlias
+	for _, value := range c.NetworkAlias {
+		container.Add("NetworkAlias", value)
+	}
+
+	// no-healthcheck -> PodmanArgs
+	if c.NoHealthcheck {
+		container.Add("PodmanArgs", "--no-healthcheck")
+	}
+
+	// oom-kill-disable -> PodmanArgs
+	if c.OomKillDisable {
+		container.Add("PodmanArgs", "--oom-kill-disable")
+	}
+
+	// oom-score-adj -> PodmanArgs
+	if c.OomScore != 0 {
+		container.Add("PodmanArgs", podmanArg("oom-score-adj", strconv.Itoa(c.OomScore)))
+	}
+
+	// pid -> PodmanArgs
+	if len(c.Pid) > 0 {
+		container.Add("PodmanArgs", podmanArg("pid", c.Pid))
+	}
+
+	// pids-limit -> PidsLimit
+	if c.PidsLimit != 0 {
+		container.Add("PidsLimit", strconv.Itoa(c.PidsLimit))
+	}
+
+	// platform -> PodmanArgs
+	if len(c.Platform) > 0 {
+		container.Add("PodmanArgs", podmanArg("platform", c.Platform))
+	}
+
+	// privileged -> PodmanArgs
+	if c.Privileged {
+		container.Add("PodmanArgs", "--privileged")
+	}
+
+	// publish -> PublishPort
+	for _, value := range c.Publish {
+		container.Add("PublishPort", value)
+	}
+
+	// publish-all -> PodmanArgs
+	if c.PublishAll {
+		container.Add("PodmanArgs", "--publish-all")
+	}
+
+	// pull -> Pull
+	if len(c.Pull) > 0 && c.Pull != "missing" {
+		container.Add("Pull", c.Pull)
+	}
+
+	// read-only -> ReadOnly
+	if c.ReadOnly {
+		container.Add("ReadOnly", "true")
+	}
+
+	// rm: quadlet always removes the container when the service stops
+
+	// runtime -> PodmanArgs
+	if len(c.Runtime) > 0 {
+		container.Add("PodmanArgs", podmanArg("runtime", c.Runtime))
+	}
+
+	// security-opt -> NoNewPrivileges / SeccompProfile / SecurityLabelDisable / PodmanArgs
+	for _, value := range c.SecurityOpt {
+		key, option := extractParts(value, "=")
+		if enabled, ok, err := noNewPrivileges(value); ok {
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			container.Add("NoNewPrivileges", strconv.FormatBool(enabled))
+		} else if key == "seccomp" {
+			container.Add("SeccompProfile", option)
+		} else if value == "label=disable" || value == "label:disable" {
+			container.Add("SecurityLabelDisable", "true")
+		} else {
+			container.Add("PodmanArgs", podmanArg("security-opt", value))
+		}
+	}
+
+	// shm-size -> ShmSize
+	if c.ShmSize != 0 {
+		container.Add("ShmSize", strconv.Itoa(c.ShmSize))
+	}
+
+	// sig-proxy -> PodmanArgs
+	if !c.SigProxy {
+		container.Add("PodmanArgs", podmanArg("sig-proxy", "false"))
+	}
+
+	// stop-signal -> StopSignal
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		container.Add("StopSignal", c.StopSignal)
+	}
+
+	// stop-timeout -> StopTimeout
+	if c.StopTimeout > 0 {
+		container.Add("StopTimeout", strconv.Itoa(c.StopTimeout))
+	}
+
+	// storage-opt -> PodmanArgs
+	for _, value := range c.StorageOpt {
+		container.Add("PodmanArgs", podmanArg("storage-opt", value))
+	}
+
+	// sysctl -> Sysctl
+	for _, key := range sortedKeys(c.Sysctl) {
+		container.Add("Sysctl", fmt.Sprintf("%s=%s", key, c.Sysctl[key]))
+	}
+
+	// tmpfs -> Tmpfs
+	for _, value := range c.Tmpfs {
+		container.Add("Tmpfs", value)
+	}
+
+	// tty -> PodmanArgs
+	if c.Tty {
+		container.Add("PodmanArgs", "--tty")
+	}
+
+	// ulimit -> Ulimit
+	for _, value := range c.Ulimit {
+		container.Add("Ulimit", value)
+	}
+
+	// user -> User / Group
+	if len(c.User) > 0 {
+		user, group := extractParts(c.User, ":")
+		container.Add("User", user)
+		if len(group) > 0 {
+			container.Add("Group", group)
+		}
+	}
+
+	// userns -> UserNS
+	if len(c.Userns) > 0 {
+		container.Add("UserNS", c.Userns)
+	}
+
+	// uts -> PodmanArgs
+	if len(c.Uts) > 0 {
+		container.Add("PodmanArgs", podmanArg("uts", c.Uts))
+	}
+
+	// volume -> Volume
+	for _, value := range c.Volume {
+		container.Add("Volume", value)
+	}
+
+	// unsupported: volume-driver
+	if len(c.VolumeDriver) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --volume-driver property in quadlet unit as the property is not supported by podman"))
+	}
+
+	// volumes-from -> PodmanArgs
+	for _, value := range c.VolumesFrom {
+		container.Add("PodmanArgs", podmanArg("volumes-from", value))
+	}
+
+	// workdir -> WorkingDir
+	if len(c.Workdir) > 0 {
+		container.Add("WorkingDir", c.Workdir)
+	}
+
+	// command -> Exec
+	if len(arguments["command"].ListValue()) > 0 {
+		container.Add("Exec", systemdJoin(arguments["command"].ListValue()))
+	}
+
+	// restart -> Restart / StartLimitIntervalSec / StartLimitBurst
+	restart, burst, err := systemdRestart(c.Restart)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	systemdStartLimit(unit, burst)
+	unit.Section("Service").Add("Restart", restart)
+
+	unit.Section("Install").Add("WantedBy", "default.target")
+
+	return unit, warnings, errs
+}
+
+// podmanArg formats a flag without a Quadlet key as a PodmanArgs= value
+func podmanArg(flag string, value string) string {
+	return systemdQuote(fmt.Sprintf("--%s=%s", flag, value))
+}
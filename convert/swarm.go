@@ -0,0 +1,613 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"gopkg.in/yaml.v2"
+)
+
+// swarmStackVersion holds the compose file version written to swarm stack
+// files, as docker stack deploy requires one
+const swarmStackVersion = "3.8"
+
+// swarmUnsupportedFlags holds the docker run flags whose properties are
+// rejected or ignored by docker stack deploy and docker service create
+var swarmUnsupportedFlags = map[string]bool{
+	"annotation":          true,
+	"blkio-weight":        true,
+	"blkio-weight-device": true,
+	"cgroup-parent":       true,
+	"cgroupns":            true,
+	"cpu-period":          true,
+	"cpu-quota":           true,
+	"cpu-rt-period":       true,
+	"cpu-rt-runtime":      true,
+	"cpu-shares":          true,
+	"cpuset-cpus":         true,
+	"cpuset-mems":         true,
+	"device":              true,
+	"device-cgroup-rule":  true,
+	"device-read-bps":     true,
+	"device-read-iops":    true,
+	"device-write-bps":    true,
+	"device-write-iops":   true,
+	"gpus":                true,
+	"ip":                  true,
+	"ip6":                 true,
+	"ipc":                 true,
+	"label-file":          true,
+	"link":                true,
+	"link-local-ip":       true,
+	"mac-address":         true,
+	"memory-swap":         true,
+	"memory-swappiness":   true,
+	"oom-kill-disable":    true,
+	"oom-score-adj":       true,
+	"pid":                 true,
+	"privileged":          true,
+	"pull":                true,
+	"runtime":             true,
+	"security-opt":        true,
+	"shm-size":            true,
+	"storage-opt":         true,
+	"userns":              true,
+	"uts":                 true,
+	"volume-driver":       true,
+	"volumes-from":        true,
+}
+
+// SwarmOptions holds the swarm specific settings
+type SwarmOptions struct {
+	// Replicas holds the number of tasks to run for the service
+	Replicas int
+}
+
+// SwarmService holds the arguments of a docker service create command
+type SwarmService struct {
+	Args []string
+}
+
+// ToSwarmStack converts docker run arguments to a compose file that can be
+// deployed with docker stack deploy
+func ToSwarmStack(projectName string, c *arguments.Args, arguments map[string]command.Argument, swarmOpts SwarmOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	project, warnings, errs := toComposeService(projectName, "app", c, arguments)
+	if len(c.ContainerName) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --name property in swarm stack as containers are named after the service"))
+	}
+	for name, service := range project.Services {
+		warnings = multierror.Append(warnings, swarmStackService(&service, c, swarmOpts))
+		project.Services[name] = service
+	}
+
+	return project, warnings, errs
+}
+
+// ToSwarmStackServices converts several docker run invocations to a single
+// compose file that can be deployed with docker stack deploy
+func ToSwarmStackServices(projectName string, containers []Container, swarmOpts SwarmOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	output, warnings, errs := ToComposeServices(projectName, containers)
+	project := output.(*types.Project)
+
+	names, resolved, _, err := resolveContainers(containers)
+	if err != nil {
+		return project, warnings, errs
+	}
+
+	for i, container := range resolved {
+		service, ok := project.Services[names[i]]
+		if !ok {
+			continue
+		}
+
+		warnings = appendContainerErrors(warnings, names[i], swarmStackService(&service, container.Args, swarmOpts))
+		project.Services[names[i]] = service
+	}
+
+	return project, warnings, errs
+}
+
+// swarmStackService moves the properties of a compose service that swarm
+// reads from the deploy section, and removes the ones it does not support
+func swarmStackService(service *types.ServiceConfig, c *arguments.Args, swarmOpts SwarmOptions) *multierror.Error {
+	warnings := swarmUnsupportedWarnings(c, "swarm stack")
+	if c.PidsLimit != 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pids-limit property in swarm stack as the property is not supported"))
+	}
+
+	// publish: the version 3 schema has no host_ip property, as published
+	// ports listen on every node of the swarm
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			continue
+		}
+		for _, p := range parsed {
+			if len(p.HostIP) > 0 {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set host ip %s of --publish %s in swarm stack as the property is not supported", p.HostIP, value))
+				break
+			}
+		}
+	}
+	for i := range service.Ports {
+		service.Ports[i].HostIP = ""
+	}
+
+	if strings.HasPrefix(service.NetworkMode, "container:") || strings.HasPrefix(service.NetworkMode, "service:") {
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network %s property in swarm stack as the property is not supported", strings.Replace(service.NetworkMode, "service:", "container:", 1)))
+	}
+
+	if service.Deploy == nil {
+		service.Deploy = &types.DeployConfig{}
+	}
+	service.Deploy.Replicas = IntToPtr(swarmOpts.Replicas)
+
+	// restart -> deploy.restart_policy
+	if len(service.Restart) > 0 {
+		mode, retries, err := parseDockerRestart(service.Restart)
+		if err != nil {
+			warnings = multierror.Append(warnings, err)
+		} else {
+			policy := &types.RestartPolicy{Condition: swarmRestartCondition(mode)}
+			if retries > 0 {
+				policy.MaxAttempts = Uint64ToPtr(uint64(retries))
+			}
+			service.Deploy.RestartPolicy = policy
+		}
+	} else {
+		// swarm restarts tasks by default, unlike docker run
+		service.Deploy.RestartPolicy = &types.RestartPolicy{Condition: "none"}
+	}
+
+	// memory-reservation -> deploy.resources.reservations
+	if service.MemReservation > 0 {
+		service.Deploy.Resources.Reservations = &types.Resource{
+			MemoryBytes: service.MemReservation,
+		}
+	}
+
+	// label -> deploy.labels, so that the label is set on the service as well
+	// as on its containers
+	if len(service.Labels) > 0 {
+		service.Deploy.Labels = types.Labels{}
+		for key, value := range service.Labels {
+			service.Deploy.Labels[key] = value
+		}
+	}
+
+	// platform -> deploy.placement.constraints
+	if len(service.Platform) > 0 {
+		service.Deploy.Placement.Constraints = swarmPlatformConstraints(service.Platform)
+	}
+
+	// log-driver / log-opt -> logging
+	if len(service.LogDriver) > 0 || len(service.LogOpt) > 0 {
+		service.Logging = &types.LoggingConfig{
+			Driver:  service.LogDriver,
+			Options: service.LogOpt,
+		}
+	}
+
+	if len(service.CapAdd) > 0 || len(service.CapDrop) > 0 {
+		warnings = multierror.Append(warnings, fmt.Errorf("--cap-add and --cap-drop require Docker Engine 20.10 or newer when deployed with docker stack deploy"))
+	}
+
+	// properties read from the deploy section
+	service.CPUS = 0
+	service.MemLimit = 0
+	service.MemReservation = 0
+	service.Restart = ""
+	service.LogDriver = ""
+	service.LogOpt = nil
+
+	// properties rejected or ignored by docker stack deploy
+	service.Annotations = nil
+	service.BlkioConfig = nil
+	service.Cgroup = ""
+	service.CgroupParent = ""
+	service.ContainerName = ""
+	service.CPUPeriod = 0
+	service.CPUQuota = 0
+	service.CPURTPeriod = 0
+	service.CPURTRuntime = 0
+	service.CPUSet = ""
+	service.CPUShares = 0
+	service.DeviceCgroupRules = nil
+	service.Devices = nil
+	service.Gpus = nil
+	service.Ipc = ""
+	service.LabelFiles = nil
+	service.Links = nil
+	service.MacAddress = ""
+	service.MemSwapLimit = 0
+	service.MemSwappiness = 0
+	service.OomKillDisable = false
+	service.OomScoreAdj = 0
+	service.Pid = ""
+	service.PidsLimit = 0
+	service.Platform = ""
+	service.Privileged = false
+	service.PullPolicy = ""
+	service.Runtime = ""
+	service.SecurityOpt = nil
+	service.ShmSize = 0
+	service.StorageOpt = nil
+	service.UserNSMode = ""
+	service.Uts = ""
+	service.VolumeDriver = ""
+	service.VolumesFrom = nil
+	if strings.HasPrefix(service.NetworkMode, "container:") || strings.HasPrefix(service.NetworkMode, "service:") {
+		service.NetworkMode = ""
+	}
+	for _, network := range service.Networks {
+		if network == nil {
+			continue
+		}
+		network.Ipv4Address = ""
+		network.Ipv6Address = ""
+		network.LinkLocalIPs = nil
+	}
+
+	return warnings
+}
+
+// ToSwarmService converts docker run arguments to the equivalent docker
+// service create command
+func ToSwarmService(projectName string, c *arguments.Args, arguments map[string]command.Argument, swarmOpts SwarmOptions) (interface{}, *multierror.Error, *multierror.Error) {
+	var errs *multierror.Error
+	warnings := swarmUnsupportedWarnings(c, "docker service create")
+
+	image := arguments["image"].StringValue()
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = projectName
+	}
+	if len(name) == 0 {
+		name = imageName(image)
+	}
+
+	flags := []dockerRunFlag{
+		{Name: "name", Value: name},
+		{Name: "replicas", Value: strconv.Itoa(swarmOpts.Replicas)},
+	}
+	for _, flag := range dockerRunFlags(c) {
+		if swarmUnsupportedFlags[flag.Name] {
+			continue
+		}
+
+		switch flag.Name {
+		case "cap-add", "cap-drop", "detach", "dns", "dns-option", "dns-search", "entrypoint", "env", "env-file",
+			"health-cmd", "health-interval", "health-retries", "health-start-period", "health-timeout",
+			"hostname", "init", "isolation", "log-driver", "log-opt", "mount", "no-healthcheck", "read-only",
+			"stop-signal", "sysctl", "tty", "ulimit", "user", "workdir":
+			flags = append(flags, flag)
+		case "add-host":
+			flags = append(flags, dockerRunFlag{Name: "host", Value: flag.Value})
+		case "cpus":
+			flags = append(flags, dockerRunFlag{Name: "limit-cpu", Value: flag.Value})
+		case "group-add":
+			flags = append(flags, dockerRunFlag{Name: "group", Value: flag.Value})
+		case "label":
+			flags = append(flags, dockerRunFlag{Name: "container-label", Value: flag.Value}, dockerRunFlag{Name: "label", Value: flag.Value})
+		case "memory":
+			flags = append(flags, dockerRunFlag{Name: "limit-memory", Value: flag.Value})
+		case "memory-reservation":
+			flags = append(flags, dockerRunFlag{Name: "reserve-memory", Value: flag.Value})
+		case "pids-limit":
+			flags = append(flags, dockerRunFlag{Name: "limit-pids", Value: flag.Value})
+		case "stop-timeout":
+			flags = append(flags, dockerRunFlag{Name: "stop-grace-period", Value: flag.Value + "s"})
+		case "name", "rm":
+			// the service name is always set, and swarm removes the containers of a service itself
+		case "network":
+			if strings.HasPrefix(flag.Value, "container:") {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network %s property in docker service create as the property is not supported", flag.Value))
+				continue
+			}
+			network := flag.Value
+			if len(c.NetworkAlias) > 0 {
+				network = fmt.Sprintf("name=%s", flag.Value)
+				for _, alias := range c.NetworkAlias {
+					network = fmt.Sprintf("%s,alias=%s", network, alias)
+				}
+			}
+			flags = append(flags, dockerRunFlag{Name: "network", Value: network})
+		case "network-alias":
+			if len(c.Network) == 0 {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias %s property in docker service create as --network is not set", flag.Value))
+			}
+		case "platform":
+			for _, constraint := range swarmPlatformConstraints(flag.Value) {
+				flags = append(flags, dockerRunFlag{Name: "constraint", Value: constraint})
+			}
+		case "publish":
+			parsed, err := types.ParsePortConfig(flag.Value)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+				continue
+			}
+			for _, p := range parsed {
+				if len(p.HostIP) > 0 {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set host ip %s of --publish %s in docker service create as the property is not supported", p.HostIP, flag.Value))
+				}
+				value := fmt.Sprintf("target=%d", p.Target)
+				if len(p.Published) > 0 {
+					value = fmt.Sprintf("published=%s,%s", p.Published, value)
+				}
+				if p.Protocol == "udp" || p.Protocol == "sctp" {
+					value = fmt.Sprintf("%s,protocol=%s", value, p.Protocol)
+				}
+				flags = append(flags, dockerRunFlag{Name: "publish", Value: value})
+			}
+		case "restart":
+			mode, retries, err := parseDockerRestart(flag.Value)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			flags = append(flags, dockerRunFlag{Name: "restart-condition", Value: swarmRestartCondition(mode)})
+			if retries > 0 {
+				flags = append(flags, dockerRunFlag{Name: "restart-max-attempts", Value: strconv.Itoa(retries)})
+			}
+		case "tmpfs":
+			mount, err := swarmTmpfsMount(flag.Value)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			flags = append(flags, dockerRunFlag{Name: "mount", Value: mount})
+		case "volume":
+			flags = append(flags, dockerRunFlag{Name: "mount", Value: swarmVolumeMount(flag.Value)})
+		default:
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in docker service create as the property is not supported", flag.Name))
+		}
+	}
+	if len(c.Restart) == 0 || c.Restart == "no" {
+		// swarm restarts tasks by default, unlike docker run
+		flags = append(flags, dockerRunFlag{Name: "restart-condition", Value: "none"})
+	}
+	sortDockerRunFlags(flags)
+
+	return &SwarmService{Args: dockerRunArgs(flags, arguments)}, warnings, errs
+}
+
+// MarshalSwarmStack marshals a compose project to a compose file that can be
+// deployed with docker stack deploy
+func MarshalSwarmStack(project *types.Project) ([]byte, error) {
+	out, err := MarshalCompose(project, "yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(out, &document); err != nil {
+		return nil, err
+	}
+
+	// docker stack deploy validates stack files against the version 3 schema,
+	// which has no name property and differs from the compose spec for a few
+	// service properties
+	stack := yaml.MapSlice{{Key: "version", Value: swarmStackVersion}}
+	for _, item := range document {
+		switch item.Key {
+		case "name":
+			continue
+		case "services":
+			services, _ := item.Value.(yaml.MapSlice)
+			for i, service := range services {
+				if properties, ok := service.Value.(yaml.MapSlice); ok {
+					services[i].Value = swarmStackServiceYAML(properties)
+				}
+			}
+		}
+		stack = append(stack, item)
+	}
+
+	return yaml.Marshal(stack)
+}
+
+// MarshalSwarmService marshals a docker service create command to a single
+// shell command line
+func MarshalSwarmService(service *SwarmService) ([]byte, error) {
+	return []byte(fmt.Sprintf("docker service create %s\n", shellJoin(service.Args))), nil
+}
+
+// swarmStackServiceYAML rewrites the properties of a marshaled compose
+// service that the version 3 schema represents differently
+func swarmStackServiceYAML(properties yaml.MapSlice) yaml.MapSlice {
+	for i, item := range properties {
+		switch item.Key {
+		case "env_file":
+			// env_file only accepts paths
+			files := []interface{}{}
+			for _, file := range toSlice(item.Value) {
+				if entry, ok := file.(yaml.MapSlice); ok {
+					file = mapSliceValue(entry, "path")
+				}
+				files = append(files, file)
+			}
+			properties[i].Value = files
+		case "extra_hosts":
+			// extra_hosts only accepts the host:ip form
+			hosts := []interface{}{}
+			for _, host := range toSlice(item.Value) {
+				if value, ok := host.(string); ok {
+					host = strings.Replace(value, "=", ":", 1)
+				}
+				hosts = append(hosts, host)
+			}
+			properties[i].Value = hosts
+		case "ports":
+			// published ports are integers
+			for _, port := range toSlice(item.Value) {
+				if entry, ok := port.(yaml.MapSlice); ok {
+					mapSliceToInt(entry, "published")
+				}
+			}
+		case "volumes":
+			// tmpfs sizes are integers
+			for _, volume := range toSlice(item.Value) {
+				if entry, ok := volume.(yaml.MapSlice); ok {
+					if tmpfs, ok := mapSliceValue(entry, "tmpfs").(yaml.MapSlice); ok {
+						mapSliceToInt(tmpfs, "size")
+					}
+				}
+			}
+		case "deploy":
+			// resource cpus are strings
+			if deploy, ok := item.Value.(yaml.MapSlice); ok {
+				if resources, ok := mapSliceValue(deploy, "resources").(yaml.MapSlice); ok {
+					for _, key := range []string{"limits", "reservations"} {
+						if resource, ok := mapSliceValue(resources, key).(yaml.MapSlice); ok {
+							for j, property := range resource {
+								if property.Key == "cpus" {
+									resource[j].Value = fmt.Sprint(property.Value)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return properties
+}
+
+// swarmUnsupportedWarnings returns a warning for each docker run flag that is
+// set and not supported by swarm services
+func swarmUnsupportedWarnings(c *arguments.Args, format string) *multierror.Error {
+	var warnings *multierror.Error
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if !swarmUnsupportedFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+
+		if flag.Name == "pull" {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --pull property in %s as swarm resolves and pulls images on each node", format))
+			continue
+		}
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in %s as the property is not supported", flag.Name, format))
+	}
+
+	return warnings
+}
+
+// swarmRestartCondition maps a docker restart mode to a swarm restart condition
+func swarmRestartCondition(mode string) string {
+	switch mode {
+	case "always", "unless-stopped":
+		return "any"
+	case "on-failure":
+		return "on-failure"
+	default:
+		return "none"
+	}
+}
+
+// swarmPlatformConstraints converts a platform string (e.g., "linux/amd64")
+// to swarm placement constraints, which use the architecture names reported
+// by the nodes
+func swarmPlatformConstraints(platform string) []string {
+	constraints := []string{}
+	osName, arch := extractParts(platform, "/")
+	if len(osName) > 0 {
+		constraints = append(constraints, fmt.Sprintf("node.platform.os==%s", osName))
+	}
+
+	arch, _ = extractParts(arch, "/")
+	switch arch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	}
+	if len(arch) > 0 {
+		constraints = append(constraints, fmt.Sprintf("node.platform.arch==%s", arch))
+	}
+
+	return constraints
+}
+
+// swarmVolumeMount converts a --volume value to a --mount value, as services
+// do not support the --volume flag
+func swarmVolumeMount(value string) string {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) == 1 {
+		return fmt.Sprintf("type=volume,target=%s", parts[0])
+	}
+
+	mountType := "bind"
+	if isNamedVolume(parts[0]) {
+		mountType = "volume"
+	}
+	mount := fmt.Sprintf("type=%s,source=%s,target=%s", mountType, parts[0], parts[1])
+	if len(parts) == 3 {
+		for _, option := range strings.Split(parts[2], ",") {
+			if option == "ro" {
+				mount = fmt.Sprintf("%s,readonly", mount)
+			}
+		}
+	}
+
+	return mount
+}
+
+// swarmTmpfsMount converts a --tmpfs value to a --mount value, as services do
+// not support the --tmpfs flag
+func swarmTmpfsMount(value string) (string, error) {
+	parsed, err := parseDockerTmpfs(value)
+	if err != nil {
+		return "", err
+	}
+
+	mount := fmt.Sprintf("type=tmpfs,target=%s", parsed["target"])
+	if options, ok := parsed["tmpfs_options"].(map[string]interface{}); ok {
+		if size, ok := options["size"]; ok {
+			mount = fmt.Sprintf("%s,tmpfs-size=%d", mount, size)
+		}
+		if mode, ok := options["mode"]; ok {
+			mount = fmt.Sprintf("%s,tmpfs-mode=%o", mount, mode)
+		}
+	}
+
+	return mount, nil
+}
+
+// toSlice returns the items of a marshaled yaml list
+func toSlice(value interface{}) []interface{} {
+	items, _ := value.([]interface{})
+	return items
+}
+
+// mapSliceValue returns the value of a key in a marshaled yaml mapping
+func mapSliceValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// mapSliceToInt converts the string value of a key in a marshaled yaml
+// mapping to an integer
+func mapSliceToInt(m yaml.MapSlice, key string) {
+	for i, item := range m {
+		if item.Key != key {
+			continue
+		}
+		if value, ok := item.Value.(string); ok {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				m[i].Value = n
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/josegonzalez/cli-skeleton/command"
+)
+
+// systemdDockerPath holds the absolute path to the docker CLI, as systemd
+// does not search the PATH for commands in older releases
+const systemdDockerPath = "/usr/bin/docker"
+
+// ToSystemd converts docker run arguments to a systemd service unit that
+// runs the container in the foreground with docker run
+func ToSystemd(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	image := arguments["image"].StringValue()
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = projectName
+	}
+	if len(name) == 0 {
+		name = imageName(image)
+	}
+
+	description := projectName
+	if len(description) == 0 {
+		description = name
+	}
+
+	unit := &SystemdUnit{}
+	unitSection := unit.Section("Unit")
+	unitSection.Add("Description", description)
+	unitSection.Add("After", "docker.service network-online.target")
+	unitSection.Add("Requires", "docker.service")
+	unitSection.Add("Wants", "network-online.target")
+
+	service := unit.Section("Service")
+
+	// the flags that systemd takes over are removed from the docker run command
+	flags := []dockerRunFlag{}
+	for _, flag := range dockerRunFlags(c) {
+		switch flag.Name {
+		case "detach", "name", "pull", "restart", "rm":
+			// detach: the container must run in the foreground for systemd to supervise it
+			// name and rm: always set so that stale containers can be removed
+			// pull: handled by an ExecStartPre step
+			// restart: handled by Restart=
+			continue
+		case "interactive", "tty":
+			// a service has no terminal, so docker run fails with "the input device is not a TTY"
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in systemd unit as the property is not supported", flag.Name))
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	flags = append(flags, dockerRunFlag{Name: "name", Value: name}, dockerRunFlag{Name: "rm", Bool: true})
+	sortDockerRunFlags(flags)
+
+	// restart -> Restart / StartLimitIntervalSec / StartLimitBurst
+	restart, burst, err := systemdRestart(c.Restart)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	service.Add("Restart", restart)
+	systemdStartLimit(unit, burst)
+
+	// image pulls may take longer than the default start timeout
+	service.Add("TimeoutStartSec", "0")
+
+	// stop-timeout -> TimeoutStopSec
+	if c.StopTimeout > 0 {
+		service.Add("TimeoutStopSec", strconv.Itoa(c.StopTimeout))
+	}
+
+	// stop-signal -> KillSignal, which the docker CLI proxies to the container
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		service.Add("KillSignal", c.StopSignal)
+		if !c.SigProxy {
+			warnings = multierror.Append(warnings, fmt.Errorf("--stop-signal %s will not reach the container as --sig-proxy is disabled", c.StopSignal))
+		}
+	}
+
+	// env-file -> EnvironmentFile, so that ${VAR} references in the unit are expanded
+	for _, value := range c.EnvFile {
+		if !path.IsAbs(value) {
+			errs = multierror.Append(errs, fmt.Errorf("unable to set --env-file %s in systemd unit as EnvironmentFile requires an absolute path", value))
+			continue
+		}
+		service.Add("EnvironmentFile", value)
+	}
+
+	service.Add("ExecStartPre", fmt.Sprintf("-%s rm --force %s", systemdDockerPath, systemdQuote(name)))
+
+	// pull -> ExecStartPre
+	switch c.Pull {
+	case "always":
+		service.Add("ExecStartPre", fmt.Sprintf("%s pull %s", systemdDockerPath, systemdQuote(image)))
+	case "", "missing":
+		// a failed pull falls back to the local image, as docker run does
+		service.Add("ExecStartPre", fmt.Sprintf("-%s pull %s", systemdDockerPath, systemdQuote(image)))
+	case "never":
+		// only the local image is used
+	default:
+		errs = multierror.Append(errs, fmt.Errorf("unknown --pull value %q", c.Pull))
+	}
+
+	service.Add("ExecStart", fmt.Sprintf("%s run %s", systemdDockerPath, systemdJoin(dockerRunArgs(flags, arguments))))
+	service.Add("ExecStopPost", fmt.Sprintf("-%s rm --force %s", systemdDockerPath, systemdQuote(name)))
+
+	// hardening only applies to the docker CLI, the container is isolated by the docker daemon
+	service.Add("NoNewPrivileges", "true")
+	if !systemdUsesTmp(c) {
+		service.Add("PrivateTmp", "true")
+	}
+	service.Add("ProtectSystem", "full")
+	service.Add("ProtectHome", "read-only")
+	service.Add("ProtectKernelTunables", "true")
+	service.Add("ProtectKernelModules", "true")
+	service.Add("ProtectControlGroups", "true")
+	service.Add("RestrictSUIDSGID", "true")
+	service.Add("LockPersonality", "true")
+
+	unit.Section("Install").Add("WantedBy", "multi-user.target")
+
+	return unit, warnings, errs
+}
+
+// systemdUsesTmp reports whether the docker CLI reads or writes a file under
+// /tmp or /var/tmp, which PrivateTmp would hide from it
+func systemdUsesTmp(c *arguments.Args) bool {
+	files := append(append([]string{c.Cidfile}, c.EnvFile...), c.LabelFile...)
+	for _, file := range files {
+		if len(file) == 0 {
+			continue
+		}
+		cleaned := path.Clean(file)
+		if strings.HasPrefix(cleaned, "/tmp/") || strings.HasPrefix(cleaned, "/var/tmp/") {
+			return true
+		}
+	}
+
+	return false
+}
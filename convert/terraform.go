@@ -0,0 +1,757 @@
+package convert
+
+import (
+	"docker-run-export/arguments"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mattn/go-shellwords"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// terraformInvalidNameCharacters matches the characters that are not allowed
+// in Terraform resource names
+var terraformInvalidNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// terraformDockerFlags holds the docker run flags that are mapped to the
+// docker_image and docker_container resources
+var terraformDockerFlags = map[string]bool{
+	"add-host":            true,
+	"cap-add":             true,
+	"cap-drop":            true,
+	"cgroupns":            true,
+	"cpu-shares":          true,
+	"cpuset-cpus":         true,
+	"detach":              true,
+	"device":              true,
+	"dns":                 true,
+	"dns-option":          true,
+	"dns-search":          true,
+	"domainname":          true,
+	"entrypoint":          true,
+	"env":                 true,
+	"gpus":                true,
+	"group-add":           true,
+	"health-cmd":          true,
+	"health-interval":     true,
+	"health-retries":      true,
+	"health-start-period": true,
+	"health-timeout":      true,
+	"hostname":            true,
+	"init":                true,
+	"interactive":         true,
+	"ip":                  true,
+	"ip6":                 true,
+	"ipc":                 true,
+	"label":               true,
+	"log-driver":          true,
+	"log-opt":             true,
+	"memory":              true,
+	"memory-swap":         true,
+	"mount":               true,
+	"name":                true,
+	"network":             true,
+	"network-alias":       true,
+	"no-healthcheck":      true,
+	"pid":                 true,
+	"platform":            true,
+	"privileged":          true,
+	"publish":             true,
+	"publish-all":         true,
+	"read-only":           true,
+	"restart":             true,
+	"rm":                  true,
+	"runtime":             true,
+	"security-opt":        true,
+	"shm-size":            true,
+	"stop-signal":         true,
+	"stop-timeout":        true,
+	"storage-opt":         true,
+	"sysctl":              true,
+	"tmpfs":               true,
+	"tty":                 true,
+	"ulimit":              true,
+	"user":                true,
+	"userns":              true,
+	"volume":              true,
+	"workdir":             true,
+}
+
+// TerraformConfig represents a Terraform configuration
+type TerraformConfig struct {
+	// Providers holds the required providers, keyed by their local name
+	Providers map[string]TerraformProvider
+	// Variables holds the input variables in the order in which they are written
+	Variables []TerraformVariable
+	// Resources holds the resources in the order in which they are written
+	Resources []TerraformResource
+}
+
+// TerraformProvider represents an entry of the required_providers block
+type TerraformProvider struct {
+	Source  string
+	Version string
+}
+
+// TerraformVariable represents a Terraform input variable
+type TerraformVariable struct {
+	Name        string
+	Type        string
+	Description string
+	// Default holds the default value of the variable, or nil for a null default
+	Default *string
+}
+
+// TerraformResource represents a Terraform resource block
+type TerraformResource struct {
+	Type string
+	Name string
+	// Attributes holds the attributes and nested blocks of the resource, in
+	// the same form as the Nomad driver config
+	Attributes map[string]interface{}
+}
+
+// TerraformReference holds a reference to an attribute of another resource,
+// such as docker_image.app.image_id
+type TerraformReference []string
+
+// TerraformExpression holds the tokens of an attribute value that cannot be
+// represented as a literal, such as a function call
+type TerraformExpression hclwrite.Tokens
+
+// ToTerraformDocker converts docker run arguments to the docker_image and
+// docker_container resources of the kreuzwerker/docker Terraform provider
+func ToTerraformDocker(projectName string, c *arguments.Args, arguments map[string]command.Argument) (interface{}, *multierror.Error, *multierror.Error) {
+	var warnings *multierror.Error
+	var errs *multierror.Error
+
+	imageRef := arguments["image"].StringValue()
+	name := c.ContainerName
+	if len(name) == 0 {
+		name = projectName
+	}
+	if len(name) == 0 {
+		name = imageName(imageRef)
+	}
+	resourceName := terraformName(name)
+
+	image := map[string]interface{}{
+		"name": imageRef,
+	}
+	container := map[string]interface{}{
+		"name":  name,
+		"image": TerraformReference{"docker_image", resourceName, "image_id"},
+	}
+
+	// platform -> docker_image.platform
+	if len(c.Platform) > 0 {
+		image["platform"] = c.Platform
+	}
+
+	// command / entrypoint
+	if len(arguments["command"].ListValue()) > 0 {
+		container["command"] = arguments["command"].ListValue()
+	}
+	if len(c.Entrypoint) > 0 {
+		args, err := shellwords.Parse(c.Entrypoint)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --entrypoint flag to slice: %w", err))
+		} else {
+			container["entrypoint"] = args
+		}
+	} else if c.EntrypointCleared {
+		container["entrypoint"] = []string{""}
+	}
+
+	// add-host -> host
+	if len(c.AddHost) > 0 {
+		hosts := []map[string]interface{}{}
+		for _, value := range c.AddHost {
+			host, ip := extractParts(strings.Replace(value, "=", ":", 1), ":")
+			hosts = append(hosts, map[string]interface{}{
+				"host": host,
+				"ip":   ip,
+			})
+		}
+		container["host"] = hosts
+	}
+
+	// cap-add / cap-drop -> capabilities
+	if len(c.CapAdd) > 0 || len(c.CapDrop) > 0 {
+		capabilities := map[string]interface{}{}
+		if len(c.CapAdd) > 0 {
+			capabilities["add"] = c.CapAdd
+		}
+		if len(c.CapDrop) > 0 {
+			capabilities["drop"] = c.CapDrop
+		}
+		container["capabilities"] = capabilities
+	}
+
+	if len(c.Cgroupns) > 0 {
+		container["cgroupns_mode"] = c.Cgroupns
+	}
+	if c.CpuShares > 0 {
+		container["cpu_shares"] = c.CpuShares
+	}
+	if len(c.CpusetCpus) > 0 {
+		container["cpu_set"] = c.CpusetCpus
+	}
+
+	// device -> devices
+	if len(c.Device) > 0 {
+		devices := []map[string]interface{}{}
+		for _, value := range c.Device {
+			parts := strings.SplitN(value, ":", 3)
+			device := map[string]interface{}{
+				"host_path": parts[0],
+			}
+			if len(parts) > 1 {
+				device["container_path"] = parts[1]
+			}
+			if len(parts) > 2 {
+				device["permissions"] = parts[2]
+			}
+			devices = append(devices, device)
+		}
+		container["devices"] = devices
+	}
+
+	if len(c.Dns) > 0 {
+		container["dns"] = c.Dns
+	}
+	if len(c.DnsOption) > 0 {
+		container["dns_opts"] = c.DnsOption
+	}
+	if len(c.DnsSearch) > 0 {
+		container["dns_search"] = c.DnsSearch
+	}
+	if len(c.Domainname) > 0 {
+		container["domainname"] = c.Domainname
+	}
+
+	// env -> env
+	if len(c.Env) > 0 {
+		env := []string{}
+		for _, value := range c.Env {
+			if !strings.Contains(value, "=") {
+				warnings = multierror.Append(warnings, fmt.Errorf("unable to set --env %s in terraform docker_container as passing through host environment variables is not supported", value))
+				continue
+			}
+			env = append(env, value)
+		}
+		if len(env) > 0 {
+			container["env"] = env
+		}
+	}
+
+	if len(c.Gpus) > 0 {
+		container["gpus"] = c.Gpus
+	}
+	if len(c.GroupAdd) > 0 {
+		container["group_add"] = c.GroupAdd
+	}
+
+	// health-* -> healthcheck
+	if c.NoHealthcheck {
+		if len(c.HealthCmd) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("ignoring --health-cmd as --no-healthcheck is specified"))
+		}
+		container["healthcheck"] = map[string]interface{}{
+			"test": []string{"NONE"},
+		}
+	} else if len(c.HealthCmd) > 0 {
+		healthcheck := map[string]interface{}{
+			"test": []string{"CMD-SHELL", c.HealthCmd},
+		}
+		if c.HealthInterval != "0s" {
+			healthcheck["interval"] = c.HealthInterval
+		}
+		if c.HealthTimeout != "0s" {
+			healthcheck["timeout"] = c.HealthTimeout
+		}
+		if c.HealthStartPeriod != "0s" {
+			healthcheck["start_period"] = c.HealthStartPeriod
+		}
+		if c.HealthRetries > 0 {
+			healthcheck["retries"] = int(c.HealthRetries)
+		}
+		container["healthcheck"] = healthcheck
+	}
+
+	if len(c.Hostname) > 0 {
+		container["hostname"] = c.Hostname
+	}
+	if c.Init {
+		container["init"] = true
+	}
+	if c.Interactive {
+		container["stdin_open"] = true
+	}
+	if len(c.Ipc) > 0 {
+		container["ipc_mode"] = c.Ipc
+	}
+
+	// label -> labels
+	if len(c.Label) > 0 {
+		labels := map[string]string{}
+		for _, value := range c.Label {
+			key, val := extractParts(value, "=")
+			labels[key] = val
+		}
+		blocks := []map[string]interface{}{}
+		for _, key := range sortedKeys(labels) {
+			blocks = append(blocks, map[string]interface{}{
+				"label": key,
+				"value": labels[key],
+			})
+		}
+		container["labels"] = blocks
+	}
+
+	// log-driver / log-opt -> log_driver / log_opts
+	if len(c.LogDriver) > 0 {
+		container["log_driver"] = c.LogDriver
+	}
+	if len(c.LogOpt) > 0 {
+		logOpts := map[string]string{}
+		for _, value := range c.LogOpt {
+			key, val := extractParts(value, "=")
+			logOpts[key] = val
+		}
+		container["log_opts"] = logOpts
+	}
+
+	// memory / memory-swap / shm-size are set in MB
+	setMiB := func(flag string, property string, bytes int64) {
+		mib := terraformBytesToMiB(bytes)
+		if int64(mib)*1024*1024 != bytes {
+			warnings = multierror.Append(warnings, fmt.Errorf("rounding --%s %d up to %d MB in terraform docker_container as %s is set in whole megabytes", flag, bytes, mib, property))
+		}
+		container[property] = mib
+	}
+	if c.Memory > 0 {
+		setMiB("memory", "memory", c.Memory)
+	}
+	if c.MemorySwap == -1 {
+		container["memory_swap"] = -1
+	} else if c.MemorySwap > 0 {
+		setMiB("memory-swap", "memory_swap", c.MemorySwap)
+	}
+	if c.ShmSize > 0 {
+		setMiB("shm-size", "shm_size", int64(c.ShmSize))
+	}
+
+	// mount -> mounts
+	mounts := []map[string]interface{}{}
+	for _, value := range c.Mount {
+		parsed, err := parseDockerMount(value)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		mounts = append(mounts, terraformMount(parsed))
+	}
+	if len(mounts) > 0 {
+		container["mounts"] = mounts
+	}
+
+	// network / network-alias / ip / ip6 -> network_mode or networks_advanced
+	switch {
+	case len(c.Network) == 0:
+		if len(c.NetworkAlias) > 0 || len(c.Ip) > 0 || len(c.Ip6) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias, --ip and --ip6 properties in terraform docker_container without --network"))
+		}
+	case c.Network == "host" || c.Network == "bridge" || c.Network == "none" || strings.HasPrefix(c.Network, "container:"):
+		container["network_mode"] = c.Network
+		if len(c.NetworkAlias) > 0 || len(c.Ip) > 0 || len(c.Ip6) > 0 {
+			warnings = multierror.Append(warnings, fmt.Errorf("unable to set --network-alias, --ip and --ip6 properties in terraform docker_container with --network %s", c.Network))
+		}
+	default:
+		network := map[string]interface{}{
+			"name": c.Network,
+		}
+		if len(c.NetworkAlias) > 0 {
+			network["aliases"] = c.NetworkAlias
+		}
+		if len(c.Ip) > 0 {
+			network["ipv4_address"] = c.Ip
+		}
+		if len(c.Ip6) > 0 {
+			network["ipv6_address"] = c.Ip6
+		}
+		container["networks_advanced"] = []map[string]interface{}{network}
+	}
+
+	if len(c.Pid) > 0 {
+		container["pid_mode"] = c.Pid
+	}
+	if c.Privileged {
+		container["privileged"] = true
+	}
+
+	// publish -> ports
+	ports := []map[string]interface{}{}
+	for _, value := range c.Publish {
+		parsed, err := types.ParsePortConfig(value)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("unable to parse --publish flag: %w", err))
+			continue
+		}
+		for _, p := range parsed {
+			port := map[string]interface{}{
+				"internal": int(p.Target),
+			}
+			if len(p.Published) > 0 {
+				external, err := strconv.Atoi(p.Published)
+				if err != nil {
+					warnings = multierror.Append(warnings, fmt.Errorf("unable to set host port range %s of --publish %s in terraform docker_container as the property is not supported", p.Published, value))
+				} else {
+					port["external"] = external
+				}
+			}
+			if len(p.HostIP) > 0 {
+				port["ip"] = p.HostIP
+			}
+			if p.Protocol != "tcp" {
+				port["protocol"] = p.Protocol
+			}
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) > 0 {
+		container["ports"] = ports
+	}
+
+	if c.PublishAll {
+		container["publish_all_ports"] = true
+	}
+	if c.ReadOnly {
+		container["read_only"] = true
+	}
+
+	// restart -> restart / max_retry_count
+	if len(c.Restart) > 0 && c.Restart != "no" {
+		mode, retries, err := parseDockerRestart(c.Restart)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		} else {
+			container["restart"] = mode
+			if retries > 0 {
+				container["max_retry_count"] = retries
+			}
+		}
+	}
+
+	if c.Rm {
+		container["rm"] = true
+	}
+	if len(c.Runtime) > 0 {
+		container["runtime"] = c.Runtime
+	}
+	if len(c.SecurityOpt) > 0 {
+		container["security_opts"] = c.SecurityOpt
+	}
+	if len(c.StopSignal) > 0 && c.StopSignal != "SIGTERM" {
+		container["stop_signal"] = c.StopSignal
+	}
+	if c.StopTimeout > 0 {
+		container["stop_timeout"] = c.StopTimeout
+	}
+
+	// storage-opt -> storage_opts
+	if len(c.StorageOpt) > 0 {
+		storageOpts := map[string]string{}
+		for _, value := range c.StorageOpt {
+			key, val := extractParts(value, "=")
+			storageOpts[key] = val
+		}
+		container["storage_opts"] = storageOpts
+	}
+
+	if len(c.Sysctl) > 0 {
+		container["sysctls"] = c.Sysctl
+	}
+
+	// tmpfs -> tmpfs, keyed by path
+	if len(c.Tmpfs) > 0 {
+		tmpfs := map[string]string{}
+		for _, value := range c.Tmpfs {
+			path, options := extractParts(value, ":")
+			tmpfs[path] = options
+		}
+		container["tmpfs"] = tmpfs
+	}
+
+	if c.Tty {
+		container["tty"] = true
+	}
+
+	// ulimit -> ulimit
+	if len(c.Ulimit) > 0 {
+		ulimits := []map[string]interface{}{}
+		for _, value := range c.Ulimit {
+			name, limits := extractParts(value, "=")
+			soft, hard := extractParts(limits, ":")
+			if hard == "" {
+				hard = soft
+			}
+			softValue, err := strconv.Atoi(soft)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit %s: %w", value, err))
+				continue
+			}
+			hardValue, err := strconv.Atoi(hard)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("unable to parse --ulimit %s: %w", value, err))
+				continue
+			}
+			ulimits = append(ulimits, map[string]interface{}{
+				"name": name,
+				"soft": softValue,
+				"hard": hardValue,
+			})
+		}
+		container["ulimit"] = ulimits
+	}
+
+	if len(c.User) > 0 {
+		container["user"] = c.User
+	}
+	if len(c.Userns) > 0 {
+		container["userns_mode"] = c.Userns
+	}
+
+	// volume -> volumes
+	volumes := []map[string]interface{}{}
+	for _, value := range c.Volume {
+		parts := strings.SplitN(value, ":", 3)
+		volume := map[string]interface{}{}
+		if len(parts) == 1 {
+			volume["container_path"] = parts[0]
+		} else {
+			if isNamedVolume(parts[0]) {
+				volume["volume_name"] = parts[0]
+			} else {
+				volume["host_path"] = parts[0]
+			}
+			volume["container_path"] = parts[1]
+		}
+		if len(parts) == 3 {
+			for _, option := range strings.Split(parts[2], ",") {
+				if option == "ro" {
+					volume["read_only"] = true
+				}
+			}
+		}
+		volumes = append(volumes, volume)
+	}
+	if len(volumes) > 0 {
+		container["volumes"] = volumes
+	}
+
+	if len(c.Workdir) > 0 {
+		container["working_dir"] = c.Workdir
+	}
+
+	// every other flag has no docker_container equivalent
+	seen := map[string]bool{}
+	for _, flag := range dockerRunFlags(c) {
+		if terraformDockerFlags[flag.Name] || seen[flag.Name] {
+			continue
+		}
+		seen[flag.Name] = true
+		warnings = multierror.Append(warnings, fmt.Errorf("unable to set --%s property in terraform docker_container as the property is not supported", flag.Name))
+	}
+
+	config := &TerraformConfig{
+		Providers: map[string]TerraformProvider{
+			"docker": {
+				Source:  "kreuzwerker/docker",
+				Version: "~> 3.0",
+			},
+		},
+		Resources: []TerraformResource{
+			{Type: "docker_image", Name: resourceName, Attributes: image},
+			{Type: "docker_container", Name: resourceName, Attributes: container},
+		},
+	}
+
+	return config, warnings, errs
+}
+
+// MarshalTerraform marshals a Terraform configuration to HCL format
+func MarshalTerraform(config *TerraformConfig) ([]byte, error) {
+	f := hclwrite.NewEmptyFile()
+	rootBody := f.Body()
+
+	if len(config.Providers) > 0 {
+		terraformBlock := rootBody.AppendNewBlock("terraform", nil)
+		providersBlock := terraformBlock.Body().AppendNewBlock("required_providers", nil)
+		names := make([]string, 0, len(config.Providers))
+		for name := range config.Providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			provider := config.Providers[name]
+			providersBlock.Body().SetAttributeValue(name, cty.ObjectVal(map[string]cty.Value{
+				"source":  cty.StringVal(provider.Source),
+				"version": cty.StringVal(provider.Version),
+			}))
+		}
+	}
+
+	for _, variable := range config.Variables {
+		if len(rootBody.Blocks()) > 0 {
+			rootBody.AppendNewline()
+		}
+		writeTerraformVariable(rootBody, variable)
+	}
+
+	for _, resource := range config.Resources {
+		if len(rootBody.Blocks()) > 0 {
+			rootBody.AppendNewline()
+		}
+		writeTerraformResource(rootBody, resource)
+	}
+
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+// writeTerraformVariable appends a variable block to the parent body
+func writeTerraformVariable(parent *hclwrite.Body, variable TerraformVariable) {
+	block := parent.AppendNewBlock("variable", []string{variable.Name})
+	body := block.Body()
+	body.SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: variable.Type}})
+	if len(variable.Description) > 0 {
+		body.SetAttributeValue("description", cty.StringVal(variable.Description))
+	}
+	if variable.Default != nil {
+		body.SetAttributeValue("default", cty.StringVal(*variable.Default))
+	} else {
+		body.SetAttributeValue("default", cty.NullVal(cty.String))
+	}
+}
+
+// writeTerraformResource appends a resource block to the parent body, with
+// the name attribute, references and expressions ahead of the other attributes
+func writeTerraformResource(parent *hclwrite.Body, resource TerraformResource) {
+	block := parent.AppendNewBlock("resource", []string{resource.Type, resource.Name})
+	body := block.Body()
+
+	attributes := map[string]interface{}{}
+	references := []string{}
+	expressions := []string{}
+	for key, value := range resource.Attributes {
+		switch value.(type) {
+		case TerraformReference:
+			references = append(references, key)
+			continue
+		case TerraformExpression:
+			expressions = append(expressions, key)
+			continue
+		}
+		if key == "name" {
+			continue
+		}
+		attributes[key] = value
+	}
+	sort.Strings(references)
+	sort.Strings(expressions)
+
+	if name, ok := resource.Attributes["name"]; ok {
+		if ctyVal, ok := goValueToCty(name); ok {
+			body.SetAttributeValue("name", ctyVal)
+		}
+	}
+	for _, key := range references {
+		reference := resource.Attributes[key].(TerraformReference)
+		traversal := hcl.Traversal{hcl.TraverseRoot{Name: reference[0]}}
+		for _, name := range reference[1:] {
+			traversal = append(traversal, hcl.TraverseAttr{Name: name})
+		}
+		body.SetAttributeTraversal(key, traversal)
+	}
+	for _, key := range expressions {
+		body.SetAttributeRaw(key, hclwrite.Tokens(resource.Attributes[key].(TerraformExpression)))
+	}
+	writeConfigBody(body, attributes)
+}
+
+// terraformMount converts a parsed --mount value to a docker_container mounts
+// block
+func terraformMount(parsed map[string]interface{}) map[string]interface{} {
+	mount := map[string]interface{}{}
+	for key, value := range parsed {
+		switch key {
+		case "readonly":
+			mount["read_only"] = value
+		case "volume_options":
+			options := value.(map[string]interface{})
+			volumeOptions := map[string]interface{}{}
+			if noCopy, ok := options["no_copy"]; ok {
+				volumeOptions["no_copy"] = noCopy
+			}
+			if labels, ok := options["labels"].(map[string]string); ok {
+				blocks := []map[string]interface{}{}
+				for _, key := range sortedKeys(labels) {
+					blocks = append(blocks, map[string]interface{}{
+						"label": key,
+						"value": labels[key],
+					})
+				}
+				volumeOptions["labels"] = blocks
+			}
+			if driver, ok := options["driver_config"].(map[string]interface{}); ok {
+				if name, ok := driver["name"]; ok {
+					volumeOptions["driver_name"] = name
+				}
+				if driverOptions, ok := driver["options"]; ok {
+					volumeOptions["driver_options"] = driverOptions
+				}
+			}
+			mount["volume_options"] = volumeOptions
+		case "tmpfs_options":
+			options := value.(map[string]interface{})
+			tmpfsOptions := map[string]interface{}{}
+			if size, ok := options["size"]; ok {
+				tmpfsOptions["size_bytes"] = size
+			}
+			if mode, ok := options["mode"]; ok {
+				tmpfsOptions["mode"] = mode
+			}
+			mount["tmpfs_options"] = tmpfsOptions
+		default:
+			mount[key] = value
+		}
+	}
+
+	return mount
+}
+
+// terraformBytesToMiB converts bytes to megabytes, rounding up so that a
+// limit below 1MB is not dropped
+func terraformBytesToMiB(bytes int64) int {
+	return int((bytes + 1024*1024 - 1) / (1024 * 1024))
+}
+
+// terraformName converts a name to a valid Terraform resource name
+func terraformName(name string) string {
+	name = terraformInvalidNameCharacters.ReplaceAllString(name, "_")
+	if len(name) == 0 {
+		return "app"
+	}
+	if name[0] >= '0' && name[0] <= '9' || name[0] == '-' {
+		name = "_" + name
+	}
+	return name
+}
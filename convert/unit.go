@@ -0,0 +1,128 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SystemdUnit represents a systemd unit file as an ordered list of sections
+type SystemdUnit struct {
+	Sections []*SystemdUnitSection
+}
+
+// SystemdUnitSection represents a `[Name]` section of a systemd unit file
+type SystemdUnitSection struct {
+	Name    string
+	Entries []SystemdUnitEntry
+}
+
+// SystemdUnitEntry represents a `Key=Value` line of a systemd unit file
+type SystemdUnitEntry struct {
+	Key   string
+	Value string
+}
+
+// Section returns the section with the given name, adding it to the end of
+// the unit if it does not exist yet
+func (u *SystemdUnit) Section(name string) *SystemdUnitSection {
+	for _, section := range u.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+
+	section := &SystemdUnitSection{Name: name}
+	u.Sections = append(u.Sections, section)
+	return section
+}
+
+// Add appends a `Key=Value` line to the section. Percent signs are escaped so
+// that systemd does not expand them as specifiers.
+func (s *SystemdUnitSection) Add(key string, value string) {
+	s.Entries = append(s.Entries, SystemdUnitEntry{
+		Key:   key,
+		Value: strings.ReplaceAll(value, "%", "%%"),
+	})
+}
+
+// MarshalSystemdUnit marshals a systemd unit to the unit file format
+func MarshalSystemdUnit(unit *SystemdUnit) ([]byte, error) {
+	var b bytes.Buffer
+	for i, section := range unit.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", section.Name)
+		for _, entry := range section.Entries {
+			if strings.ContainsAny(entry.Value, "\n") {
+				return nil, fmt.Errorf("unable to marshal %s: value contains a newline", entry.Key)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", entry.Key, entry.Value)
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// systemdQuote quotes a single word for use in a systemd setting that is
+// split on whitespace, such as ExecStart= or Environment=
+func systemdQuote(value string) string {
+	if len(value) > 0 && !strings.ContainsAny(value, " \t\"'\\") {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// systemdJoin quotes each word and joins them with spaces
+func systemdJoin(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, systemdQuote(word))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdRestart converts a docker restart policy to the value of the systemd
+// Restart= setting and, for `on-failure:N`, the number of starts that
+// systemdStartLimit allows: the first start plus N restarts
+func systemdRestart(value string) (string, int, error) {
+	if len(value) == 0 {
+		return "no", 0, nil
+	}
+
+	mode, maxRetries, err := parseDockerRestart(value)
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch mode {
+	case "always", "unless-stopped":
+		return "always", 0, nil
+	case "on-failure":
+		if maxRetries == 0 {
+			return "on-failure", 0, nil
+		}
+		return "on-failure", maxRetries + 1, nil
+	default:
+		return "no", 0, nil
+	}
+}
+
+// systemdStartLimit caps the number of starts of the unit. systemd only counts
+// the starts within StartLimitIntervalSec, which defaults to 10 seconds and
+// would let a failing container restart forever with the default RestartSec,
+// so the interval is never reset, as docker does with on-failure:N.
+func systemdStartLimit(unit *SystemdUnit, burst int) {
+	if burst == 0 {
+		return
+	}
+
+	section := unit.Section("Unit")
+	section.Add("StartLimitIntervalSec", "infinity")
+	section.Add("StartLimitBurst", strconv.Itoa(burst))
+}
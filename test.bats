@@ -820,6 +820,1273 @@ nomad_validate_json() {
   [[ "$(yq_s '.services.app.command[0]')" == "echo" ]]
 }
 
+# Command line input
+
+@test "command line: from string" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-from-string 'docker run --name web -p 8080:80 nginx:latest'
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.image')" == "nginx:latest" ]]
+  [[ "$(yq_s '.services.app.container_name')" == "web" ]]
+  [[ "$(yq_s '.services.app.ports[0].target')" == "80" ]]
+}
+
+@test "command line: from stdin with line continuations" {
+  run bash -c "printf '%s\n' 'docker container run \\' '  -e FOO=bar \\' '  nginx:latest sh -c \"echo hi\"' | $DOCKER_RUN_EXPORT_BIN run --dre-from-stdin"
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.environment.FOO')" == "bar" ]]
+  [[ "$(yq_s '.services.app.command[0]')" == "sh" ]]
+  [[ "$(yq_s '.services.app.command[1]')" == "-c" ]]
+  [[ "$(yq_s '.services.app.command[2]')" == "echo hi" ]]
+}
+
+@test "command line: env references kept verbatim" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-from-string 'docker run -e TOKEN=$TOKEN alpine:latest'
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.environment.TOKEN')" == '$TOKEN' ]]
+}
+
+@test "command line: dre flags with other formats" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ecs --dre-from-string 'docker run --memory 536870912 alpine:latest'
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.containerDefinitions[0].image')" == "alpine:latest" ]]
+  [[ "$(jq_s '.containerDefinitions[0].memory')" == "512" ]]
+}
+
+@test "command line: rejects non docker run commands" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-from-string 'docker ps'
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"expected \`docker run\`"* ]]
+}
+
+@test "command line: rejects positional arguments" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-from-string 'docker run alpine:latest' busybox
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"cannot be used with --dre-from-stdin or --dre-from-string"* ]]
+}
+
+@test "inspect: from file" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-from-inspect arguments/testdata/inspect-web.json
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.image')" == "nginx:1.27" ]]
+  [[ "$(yq_s '.services.app.container_name')" == "web" ]]
+  [[ "$(yq_s '.services.app.environment.APP_ENV')" == "production" ]]
+  [[ "$(yq_s '.services.app.environment.PATH')" == "null" ]]
+  [[ "$(yq_s '.services.app.cap_add | length')" == "1" ]]
+  [[ "$(yq_s '.services.app.restart')" == "on-failure:3" ]]
+  [[ "$(yq_s '.services.app.command')" == "null" ]]
+}
+
+@test "inspect: from stdin" {
+  run bash -c "cat arguments/testdata/inspect-web.json | $DOCKER_RUN_EXPORT_BIN run --dre-format ecs --dre-from-inspect -"
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.containerDefinitions[0].name')" == "web" ]]
+  [[ "$(jq_s '.containerDefinitions[0].memory')" == "512" ]]
+}
+
+# Multiple containers
+
+@test "multiple containers: groups" {
+  run $DOCKER_RUN_EXPORT_BIN run -- --name redis -v redis-data:/data redis:7 -- --name web --link redis:cache nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services | length')" == "2" ]]
+  [[ "$(yq_s '.services.redis.image')" == "redis:7" ]]
+  [[ "$(yq_s '.services.web.links[0]')" == "redis:cache" ]]
+  [[ "$(yq_s '.volumes["redis-data"].name')" == "redis-data" ]]
+}
+
+@test "multiple containers: from stdin" {
+  run bash -c "printf '%s\n' 'docker run --name web nginx:latest' 'docker run --network container:web --volumes-from web alpine:latest' | $DOCKER_RUN_EXPORT_BIN run --dre-from-stdin"
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.alpine.network_mode')" == "service:web" ]]
+  [[ "$(yq_s '.services.alpine.volumes_from[0]')" == "web" ]]
+}
+
+@test "multiple containers: shared network" {
+  run $DOCKER_RUN_EXPORT_BIN run -- --network backend redis:7 -- --network backend nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.networks.backend.external')" == "true" ]]
+  [[ "$(yq_s '.services.redis.networks | keys | .[0]')" == "backend" ]]
+  [[ "$(yq_s '.services.nginx.networks | keys | .[0]')" == "backend" ]]
+}
+
+@test "multiple containers: ecs" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ecs --dre-project stack -- --name redis redis:7 -- --name web --volumes-from redis:ro nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.family')" == "stack" ]]
+  [[ "$(jq_s '.containerDefinitions | length')" == "2" ]]
+  [[ "$(jq_s '.containerDefinitions[1].volumesFrom[0].sourceContainer')" == "redis" ]]
+  [[ "$(jq_s '.containerDefinitions[1].volumesFrom[0].readOnly')" == "true" ]]
+}
+
+@test "multiple containers: nomad" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format nomad-json --dre-project stack -- --name redis redis:7 -- --name web --link redis nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.Job.TaskGroups | length')" == "1" ]]
+  [[ "$(jq_s '.Job.TaskGroups[0].Tasks | length')" == "2" ]]
+  [[ "$(jq_s '.Job.TaskGroups[0].Networks[0].Mode')" == "bridge" ]]
+  [[ "$(jq_s '.Job.TaskGroups[0].Tasks[1].Config.extra_hosts[0]')" == "redis:127.0.0.1" ]]
+}
+
+@test "multiple containers: duplicate names fail" {
+  run $DOCKER_RUN_EXPORT_BIN run -- --name web nginx:latest -- --name web alpine:latest
+  [[ "$status" -ne 0 ]]
+}
+
+@test "multiple containers: dre flags in a group fail" {
+  run $DOCKER_RUN_EXPORT_BIN run -- nginx:latest -- --dre-format ecs alpine:latest
+  [[ "$status" -ne 0 ]]
+}
+
+# Kubernetes
+
+@test "kubernetes: deployment and service" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --dre-project myapp -e FOO=bar -p 8080:80 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s 'select(.kind == "Deployment") | .metadata.name')" == "myapp" ]]
+  [[ "$(yq_s 'select(.kind == "Deployment") | .spec.template.spec.containers[0].image')" == "nginx:latest" ]]
+  [[ "$(yq_s 'select(.kind == "Deployment") | .spec.template.spec.containers[0].env[0].value')" == "bar" ]]
+  [[ "$(yq_s 'select(.kind == "Deployment") | .spec.template.spec.containers[0].ports[0].containerPort')" == "80" ]]
+  [[ "$(yq_s 'select(.kind == "Service") | .spec.ports[0].port')" == "8080" ]]
+  [[ "$(yq_s 'select(.kind == "Service") | .spec.ports[0].targetPort')" == "80" ]]
+}
+
+@test "kubernetes: no service without ports" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" != *"kind: Service"* ]]
+}
+
+@test "kubernetes: health check probes" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --health-cmd "curl -f localhost" --health-interval 10s --health-retries 3 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].livenessProbe.exec.command[2]')" == "curl -f localhost" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].livenessProbe.periodSeconds')" == "10" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].readinessProbe.failureThreshold')" == "3" ]]
+}
+
+@test "kubernetes: security context and resources" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --cap-add NET_ADMIN --cap-drop ALL --read-only -u 1000:1000 --memory 536870912 --cpus 0.5 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].securityContext.capabilities.add[0]')" == "NET_ADMIN" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].securityContext.readOnlyRootFilesystem')" == "true" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].securityContext.runAsUser')" == "1000" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].resources.limits.cpu')" == "0.5" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].resources.limits.memory')" == "536870912" ]]
+}
+
+@test "kubernetes: no-new-privileges value" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --security-opt no-new-privileges nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"allowPrivilegeEscalation: false"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --security-opt no-new-privileges=false nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"allowPrivilegeEscalation: true"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --security-opt no-new-privileges:false nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"allowPrivilegeEscalation: true"* ]]
+}
+
+@test "kubernetes: volumes" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes -v /srv/data:/data:ro -v pgdata:/var/lib/postgresql --tmpfs /run:size=64m nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.spec.template.spec.volumes[0].emptyDir.medium')" == "Memory" ]]
+  [[ "$(yq_s '.spec.template.spec.volumes[1].hostPath.path')" == "/srv/data" ]]
+  [[ "$(yq_s '.spec.template.spec.volumes[2].persistentVolumeClaim.claimName')" == "pgdata" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].volumeMounts[1].readOnly')" == "true" ]]
+}
+
+@test "kubernetes: unsupported flags warn" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes --ulimit nofile=1024 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --ulimit property in kubernetes manifest"* ]]
+}
+
+@test "kubernetes: multiple containers fail" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes -- nginx:latest -- alpine:latest
+  [[ "$status" -ne 0 ]]
+}
+
+@test "kubernetes-job: job runs the container once" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes-job --dre-project backup --rm -d alpine:latest echo backup
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.kind')" == "Job" ]]
+  [[ "$(yq_s '.metadata.name')" == "backup" ]]
+  [[ "$(yq_s '.spec.backoffLimit')" == "0" ]]
+  [[ "$(yq_s '.spec.ttlSecondsAfterFinished')" == "0" ]]
+  [[ "$(yq_s '.spec.template.spec.restartPolicy')" == "Never" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].args[1]')" == "backup" ]]
+}
+
+@test "kubernetes-job: restart on-failure and stop-timeout" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes-job --restart on-failure:3 --stop-timeout 30 alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.spec.backoffLimit')" == "3" ]]
+  [[ "$(yq_s '.spec.ttlSecondsAfterFinished')" == "null" ]]
+  [[ "$(yq_s '.spec.template.spec.restartPolicy')" == "OnFailure" ]]
+  [[ "$(yq_s '.spec.template.spec.terminationGracePeriodSeconds')" == "30" ]]
+}
+
+@test "kubernetes-job: schedule wraps the job in a cronjob" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes-job --dre-project backup --dre-schedule "0 3 * * *" alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.kind')" == "CronJob" ]]
+  [[ "$(yq_s '.spec.schedule')" == "0 3 * * *" ]]
+  [[ "$(yq_s '.spec.jobTemplate.spec.template.spec.restartPolicy')" == "Never" ]]
+  [[ "$(yq_s '.spec.jobTemplate.spec.template.spec.containers[0].image')" == "alpine:latest" ]]
+}
+
+@test "kubernetes-job: invalid schedule fails" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kubernetes-job --dre-schedule "every day" alpine:latest
+  [[ "$status" -ne 0 ]]
+  [[ "$output" == *"invalid --dre-schedule value"* ]]
+}
+
+# Quadlet
+
+@test "quadlet: container unit" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --name web -e FOO=bar -p 8080:80 -v /srv:/data:ro --cap-add NET_ADMIN nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "${lines[0]}" == "[Unit]" ]]
+  [[ "$output" == *"[Container]"* ]]
+  [[ "$output" == *"Image=nginx:latest"* ]]
+  [[ "$output" == *"ContainerName=web"* ]]
+  [[ "$output" == *"Environment=FOO=bar"* ]]
+  [[ "$output" == *"PublishPort=8080:80"* ]]
+  [[ "$output" == *"Volume=/srv:/data:ro"* ]]
+  [[ "$output" == *"AddCapability=NET_ADMIN"* ]]
+  [[ "$output" == *"WantedBy=default.target"* ]]
+}
+
+@test "quadlet: command and quoting" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --dre-from-string "docker run -e 'MSG=hello world' alpine:latest sh -c 'echo 100% done'"
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'Environment="MSG=hello world"'* ]]
+  [[ "$output" == *'Exec=sh -c "echo 100%% done"'* ]]
+}
+
+@test "quadlet: no-new-privileges value" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --security-opt no-new-privileges:true nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"NoNewPrivileges=true"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --security-opt no-new-privileges=false nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"NoNewPrivileges=false"* ]]
+  [[ "$output" != *"PodmanArgs"* ]]
+}
+
+@test "quadlet: cleared entrypoint" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --entrypoint "" nginx:latest sh
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"Entrypoint=[]"* ]]
+  [[ "$output" == *"Exec=sh"* ]]
+}
+
+@test "quadlet: restart policy" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --restart on-failure:5 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"Restart=on-failure"* ]]
+  [[ "$output" == *"StartLimitIntervalSec=infinity"* ]]
+  [[ "$output" == *"StartLimitBurst=6"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --restart unless-stopped nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"Restart=always"* ]]
+}
+
+@test "quadlet: flags without a key use PodmanArgs" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --memory 536870912 --privileged nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"PodmanArgs=--memory=536870912"* ]]
+  [[ "$output" == *"PodmanArgs=--privileged"* ]]
+}
+
+@test "quadlet: unsupported flags warn" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format quadlet --link db nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --link property in quadlet unit"* ]]
+}
+
+# systemd
+
+@test "systemd: service unit" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --name web -d --rm -p 8080:80 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "${lines[0]}" == "[Unit]" ]]
+  [[ "$output" == *"Requires=docker.service"* ]]
+  [[ "$output" == *"ExecStartPre=-/usr/bin/docker rm --force web"* ]]
+  [[ "$output" == *"ExecStartPre=-/usr/bin/docker pull nginx:latest"* ]]
+  [[ "$output" == *"ExecStart=/usr/bin/docker run --name web --publish 8080:80 --rm nginx:latest"* ]]
+  [[ "$output" == *"NoNewPrivileges=true"* ]]
+  [[ "$output" == *"WantedBy=multi-user.target"* ]]
+}
+
+@test "systemd: restart, stop and env-file" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --restart on-failure:3 --stop-timeout 30 --stop-signal SIGQUIT --env-file /etc/web.env nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"Restart=on-failure"* ]]
+  [[ "$output" == *"StartLimitIntervalSec=infinity"* ]]
+  [[ "$output" == *"StartLimitBurst=4"* ]]
+  [[ "$output" == *"TimeoutStopSec=30"* ]]
+  [[ "$output" == *"KillSignal=SIGQUIT"* ]]
+  [[ "$output" == *"EnvironmentFile=/etc/web.env"* ]]
+  [[ "$output" != *"--restart"* ]]
+}
+
+@test "systemd: relative env-file is rejected" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --env-file ./web.env nginx:latest
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set --env-file ./web.env in systemd unit as EnvironmentFile requires an absolute path"* ]]
+}
+
+@test "systemd: interactive and tty are removed" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --name web -it nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --interactive property in systemd unit as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --tty property in systemd unit as the property is not supported"* ]]
+  [[ "$output" == *"ExecStart=/usr/bin/docker run --name web --rm nginx:latest"* ]]
+}
+
+@test "systemd: private tmp is left out for files under tmp" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --env-file /etc/web.env nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"PrivateTmp=true"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --env-file /tmp/envf nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"EnvironmentFile=/tmp/envf"* ]]
+  [[ "$output" != *"PrivateTmp"* ]]
+  [[ "$output" == *"ProtectSystem=full"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --cidfile /var/tmp/web.cid nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" != *"PrivateTmp"* ]]
+}
+
+@test "systemd: pull policy" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --pull always nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"ExecStartPre=/usr/bin/docker pull nginx:latest"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --pull never nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" != *"docker pull"* ]]
+}
+
+@test "systemd: command quoting" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format systemd --dre-from-string "docker run --name web nginx:latest nginx -g 'daemon off;'"
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'nginx:latest nginx -g "daemon off;"'* ]]
+}
+
+# Dokku
+
+@test "dokku: app commands" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format dokku --dre-project myapp -e FOO=bar -p 8080:80 -v /srv/data:/data --memory 536870912 --cpus 0.5 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "${lines[0]}" == "dokku apps:create myapp" ]]
+  [[ "$output" == *"dokku config:set --no-restart myapp FOO=bar"* ]]
+  [[ "$output" == *"dokku ports:set myapp http:8080:80"* ]]
+  [[ "$output" == *"dokku storage:mount myapp /srv/data:/data"* ]]
+  [[ "$output" == *"dokku resource:limit --cpu 0.5 --memory 512m myapp"* ]]
+  [[ "${lines[${#lines[@]}-1]}" == "dokku git:from-image myapp nginx:latest" ]]
+}
+
+@test "dokku: named volumes and networks" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format dokku --dre-project myapp -v pgdata:/var/lib/postgresql --network backend postgres:16
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"dokku storage:ensure-directory pgdata"* ]]
+  [[ "$output" == *"dokku storage:mount myapp /var/lib/dokku/data/storage/pgdata:/var/lib/postgresql"* ]]
+  [[ "$output" == *"dokku network:set myapp initial-network backend"* ]]
+}
+
+@test "dokku: other flags use docker-options" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format dokku --dre-project myapp --cap-add NET_ADMIN --restart always nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"dokku docker-options:add myapp deploy,run '--cap-add NET_ADMIN'"* ]]
+  [[ "$output" == *"dokku ps:set myapp restart-policy always"* ]]
+}
+
+@test "dokku: healthchecks" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format dokku --dre-project myapp --health-cmd "curl -f localhost" --health-retries 3 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"cat > app.json <<'EOF'"* ]]
+  [[ "$(echo "$output" | sed -n '/^{/,/^}/p' | jq -r '.healthchecks.web[0].command[2]')" == "curl -f localhost" ]]
+  [[ "$(echo "$output" | sed -n '/^{/,/^}/p' | jq -r '.healthchecks.web[0].attempts')" == "3" ]]
+}
+
+# Docker Swarm
+
+@test "swarm-stack: deploy settings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack --dre-swarm-replicas 3 -l tier=web --memory-reservation 268435456 --restart on-failure:3 -p 8080:80 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.version')" == "3.8" ]]
+  [[ "$(yq_s '.name')" == "null" ]]
+  [[ "$(yq_s '.services.app.deploy.replicas')" == "3" ]]
+  [[ "$(yq_s '.services.app.deploy.labels.tier')" == "web" ]]
+  [[ "$(yq_s '.services.app.deploy.resources.reservations.memory')" == "268435456" ]]
+  [[ "$(yq_s '.services.app.deploy.restart_policy.condition')" == "on-failure" ]]
+  [[ "$(yq_s '.services.app.deploy.restart_policy.max_attempts')" == "3" ]]
+  [[ "$(yq_s '.services.app.restart')" == "null" ]]
+  [[ "$(yq_s '.services.app.ports[0].published')" == "8080" ]]
+}
+
+@test "swarm-stack: restart no disables restarts" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack --restart no nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.deploy.restart_policy.condition')" == "none" ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.app.deploy.restart_policy.condition')" == "none" ]]
+}
+
+@test "swarm-stack: host ip of published ports is removed" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack -p 127.0.0.1:8080:80 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set host ip 127.0.0.1 of --publish 127.0.0.1:8080:80 in swarm stack as the property is not supported"* ]]
+  [[ "$output" == *"published: 8080"* ]]
+  [[ "$output" != *"host_ip"* ]]
+}
+
+@test "swarm-stack: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack --privileged --device /dev/fuse --cap-add NET_ADMIN alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --privileged property in swarm stack as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --device property in swarm stack as the property is not supported"* ]]
+  [[ "$output" == *"--cap-add and --cap-drop require Docker Engine 20.10 or newer"* ]]
+  [[ "$output" != *"privileged: true"* ]]
+}
+
+@test "swarm-stack: multiple containers" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-stack --dre-swarm-replicas 2 -- --name db postgres:16 -- --name web --link db nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.db.deploy.replicas')" == "2" ]]
+  [[ "$(yq_s '.services.web.deploy.replicas')" == "2" ]]
+  [[ "$(yq_s '.services.web.links')" == "null" ]]
+}
+
+@test "swarm-service: docker service create command" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service --name web --restart always -v data:/data -p 8080:80 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker service create --mount type=volume,source=data,target=/data --name web --publish published=8080,target=80 --replicas 1 --restart-condition any nginx:latest" ]]
+}
+
+@test "swarm-service: restart no disables restarts" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service --restart no nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker service create --name nginx --replicas 1 --restart-condition none nginx:latest" ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker service create --name nginx --replicas 1 --restart-condition none nginx:latest" ]]
+}
+
+@test "swarm-service: renamed flags" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service --dre-swarm-replicas 2 --memory 536870912 --memory-reservation 268435456 --cpus 0.5 --network backend --network-alias api --tmpfs /tmp:size=64m nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"--limit-memory 536870912"* ]]
+  [[ "$output" == *"--reserve-memory 268435456"* ]]
+  [[ "$output" == *"--limit-cpu 0.5"* ]]
+  [[ "$output" == *"--network name=backend,alias=api"* ]]
+  [[ "$output" == *"--mount type=tmpfs,target=/tmp,tmpfs-size=67108864"* ]]
+  [[ "$output" == *"--replicas 2"* ]]
+}
+
+@test "swarm-service: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service --privileged --expose 8080 alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --privileged property in docker service create as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --expose property in docker service create as the property is not supported"* ]]
+}
+
+@test "swarm-service: multiple containers are rejected" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format swarm-service -- nginx:latest -- redis:7
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"swarm-service format does not support exporting several containers"* ]]
+}
+
+# docker run
+
+@test "docker-run: canonical command" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run --dre-from-string "docker run -d -p 8080:80 -e 'MSG=hello world' --name web --restart no nginx:latest"
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker run --detach --env 'MSG=hello world' --name web --publish 8080:80 nginx:latest" ]]
+}
+
+@test "docker-run: boolean flags that default to true" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run --sig-proxy=false --init alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker run --init --sig-proxy=false alpine:latest" ]]
+}
+
+@test "docker-run: cleared entrypoint is kept" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run --dre-from-string "docker run --entrypoint '' alpine:latest sh"
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "docker run --entrypoint '' alpine:latest sh" ]]
+}
+
+@test "docker-run: output round-trips" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run --dre-from-string "docker run -it --rm -v \$PWD:/src -w /src --sysctl net.core.somaxconn=1024 --health-cmd 'curl -f localhost' alpine:latest sh -c 'echo hi'"
+  [[ "$status" -eq 0 ]]
+  first="$output"
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run --dre-from-string "$first"
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == "$first" ]]
+}
+
+@test "docker-run: multiple containers" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format docker-run -- -p 80:80 nginx:latest -- --name db redis:7
+  [[ "$status" -eq 0 ]]
+  [[ "${lines[0]}" == "docker run --publish 80:80 nginx:latest" ]]
+  [[ "${lines[1]}" == "docker run --name db redis:7" ]]
+}
+
+# Terraform Docker
+
+@test "terraform-docker: image and container resources" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format terraform-docker --name web -e FOO=bar -p 8080:80 -v data:/data --restart on-failure:3 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'source  = "kreuzwerker/docker"'* ]]
+  [[ "$output" == *'resource "docker_image" "web" {'* ]]
+  [[ "$output" == *'resource "docker_container" "web" {'* ]]
+  [[ "$output" == *'image = docker_image.web.image_id'* ]]
+  [[ "$output" == *'env   = ["FOO=bar"]'* ]]
+  [[ "$output" == *'external = 8080'* ]]
+  [[ "$output" == *'volume_name    = "data"'* ]]
+  [[ "$output" == *'restart = "on-failure"'* ]]
+  [[ "$output" == *'max_retry_count = 3'* ]]
+}
+
+@test "terraform-docker: healthcheck, capabilities and networks" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format terraform-docker --health-cmd "curl -f localhost" --health-interval 30s --cap-add NET_ADMIN --network backend --network-alias api nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'test     = ["CMD-SHELL", "curl -f localhost"]'* ]]
+  [[ "$output" == *'add = ["NET_ADMIN"]'* ]]
+  [[ "$output" == *'aliases = ["api"]'* ]]
+  [[ "$output" == *'name    = "backend"'* ]]
+}
+
+@test "terraform-docker: sizes are rounded up to whole megabytes" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format terraform-docker --shm-size 1000000 --memory 1073741824 alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'shm_size = 1'* ]]
+  [[ "$output" == *'memory   = 1024'* ]]
+  [[ "$output" == *"rounding --shm-size 1000000 up to 1 MB in terraform docker_container"* ]]
+  [[ "$output" != *"rounding --memory "* ]]
+}
+
+@test "terraform-docker: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format terraform-docker --memory-reservation 1048576 --expose 8080 alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --memory-reservation property in terraform docker_container as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --expose property in terraform docker_container as the property is not supported"* ]]
+}
+
+# ECS Terraform
+
+@test "ecs-terraform: task definition resource with variables" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-project myapp --dre-format ecs-terraform --dre-ecs-execution-role-arn arn:aws:iam::123456789:role/exec --dre-ecs-launch-type FARGATE -p 8080:80 --cpus 0.5 --memory 1073741824 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'source  = "hashicorp/aws"'* ]]
+  [[ "$output" == *'resource "aws_ecs_task_definition" "myapp" {'* ]]
+  [[ "$output" == *'default     = "nginx:latest"'* ]]
+  [[ "$output" == *'default     = "arn:aws:iam::123456789:role/exec"'* ]]
+  [[ "$output" == *'default     = null'* ]]
+  [[ "$output" == *'execution_role_arn = var.execution_role_arn'* ]]
+  [[ "$output" == *'container_definitions = jsonencode([{'* ]]
+  [[ "$output" == *'image  = var.image'* ]]
+  [[ "$output" == *'containerPort = 80'* ]]
+  [[ "$output" == *'cpu                      = "512"'* ]]
+  [[ "$output" == *'requires_compatibilities = ["FARGATE"]'* ]]
+}
+
+@test "ecs-terraform: runtime platform and volume blocks" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ecs-terraform --platform linux/arm64 -v /srv:/data alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'cpu_architecture        = "ARM64"'* ]]
+  [[ "$output" == *'host_path = "/srv"'* ]]
+  [[ "$output" == *'sourceVolume  = "volume-0"'* ]]
+}
+
+@test "ecs-terraform: one image variable per container" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-project stack --dre-format ecs-terraform -- --name web nginx:latest -- --name db postgres:16
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'variable "web_image" {'* ]]
+  [[ "$output" == *'variable "db_image" {'* ]]
+  [[ "$output" == *'image     = var.db_image'* ]]
+}
+
+# Ansible
+
+@test "ansible: docker_container task" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --name web -e FOO=bar -p 8080:80 -v data:/data --restart on-failure:3 --memory 536870912 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0].name')" == "Start web container" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".name')" == "web" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".image')" == "nginx:latest" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".state')" == "started" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".env.FOO')" == "bar" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".published_ports[0]')" == "8080:80" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".volumes[0]')" == "data:/data" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".restart_policy')" == "on-failure" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".restart_retries')" == "3" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".memory')" == "512M" ]]
+}
+
+@test "ansible: image pull task" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --dre-ansible-pull-image nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_image".name')" == "nginx:latest" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_image".source')" == "pull" ]]
+  [[ "$(yq_s '.[1]."community.docker.docker_container".image')" == "nginx:latest" ]]
+}
+
+@test "ansible: healthcheck, networks and mounts" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --health-cmd "curl -f localhost" --health-interval 30s --network backend --network-alias api --mount type=tmpfs,target=/cache,tmpfs-size=67108864 --ulimit nofile=1024:2048 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".healthcheck.test[1]')" == "curl -f localhost" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".healthcheck.interval')" == "30s" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".networks[0].name')" == "backend" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".networks[0].aliases[0]')" == "api" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".mounts[0].tmpfs_size')" == "64M" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".ulimits[0]')" == "nofile:1024:2048" ]]
+}
+
+@test "ansible: multiple containers" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible -- -v redis-data:/data redis:7 -- --name web --link redis:cache nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".name')" == "redis" ]]
+  [[ "$(yq_s '.[1]."community.docker.docker_container".links[0]')" == "redis:cache" ]]
+}
+
+@test "ansible: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --label-file labels.txt alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --label-file property in ansible docker_container as the property is not supported"* ]]
+}
+
+@test "ansible: gpus become device requests" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --gpus all alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".device_requests[0].count')" == "-1" ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".device_requests[0].capabilities[0][0]')" == "gpu" ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format ansible --gpus '"device=0,1"' alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.[0]."community.docker.docker_container".device_requests[0].device_ids[1]')" == "1" ]]
+}
+
+# Kamal
+
+@test "kamal: deploy.yml skeleton" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kamal --dre-project myapp -e RAILS_ENV=production -p 8080:3000 -v data:/rails/storage -l com.example=test ghcr.io/acme/app:latest bin/rails server
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.service')" == "myapp" ]]
+  [[ "$(yq_s '.image')" == "acme/app" ]]
+  [[ "$(yq_s '.registry.server')" == "ghcr.io" ]]
+  [[ "$(yq_s '.servers.web.cmd')" == "bin/rails server" ]]
+  [[ "$(yq_s '.proxy.app_port')" == "3000" ]]
+  [[ "$(yq_s '.env.clear.RAILS_ENV')" == "production" ]]
+  [[ "$(yq_s '.volumes[0]')" == "data:/rails/storage" ]]
+  [[ "$(yq_s '.labels."com.example"')" == "test" ]]
+}
+
+@test "kamal: credentials move to env.secret" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kamal -e DATABASE_PASSWORD=hunter2 -e API_TOKEN=abc -e HOME -e KEYBOARD=us alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"moved --env DATABASE_PASSWORD, API_TOKEN, HOME to env.secret in kamal deploy.yml"* ]]
+  [[ "$output" != *"hunter2"* ]]
+  [[ "$output" == *"KEYBOARD: us"* ]]
+}
+
+@test "kamal: remaining flags become options" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kamal --init --add-host a:10.0.0.1 --add-host b:10.0.0.2 -p 80:80 -p 9090:9090 --memory 536870912 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"init: true"* ]]
+  [[ "$output" == *"- a:10.0.0.1"* ]]
+  [[ "$output" == *"- b:10.0.0.2"* ]]
+  [[ "$output" == *"publish: 9090:9090"* ]]
+  [[ "$output" == *'memory: "536870912"'* ]]
+}
+
+@test "kamal: flags managed by kamal emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kamal --network backend --env-file app.env --restart on-failure nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --network property in kamal deploy.yml as kamal attaches containers to the kamal network"* ]]
+  [[ "$output" == *"unable to set --env-file property in kamal deploy.yml"* ]]
+  [[ "$output" == *"unable to set --restart on-failure in kamal deploy.yml"* ]]
+}
+
+# Cloud Run
+
+@test "cloudrun: knative service" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun --dre-project my-api --dre-from-string "docker run -e NODE_ENV=production -p 8080:3000 --memory 536870912 --cpus 0.5 --workdir /app --entrypoint /entrypoint.sh -l team=core -l com.example.owner=me ghcr.io/acme/api:1.2.3 node server.js"
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.apiVersion')" == "serving.knative.dev/v1" ]]
+  [[ "$(yq_s '.kind')" == "Service" ]]
+  [[ "$(yq_s '.metadata.name')" == "my-api" ]]
+  [[ "$(yq_s '.metadata.labels.team')" == "core" ]]
+  [[ "$(yq_s '.metadata.annotations."com.example.owner"')" == "me" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].image')" == "ghcr.io/acme/api:1.2.3" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].command[0]')" == "/entrypoint.sh" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].args[1]')" == "server.js" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].env[0].value')" == "production" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].ports[0].containerPort')" == "3000" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].resources.limits.cpu')" == "500m" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].resources.limits.memory')" == "512Mi" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].workingDir')" == "/app" ]]
+}
+
+@test "cloudrun: health-cmd becomes startup and liveness probes" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun -p 8080:8080 --health-cmd "curl -f http://localhost:8080/up" --health-interval 10s --health-start-period 30s nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].startupProbe.httpGet.path')" == "/up" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].startupProbe.httpGet.port')" == "8080" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].startupProbe.exec')" == "null" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].startupProbe.initialDelaySeconds')" == "30" ]]
+  [[ "$(yq_s '.spec.template.spec.containers[0].livenessProbe.periodSeconds')" == "10" ]]
+}
+
+@test "cloudrun: health-cmd without an http check is skipped" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun --health-cmd "pg_isready" nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --health-cmd in cloudrun service as probes only support http, tcp and grpc checks"* ]]
+  [[ "$output" != *"startupProbe"* ]]
+}
+
+@test "cloudrun: fractional cpus above one are rounded up" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun --cpus 1.5 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"rounding --cpus 1.5 up to 2 in cloudrun service"* ]]
+  [[ "$output" == *'cpu: "2"'* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun --cpus 16 nginx:latest
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set --cpus 16 in cloudrun service as Cloud Run allows at most 8 cpus"* ]]
+}
+
+@test "cloudrun: only the first port is exported" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun -p 80:80 -p 9090:9090 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set port 9090/tcp in cloudrun service as Cloud Run sends requests to a single port"* ]]
+}
+
+@test "cloudrun: forbidden flags fail the export" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun --privileged --network host --device /dev/fuse -v /srv:/data nginx:latest
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set --privileged property in cloudrun service as Cloud Run does not allow privileged containers"* ]]
+  [[ "$output" == *"unable to set --network host in cloudrun service as Cloud Run does not allow host namespaces"* ]]
+  [[ "$output" == *"unable to set --device property in cloudrun service as Cloud Run does not allow host devices"* ]]
+  [[ "$output" == *"unable to set --volume /srv:/data in cloudrun service as Cloud Run does not allow bind mounts"* ]]
+}
+
+@test "cloudrun: reserved env variables fail the export" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format cloudrun -e PORT=80 nginx:latest
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set --env PORT in cloudrun service as Cloud Run reserves the variable"* ]]
+}
+
+# Azure Container Instances
+
+@test "aci: container group" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aci --dre-project web -e FOO=bar -p 80:80 --cpus 0.5 --memory 536870912 --restart always -l team=core nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.name')" == "web" ]]
+  [[ "$(yq_s '.type')" == "Microsoft.ContainerInstance/containerGroups" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.image')" == "nginx:latest" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.environmentVariables[0].value')" == "bar" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.resources.requests.cpu')" == "0.5" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.resources.requests.memoryInGB')" == "0.5" ]]
+  [[ "$(yq_s '.properties.ipAddress.ports[0].port')" == "80" ]]
+  [[ "$(yq_s '.properties.restartPolicy')" == "Always" ]]
+  [[ "$(yq_s '.tags.team')" == "core" ]]
+}
+
+@test "aci: resources are rounded up" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aci --cpus 0.25 --memory 1000000000 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"rounding --cpus 0.25 up to 0.3 in aci container group"* ]]
+  [[ "$output" == *"rounding --memory 1000000000 up to 1 GB in aci container group"* ]]
+}
+
+@test "aci: volumes become emptyDir and azureFile volumes" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aci -v data:/data:ro -v /cache -v /srv:/srv nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to bind mount /srv for --volume /srv:/srv in aci container group as host paths are not available, mounting the emptyDir volume bind-2 instead"* ]]
+  [[ "$output" == *"shareName: data"* ]]
+  [[ "$output" == *"emptyDir: {}"* ]]
+  [[ "$output" == *"readOnly: true"* ]]
+  [[ "$output" == *"- name: bind-2"$'\n'"        mountPath: /srv"* ]]
+  [[ "$output" == *"- name: bind-2"$'\n'"    emptyDir: {}"* ]]
+}
+
+@test "aci: health-cmd becomes a liveness probe" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aci --health-cmd "curl -f localhost" --health-interval 15s --health-retries 3 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.properties.containers[0].properties.livenessProbe.exec.command[2]')" == "curl -f localhost" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.livenessProbe.periodSeconds')" == "15" ]]
+  [[ "$(yq_s '.properties.containers[0].properties.livenessProbe.failureThreshold')" == "3" ]]
+}
+
+@test "aci: multiple containers share a container group" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aci --dre-project stack -- --name web -p 80:80 --link db nginx -- --name db -v data:/var/lib/postgresql/data postgres
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"web: containers of an aci container group reach each other on localhost rather than through --link"* ]]
+  [[ "$output" == *"- name: web"* ]]
+  [[ "$output" == *"- name: db"* ]]
+  [[ "$output" == *"shareName: data"* ]]
+}
+
+# Fly.io
+
+@test "fly: fly.toml" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format fly --dre-project my-app -e RAILS_ENV=production -p 80:3000 -p 5432:5432 -v storage:/rails/storage --restart always ghcr.io/acme/app:latest bin/rails server
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *'app = "my-app"'* ]]
+  [[ "$output" == *'image = "ghcr.io/acme/app:latest"'* ]]
+  [[ "$output" == *'RAILS_ENV = "production"'* ]]
+  [[ "$output" == *'app = "bin/rails server"'* ]]
+  [[ "$output" == *"[http_service]"*"internal_port = 3000"* ]]
+  [[ "$output" == *"[[services]]"*"internal_port = 5432"* ]]
+  [[ "$output" == *'source = "storage"'* ]]
+  [[ "$output" == *'destination = "/rails/storage"'* ]]
+  [[ "$output" == *'policy = "always"'* ]]
+}
+
+@test "fly: vm sizing is rounded to presets" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format fly --cpus 1.5 --memory 5000000000 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"rounding --cpus 1.5 up to the shared-cpu-2x preset in fly.toml"* ]]
+  [[ "$output" == *"raising the machine size to shared-cpu-4x in fly.toml to fit --memory 5000000000"* ]]
+  [[ "$output" == *'size = "shared-cpu-4x"'* ]]
+  [[ "$output" == *'memory = "4864mb"'* ]]
+}
+
+@test "fly: health-cmd becomes a check" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format fly -p 80:3000 --health-cmd "curl -f http://localhost:3000/up" --health-interval 15s nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"[checks.health]"* ]]
+  [[ "$output" == *'type = "http"'* ]]
+  [[ "$output" == *'path = "/up"'* ]]
+  [[ "$output" == *'interval = "15s"'* ]]
+}
+
+@test "fly: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format fly --entrypoint /bin/sh -v a:/a -v b:/b --user 1000 nginx:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --entrypoint property in fly.toml"* ]]
+  [[ "$output" == *"unable to set --volume b:/b in fly.toml as fly machines mount a single volume"* ]]
+  [[ "$output" == *"unable to set --user property in fly.toml as the property is not supported"* ]]
+}
+
+# AWS Batch
+
+@test "aws-batch: job definition" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aws-batch --dre-project nightly -e MODE=full --cpus 2 --memory 4294967296 --gpus 1 -l team=data acme/etl:1.0 run.sh
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.jobDefinitionName')" == "nightly" ]]
+  [[ "$(jq_s '.type')" == "container" ]]
+  [[ "$(jq_s '.platformCapabilities[0]')" == "EC2" ]]
+  [[ "$(jq_s '.containerProperties.image')" == "acme/etl:1.0" ]]
+  [[ "$(jq_s '.containerProperties.command[0]')" == "run.sh" ]]
+  [[ "$(jq_s '.containerProperties.environment[0].value')" == "full" ]]
+  [[ "$(jq_s '.containerProperties.resourceRequirements[] | select(.type == "VCPU") | .value')" == "2" ]]
+  [[ "$(jq_s '.containerProperties.resourceRequirements[] | select(.type == "MEMORY") | .value')" == "4096" ]]
+  [[ "$(jq_s '.containerProperties.resourceRequirements[] | select(.type == "GPU") | .value')" == "1" ]]
+  [[ "$(jq_s '.tags.team')" == "data" ]]
+}
+
+@test "aws-batch: restart and stop-timeout become retry strategy and timeout" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aws-batch --restart on-failure:2 --stop-timeout 3600 acme/etl:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.retryStrategy.attempts')" == "3" ]]
+  [[ "$(jq_s '.timeout.attemptDurationSeconds')" == "3600" ]]
+}
+
+@test "aws-batch: fargate rounds resources to a fargate size" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aws-batch --dre-ecs-launch-type FARGATE --cpus 0.75 --memory 3000000000 --privileged -v /srv:/srv acme/etl:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --privileged property in aws batch job definition as fargate does not support privileged containers"* ]]
+  [[ "$output" == *"unable to mount host path /srv in aws batch job definition as fargate does not support host volumes"* ]]
+  [[ "$(jq_s '.platformCapabilities[0]')" == "FARGATE" ]]
+  [[ "$(jq_s '.containerProperties.resourceRequirements[] | select(.type == "VCPU") | .value')" == "1" ]]
+  [[ "$(jq_s '.containerProperties.resourceRequirements[] | select(.type == "MEMORY") | .value')" == "3072" ]]
+  [[ "$(jq_s '.containerProperties.privileged')" == "null" ]]
+}
+
+@test "aws-batch: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format aws-batch --entrypoint /bin/sh -p 80:80 --restart always acme/etl:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --entrypoint property in aws batch job definition as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --publish property in aws batch job definition as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --restart always in aws batch job definition as batch jobs run to completion"* ]]
+}
+
+# AWS App Runner
+
+@test "apprunner: createservice input" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner --dre-project myapp -e NODE_ENV=production -p 80:3000 --cpus 1 --memory 3221225472 -l team=web 123456789012.dkr.ecr.us-east-1.amazonaws.com/acme/app:1.0 npm start
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.ServiceName')" == "myapp" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageIdentifier')" == "123456789012.dkr.ecr.us-east-1.amazonaws.com/acme/app:1.0" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageRepositoryType')" == "ECR" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageConfiguration.Port')" == "3000" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageConfiguration.RuntimeEnvironmentVariables.NODE_ENV')" == "production" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageConfiguration.StartCommand')" == "npm start" ]]
+  [[ "$(jq_s '.SourceConfiguration.AuthenticationConfiguration.AccessRoleArn')" == "arn:aws:iam::123456789012:role/service-role/AppRunnerECRAccessRole" ]]
+  [[ "$(jq_s '.InstanceConfiguration.Cpu')" == "1 vCPU" ]]
+  [[ "$(jq_s '.InstanceConfiguration.Memory')" == "3 GB" ]]
+  [[ "$(jq_s '.Tags[0].Key')" == "team" ]]
+}
+
+@test "apprunner: resources are rounded to an instance size" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner -p 8080 --cpus 0.3 --memory 3221225472 public.ecr.aws/acme/app
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"rounding --cpus 0.3 up to 0.5 in apprunner service to match an instance size"* ]]
+  [[ "$output" == *"raising the vCPU to 1 in apprunner service to fit --memory 3221225472"* ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageIdentifier')" == "public.ecr.aws/acme/app:latest" ]]
+  [[ "$(jq_s '.SourceConfiguration.ImageRepository.ImageRepositoryType')" == "ECR_PUBLIC" ]]
+  [[ "$(jq_s '.InstanceConfiguration.Cpu')" == "1 vCPU" ]]
+  [[ "$(jq_s '.InstanceConfiguration.Memory')" == "3 GB" ]]
+}
+
+@test "apprunner: health-cmd becomes an http health check" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner -p 3000 --health-cmd "curl -f http://localhost:3000/healthz" --health-interval 30s --health-retries 3 public.ecr.aws/acme/app:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"setting --health-interval 30s to 20 in apprunner service as health checks accept values between 1 and 20"* ]]
+  [[ "$(jq_s '.HealthCheckConfiguration.Protocol')" == "HTTP" ]]
+  [[ "$(jq_s '.HealthCheckConfiguration.Path')" == "/healthz" ]]
+  [[ "$(jq_s '.HealthCheckConfiguration.Interval')" == "20" ]]
+  [[ "$(jq_s '.HealthCheckConfiguration.UnhealthyThreshold')" == "3" ]]
+}
+
+@test "apprunner: health-cmd without a url becomes a tcp health check" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner -p 3000 --health-cmd "pg_isready" public.ecr.aws/acme/app:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to run --health-cmd in apprunner service as health checks only support http and tcp, checking the tcp port instead"* ]]
+  [[ "$(jq_s '.HealthCheckConfiguration.Protocol')" == "TCP" ]]
+}
+
+@test "apprunner: unsupported flags are errors" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner -v data:/data --cap-add NET_ADMIN -p 80:80 -p 443:443 nginx
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to use image nginx:latest in apprunner service as only amazon ecr and ecr public images are supported"* ]]
+  [[ "$output" == *"unable to publish port 443 in apprunner service as services serve a single port, and port 80 is already published"* ]]
+  [[ "$output" == *"unable to set --volume property in apprunner service as the property is not supported"* ]]
+  [[ "$output" == *"unable to set --cap-add property in apprunner service as the property is not supported"* ]]
+}
+
+@test "apprunner-cfn: cloudformation template" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format apprunner-cfn --dre-project myapp -e NODE_ENV=production -p 3000 public.ecr.aws/acme/app:1.0
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.Resources.Service.Type')" == "AWS::AppRunner::Service" ]]
+  [[ "$(yq_s '.Resources.Service.Properties.ServiceName')" == "myapp" ]]
+  [[ "$(yq_s '.Resources.Service.Properties.SourceConfiguration.ImageRepository.ImageConfiguration.Port')" == "3000" ]]
+  [[ "$(yq_s '.Resources.Service.Properties.SourceConfiguration.ImageRepository.ImageConfiguration.RuntimeEnvironmentVariables[0].Name')" == "NODE_ENV" ]]
+  [[ "$(yq_s '.Resources.Service.Properties.SourceConfiguration.ImageRepository.ImageConfiguration.RuntimeEnvironmentVariables[0].Value')" == "production" ]]
+}
+
+# GitHub Actions
+
+@test "github-actions: service container" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format github-actions --name db -e POSTGRES_PASSWORD=postgres -p 5432:5432 -v pgdata:/var/lib/postgresql/data --health-cmd "pg_isready -U postgres" --health-interval 10s postgres:16
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services.db.image')" == "postgres:16" ]]
+  [[ "$(yq_s '.services.db.env.POSTGRES_PASSWORD')" == "postgres" ]]
+  [[ "$(yq_s '.services.db.ports[0]')" == "5432:5432" ]]
+  [[ "$(yq_s '.services.db.volumes[0]')" == "pgdata:/var/lib/postgresql/data" ]]
+  [[ "$(yq_s '.services.db.options')" == '--health-cmd "pg_isready -U postgres" --health-interval 10s' ]]
+}
+
+@test "github-actions: job container" {
+  run bash -c "printf '%s\n' 'docker run --name app --workdir /src node:20' 'docker run --name db postgres:16' | $DOCKER_RUN_EXPORT_BIN run --dre-format github-actions --dre-github-job-container app --dre-from-stdin"
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.container.image')" == "node:20" ]]
+  [[ "$(yq_s '.container.options')" == "--workdir /src" ]]
+  [[ "$(yq_s '.services.db.image')" == "postgres:16" ]]
+  [[ "$(yq_s '.services.app')" == "null" ]]
+}
+
+@test "github-actions: unknown job container is an error" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format github-actions --dre-github-job-container app redis:7
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to use app as the job container as no exported container has that name"* ]]
+}
+
+@test "github-actions: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format github-actions -e TOKEN --network ci --restart always redis:7 redis-server
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set command in github actions service as the runner does not support overriding it"* ]]
+  [[ "$output" == *"passing through --env TOKEN in github actions service as the secret of the same name"* ]]
+  [[ "$output" == *"unable to set --network property in github actions service as the runner attaches containers to the network of the job"* ]]
+  [[ "$output" == *"unable to set --restart property in github actions service as the runner removes containers when the job ends"* ]]
+  [[ "$output" == *'TOKEN: ${{ secrets.TOKEN }}'* ]]
+}
+
+# GitLab CI
+
+@test "gitlab-ci: service" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format gitlab-ci --name db --network-alias postgres -e POSTGRES_PASSWORD=postgres --entrypoint docker-entrypoint.sh --user 999 --pull always postgres:16 postgres
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services[0].name')" == "postgres:16" ]]
+  [[ "$(yq_s '.services[0].alias')" == "db,postgres" ]]
+  [[ "$(yq_s '.services[0].entrypoint[0]')" == "docker-entrypoint.sh" ]]
+  [[ "$(yq_s '.services[0].command[0]')" == "postgres" ]]
+  [[ "$(yq_s '.services[0].variables.POSTGRES_PASSWORD')" == "postgres" ]]
+  [[ "$(yq_s '.services[0].pull_policy')" == "always" ]]
+  [[ "$(yq_s '.services[0].docker.user')" == "999" ]]
+}
+
+@test "gitlab-ci: multiple services" {
+  run bash -c "printf '%s\n' 'docker run --name db postgres:16' 'docker run redis:7' | $DOCKER_RUN_EXPORT_BIN run --dre-format gitlab-ci --dre-from-stdin"
+  [[ "$status" -eq 0 ]]
+  [[ "$(yq_s '.services[0].alias')" == "db" ]]
+  [[ "$(yq_s '.services[1].name')" == "redis:7" ]]
+  [[ "$(yq_s '.services[1].alias')" == "redis" ]]
+}
+
+@test "gitlab-ci: unsupported flags emit warnings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format gitlab-ci -e TOKEN -p 6379:6379 --health-cmd "redis-cli ping" -v data:/data redis:7
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --env TOKEN in gitlab ci service as passing through host environment variables is not supported"* ]]
+  [[ "$output" == *"unable to set --publish property in gitlab ci service as services are reachable from the job by alias"* ]]
+  [[ "$output" == *"unable to set --health-cmd property in gitlab ci service as the runner waits for the exposed ports of services instead"* ]]
+  [[ "$output" == *"unable to set --volume property in gitlab ci service as the property is not supported"* ]]
+}
+
+# Dev Containers
+
+@test "devcontainer: native properties" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format devcontainer --dre-project myapp -e NODE_ENV=development -e NPM_TOKEN -v node_modules:/workspace/node_modules -v /var/run/docker.sock:/var/run/docker.sock:ro --cap-add SYS_PTRACE --security-opt seccomp=unconfined --init --privileged --user node node:20
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.name')" == "myapp" ]]
+  [[ "$(jq_s '.image')" == "node:20" ]]
+  [[ "$(jq_s '.containerEnv.NODE_ENV')" == "development" ]]
+  [[ "$(jq_s '.containerEnv.NPM_TOKEN')" == '${localEnv:NPM_TOKEN}' ]]
+  [[ "$(jq_s '.mounts[0]')" == "source=node_modules,target=/workspace/node_modules,type=volume" ]]
+  [[ "$(jq_s '.mounts[1]')" == "source=/var/run/docker.sock,target=/var/run/docker.sock,type=bind,readonly" ]]
+  [[ "$(jq_s '.capAdd[0]')" == "SYS_PTRACE" ]]
+  [[ "$(jq_s '.securityOpt[0]')" == "seccomp=unconfined" ]]
+  [[ "$(jq_s '.init')" == "true" ]]
+  [[ "$(jq_s '.privileged')" == "true" ]]
+  [[ "$(jq_s '.containerUser')" == "node" ]]
+  [[ "$(jq_s '.runArgs')" == "null" ]]
+}
+
+@test "devcontainer: publish becomes forwardPorts and appPort" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format devcontainer -p 3000 -p 9229:9229 -p 8080:80 -p 127.0.0.1:5432:5432 node:20
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.forwardPorts | join(",")')" == "3000,9229" ]]
+  [[ "$(jq_s '.appPort | join(",")')" == "8080:80,127.0.0.1:5432:5432" ]]
+}
+
+@test "devcontainer: other flags become runArgs" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format devcontainer --workdir /workspace --label 'a=b c' -v /src:/src:z --name dev --rm node:20 npm start
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set command in devcontainer.json as the property is not supported"* ]]
+  [[ "$(jq_s '.runArgs | join("|")')" == "--volume|/src:/src:z|--label|a=b c|--workdir|/workspace" ]]
+  [[ "$(jq_s '.name')" == "dev" ]]
+}
+
+# Docker Engine API
+
+@test "engine-api: config and host config" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format engine-api -d --restart on-failure:3 -e FOO=bar -l app=web -p 127.0.0.1:8080:80 --expose 9000 -v data:/data:ro -v /cache --tmpfs /run:size=64m --cpus 0.1 --memory 536870912 --pids-limit 100 --ulimit nofile=1024:2048 --health-cmd "curl -f http://localhost/" --health-interval 30s nginx:1.27 nginx
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.Image')" == "nginx:1.27" ]]
+  [[ "$(jq_s '.Cmd[0]')" == "nginx" ]]
+  [[ "$(jq_s '.Env[0]')" == "FOO=bar" ]]
+  [[ "$(jq_s '.Labels.app')" == "web" ]]
+  [[ "$(jq_s '.ExposedPorts | keys | join(",")')" == "80/tcp,9000/tcp" ]]
+  [[ "$(jq_s '.Volumes | keys | join(",")')" == "/cache" ]]
+  [[ "$(jq_s '.AttachStdout')" == "null" ]]
+  [[ "$(jq_s '.Healthcheck.Test | join("|")')" == "CMD-SHELL|curl -f http://localhost/" ]]
+  [[ "$(jq_s '.Healthcheck.Interval')" == "30000000000" ]]
+  [[ "$(jq_s '.HostConfig.PortBindings["80/tcp"][0].HostIp')" == "127.0.0.1" ]]
+  [[ "$(jq_s '.HostConfig.PortBindings["80/tcp"][0].HostPort')" == "8080" ]]
+  [[ "$(jq_s '.HostConfig.Binds[0]')" == "data:/data:ro" ]]
+  [[ "$(jq_s '.HostConfig.Tmpfs["/run"]')" == "size=64m" ]]
+  [[ "$(jq_s '.HostConfig.NanoCpus')" == "100000000" ]]
+  [[ "$(jq_s '.HostConfig.Memory')" == "536870912" ]]
+  [[ "$(jq_s '.HostConfig.PidsLimit')" == "100" ]]
+  [[ "$(jq_s '.HostConfig.Ulimits[0].Hard')" == "2048" ]]
+  [[ "$(jq_s '.HostConfig.RestartPolicy.Name')" == "on-failure" ]]
+  [[ "$(jq_s '.HostConfig.RestartPolicy.MaximumRetryCount')" == "3" ]]
+}
+
+@test "engine-api: networking config and attached streams" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format engine-api -it --network app --network-alias web --ip 10.0.0.2 --gpus '"capabilities=compute,utility",count=2' alpine:3.20 sh
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.OpenStdin')" == "true" ]]
+  [[ "$(jq_s '.AttachStdin')" == "true" ]]
+  [[ "$(jq_s '.StdinOnce')" == "true" ]]
+  [[ "$(jq_s '.AttachStdout')" == "true" ]]
+  [[ "$(jq_s '.HostConfig.NetworkMode')" == "app" ]]
+  [[ "$(jq_s '.NetworkingConfig.EndpointsConfig.app.Aliases[0]')" == "web" ]]
+  [[ "$(jq_s '.NetworkingConfig.EndpointsConfig.app.IPAMConfig.IPv4Address')" == "10.0.0.2" ]]
+  [[ "$(jq_s '.HostConfig.DeviceRequests[0].Count')" == "2" ]]
+  [[ "$(jq_s '.HostConfig.DeviceRequests[0].Capabilities[0] | join(",")')" == "compute,utility,gpu" ]]
+}
+
+@test "engine-api: client-side flags warn" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format engine-api --name web --platform linux/amd64 --env-file app.env --pull always alpine:3.20
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --name in engine api payload as it is sent as the name query parameter"* ]]
+  [[ "$output" == *"unable to set --platform in engine api payload as it is sent as the platform query parameter"* ]]
+  [[ "$output" == *"unable to set --env-file app.env in engine api payload as the file is read by the docker cli"* ]]
+  [[ "$output" == *"unable to set --pull property in engine api payload as the image is pulled by the docker cli"* ]]
+}
+
+@test "engine-api: rejected combinations fail" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format engine-api --rm --restart always alpine:3.20
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set both --restart and --rm in engine api payload"* ]]
+
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format engine-api --network-alias web alpine:3.20
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"without a user-defined --network"* ]]
+}
+
+# OCI Runtime
+
+@test "oci-runtime: process, mounts, and resources" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format oci-runtime --cap-drop ALL --cap-add net_bind_service --user 1000:1000 --group-add 2000 -t --tmpfs /tmp:size=64m --read-only -v /srv/data:/data:ro --memory 268435456 --cpus 0.5 --pids-limit 100 --sysctl net.core.somaxconn=1024 -l app=web --security-opt no-new-privileges alpine:3.20 sh
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.ociVersion')" == "1.2.0" ]]
+  [[ "$(jq_s '.process.args | join(" ")')" == "sh" ]]
+  [[ "$(jq_s '.process.terminal')" == "true" ]]
+  [[ "$(jq_s '.process.env[1]')" == "TERM=xterm" ]]
+  [[ "$(jq_s '.process.user.uid')" == "1000" ]]
+  [[ "$(jq_s '.process.user.additionalGids[0]')" == "2000" ]]
+  [[ "$(jq_s '.process.capabilities.bounding | join(",")')" == "CAP_NET_BIND_SERVICE" ]]
+  [[ "$(jq_s '.process.noNewPrivileges')" == "true" ]]
+  [[ "$(jq_s '.root.readonly')" == "true" ]]
+  [[ "$(jq_s '.mounts[] | select(.destination == "/tmp") | .options | join(",")')" == "nosuid,nodev,noexec,size=64m" ]]
+  [[ "$(jq_s '.mounts[] | select(.destination == "/data") | .options | join(",")')" == "rbind,ro,rprivate" ]]
+  [[ "$(jq_s '.annotations.app')" == "web" ]]
+  [[ "$(jq_s '.linux.sysctl["net.core.somaxconn"]')" == "1024" ]]
+  [[ "$(jq_s '.linux.resources.memory.swap')" == "536870912" ]]
+  [[ "$(jq_s '.linux.resources.cpu.quota')" == "50000" ]]
+  [[ "$(jq_s '.linux.resources.pids.limit')" == "100" ]]
+}
+
+@test "oci-runtime: host namespaces and device throttles" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format oci-runtime --network host --pid host --device-read-bps /dev/sda:1mb --blkio-weight-device /dev/sda:200 alpine:3.20 sh
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '[.linux.namespaces[].type] | join(",")')" == "ipc,uts,mount,cgroup" ]]
+  [[ "$(jq_s '.linux.resources.blockIO.throttleReadBpsDevice[0].major')" == "0" ]]
+  [[ "$(jq_s '.linux.resources.blockIO.throttleReadBpsDevice[0].minor')" == "0" ]]
+  [[ "$(jq_s '.linux.resources.blockIO.throttleReadBpsDevice[0].rate')" == "1048576" ]]
+  [[ "$(jq_s '.linux.resources.blockIO.weightDevice[0].weight')" == "200" ]]
+  [[ "$output" == *"--device-read-bps /dev/sda:1mb is set on the placeholder device number 0:0 in oci runtime config"* ]]
+  [[ "$output" == *"--blkio-weight-device /dev/sda:200 is set on the placeholder device number 0:0 in oci runtime config"* ]]
+}
+
+@test "oci-runtime: daemon features warn" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format oci-runtime -v data:/data -p 8080:80 --restart always alpine:3.20
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set process.args in oci runtime config as the image command is not known"* ]]
+  [[ "$output" == *"unable to set --volume data:/data in oci runtime config as volumes are created by the docker daemon"* ]]
+  [[ "$output" == *"unable to set --publish and --expose properties in oci runtime config"* ]]
+  [[ "$output" == *"unable to set --restart property in oci runtime config as the property is not supported"* ]]
+}
+
+@test "oci-runtime: conflicting cpu flags fail" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format oci-runtime --cpus 1 --cpu-quota 50000 alpine:3.20 sh
+  [[ "$status" -eq 1 ]]
+  [[ "$output" == *"unable to set --cpus with --cpu-period or --cpu-quota in oci runtime config"* ]]
+}
+
+# Helm
+
+@test "helm: chart files on stdout" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format helm --dre-project myapp -p 8080:80 nginx:1.27
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"# Source: myapp/Chart.yaml"* ]]
+  [[ "$output" == *"# Source: myapp/values.yaml"* ]]
+  [[ "$output" == *"# Source: myapp/templates/deployment.yaml"* ]]
+  [[ "$output" == *"# Source: myapp/templates/service.yaml"* ]]
+  [[ "$output" == *"image: \"{{ .Values.image.repository }}"* ]]
+}
+
+@test "helm: values from docker run flags" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format helm --dre-project myapp --dre-output-dir "$BATS_TEST_TMPDIR/myapp" -e FOO=bar -p 8080:80 --cpus 0.5 --health-cmd "curl -f localhost" --health-interval 10s -v data:/data ghcr.io/acme/app:1.2.3
+  [[ "$status" -eq 0 ]]
+  [[ -f "$BATS_TEST_TMPDIR/myapp/templates/deployment.yaml" ]]
+  [[ -f "$BATS_TEST_TMPDIR/myapp/templates/service.yaml" ]]
+  [[ "$(yq '.name' "$BATS_TEST_TMPDIR/myapp/Chart.yaml")" == "myapp" ]]
+  [[ "$(yq '.appVersion' "$BATS_TEST_TMPDIR/myapp/Chart.yaml")" == "1.2.3" ]]
+  values="$BATS_TEST_TMPDIR/myapp/values.yaml"
+  [[ "$(yq '.image.repository' "$values")" == "ghcr.io/acme/app" ]]
+  [[ "$(yq '.image.tag' "$values")" == "1.2.3" ]]
+  [[ "$(yq '.env[0].value' "$values")" == "bar" ]]
+  [[ "$(yq '.service.enabled' "$values")" == "true" ]]
+  [[ "$(yq '.service.ports[0].port' "$values")" == "8080" ]]
+  [[ "$(yq '.resources.limits.cpu' "$values")" == "0.5" ]]
+  [[ "$(yq '.livenessProbe.periodSeconds' "$values")" == "10" ]]
+  [[ "$(yq '.volumes[0].persistentVolumeClaim.claimName' "$values")" == "data" ]]
+}
+
+@test "helm: image digest and unsupported pod settings" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format helm --dre-output-dir "$BATS_TEST_TMPDIR/chart" --hostname web redis@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set hostname in helm chart as the templates do not support it"* ]]
+  [[ "$(yq '.image.repository' "$BATS_TEST_TMPDIR/chart/values.yaml")" == "redis" ]]
+  [[ "$(yq '.image.digest' "$BATS_TEST_TMPDIR/chart/values.yaml")" == "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" ]]
+  [[ "$(yq '.service.enabled' "$BATS_TEST_TMPDIR/chart/values.yaml")" == "false" ]]
+}
+
+# Kustomize
+
+@test "kustomize: base and overlay on stdout" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kustomize --dre-project myapp -p 8080:80 nginx:1.27
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"# Source: base/kustomization.yaml"* ]]
+  [[ "$output" == *"# Source: base/deployment.yaml"* ]]
+  [[ "$output" == *"# Source: base/service.yaml"* ]]
+  [[ "$output" == *"# Source: overlays/production/kustomization.yaml"* ]]
+  [[ "$output" == *"# Source: overlays/production/deployment-patch.yaml"* ]]
+}
+
+@test "kustomize: env moves to a configmap generator" {
+  mkdir -p "$BATS_TEST_TMPDIR/config"
+  printf 'DB_HOST=db\n' > "$BATS_TEST_TMPDIR/config/app.env"
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kustomize --dre-project myapp --dre-output-dir "$BATS_TEST_TMPDIR/deploy" -e FOO=bar --env-file "$BATS_TEST_TMPDIR/config/app.env" nginx:1.27
+  [[ "$status" -eq 0 ]]
+  [[ "$output" != *"--env-file"* ]]
+  base="$BATS_TEST_TMPDIR/deploy/base"
+  [[ "$(yq '.configMapGenerator[0].name' "$base/kustomization.yaml")" == "myapp-env" ]]
+  [[ "$(yq '.configMapGenerator[0].literals[0]' "$base/kustomization.yaml")" == "FOO=bar" ]]
+  [[ "$(yq '.configMapGenerator[0].envs[0]' "$base/kustomization.yaml")" == "app.env" ]]
+  [[ -f "$base/app.env" ]]
+  [[ "$(cat "$base/app.env")" == "DB_HOST=db" ]]
+  [[ "$(yq '.spec.template.spec.containers[0].envFrom[0].configMapRef.name' "$base/deployment.yaml")" == "myapp-env" ]]
+  [[ "$(yq '.spec.template.spec.containers[0].env' "$base/deployment.yaml")" == "null" ]]
+  [[ ! -f "$base/service.yaml" ]]
+}
+
+@test "kustomize: unreadable env file warns" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kustomize --env-file "$BATS_TEST_TMPDIR/missing.env" nginx:1.27
+  [[ "$status" -eq 0 ]]
+  [[ "$output" == *"unable to set --env-file $BATS_TEST_TMPDIR/missing.env in kustomize base as the file could not be read"* ]]
+  [[ "$output" != *"envs:"* ]]
+}
+
+@test "kustomize: overlay patch holds resources and replicas" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format kustomize --dre-output-dir "$BATS_TEST_TMPDIR/deploy" --dre-kustomize-overlay staging --cpus 0.5 --memory 536870912 nginx:1.27
+  [[ "$status" -eq 0 ]]
+  overlay="$BATS_TEST_TMPDIR/deploy/overlays/staging"
+  [[ "$(yq '.resources[0]' "$overlay/kustomization.yaml")" == "../../base" ]]
+  [[ "$(yq '.patches[0].path' "$overlay/kustomization.yaml")" == "deployment-patch.yaml" ]]
+  [[ "$(yq '.spec.replicas' "$overlay/deployment-patch.yaml")" == "1" ]]
+  [[ "$(yq '.spec.template.spec.containers[0].resources.limits.cpu' "$overlay/deployment-patch.yaml")" == "0.5" ]]
+  [[ "$(yq '.spec.replicas' "$BATS_TEST_TMPDIR/deploy/base/deployment.yaml")" == "null" ]]
+  [[ "$(yq '.spec.template.spec.containers[0].resources' "$BATS_TEST_TMPDIR/deploy/base/deployment.yaml")" == "null" ]]
+}
+
 # ==========================================
 # ECS Task Definition Tests
 # ==========================================
@@ -1669,6 +2936,20 @@ nomad_validate_json() {
   [[ "$(jq_s '.Job.TaskGroups[0].Count')" == "3" ]]
 }
 
+@test "nomad-json specific: schedule" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format nomad-json --dre-nomad-type batch --dre-schedule "0 3 * * *" alpine:latest
+  [[ "$status" -eq 0 ]]
+  [[ "$(jq_s '.Job.Periodic.Enabled')" == "true" ]]
+  [[ "$(jq_s '.Job.Periodic.SpecType')" == "cron" ]]
+  [[ "$(jq_s '.Job.Periodic.Spec')" == "0 3 * * *" ]]
+}
+
+@test "nomad-json specific: schedule requires batch" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format nomad-json --dre-schedule @daily alpine:latest
+  [[ "$status" -ne 0 ]]
+  [[ "$output" == *"only batch jobs can be periodic"* ]]
+}
+
 # Nomad HCL Output
 
 @test "nomad hcl: basic structure" {
@@ -2117,6 +3398,13 @@ nomad_validate_json() {
   nomad_validate_hcl
 }
 
+@test "nomad validate: hcl with periodic block" {
+  run $DOCKER_RUN_EXPORT_BIN run --dre-format nomad --dre-project nightly \
+    --dre-nomad-type batch --dre-schedule "0 3 * * *" alpine:latest
+  [[ "$status" -eq 0 ]]
+  nomad_validate_hcl
+}
+
 @test "nomad validate: hcl with gpus" {
   run $DOCKER_RUN_EXPORT_BIN run --dre-format nomad --dre-project gpuapp \
     --gpus 2 nvidia/cuda:latest